@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ybotet/pz12-notes-api/internal/config"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+// runConfigCommand выполняет подкоманду config и возвращает код выхода.
+// config print [флаги] выводит действующую конфигурацию со скрытыми секретами.
+// Неверная конфигурация тоже выводится, а ошибки проверки сообщаются после нее.
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "использование: api config print [флаги]")
+		return 2
+	}
+
+	cfg, err := config.Parse(os.Args[0]+" config print", args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		if errors.Is(err, config.ErrUsage) {
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "неверная конфигурация:\n%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadValidationRules читает правила из файла или возвращает встроенные
+func loadValidationRules(path string) (validation.Rules, error) {
+	if path == "" {
+		return validation.DefaultRules(), nil
+	}
+	return validation.LoadRules(path)
+}
+
+// watchReload по SIGHUP перечитывает конфигурацию из тех же источников
+// (файл, окружение и флаги args) и передает ее в apply. На лету меняются
+// только поля с тегом reload; об остальных изменениях выводится
+// предупреждение. Возвращается после отмены ctx.
+func watchReload(ctx context.Context, current *config.Config, args []string, apply func(*config.Config) error) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+
+		updated, err := config.Load(os.Args[0], args)
+		if err != nil {
+			slog.Error("конфигурация не перезагружена", slog.Any("error", err))
+			continue
+		}
+		if err := apply(updated); err != nil {
+			slog.Error("конфигурация не перезагружена", slog.Any("error", err))
+			continue
+		}
+
+		reloaded, restart := config.Diff(current, updated)
+		current.ApplyReloadable(updated)
+		slog.Info("конфигурация перезагружена", slog.Any("changed", reloaded))
+		if len(restart) > 0 {
+			slog.Warn("изменения вступят в силу после перезапуска", slog.Any("keys", restart))
+		}
+	}
+}
+
+// displayAddr превращает адрес прослушивания в адрес для ссылок в логе
+func displayAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" || host == "0.0.0.0" || host == "::" {
+		return net.JoinHostPort("localhost", port)
+	}
+	return addr
+}
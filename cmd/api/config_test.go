@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/config"
+)
+
+// writeConfig записывает файл конфигурации в формате YAML
+func writeConfig(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sighup отправляет процессу SIGHUP, пока apply не получит конфигурацию:
+// первый сигнал может прийти раньше, чем watchReload подпишется на него
+func sighup(t *testing.T, applied <-chan *config.Config) *config.Config {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case cfg := <-applied:
+			return cfg
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	t.Fatal("конфигурация не перезагружена по SIGHUP")
+	return nil
+}
+
+func TestWatchReload(t *testing.T) {
+	// Пока тест подписан на SIGHUP, сигнал не завершает процесс
+	guard := make(chan os.Signal, 16)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "server:\n  addr: \":8080\"\nadmin:\n  token: old\n")
+	args := []string{"-config", path}
+	current, err := config.Load("api", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const rejectToken = "rejected"
+	applied := make(chan *config.Config, 16)
+	apply := func(updated *config.Config) error {
+		applied <- updated
+		if updated.Admin.Token == rejectToken {
+			return errors.New("отклонено")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watchReload(ctx, current, args, apply)
+		close(done)
+	}()
+
+	writeConfig(t, path, "server:\n  addr: \":9090\"\nadmin:\n  token: new\n")
+	if got := sighup(t, applied); got.Admin.Token != "new" || got.Server.Addr != ":9090" {
+		t.Errorf("apply() получил token = %q, addr = %q", got.Admin.Token, got.Server.Addr)
+	}
+
+	// Конфигурация, отклоненная apply, не применяется. Повторные сигналы
+	// могли перезагрузить и прежний файл, поэтому ждем именно новый.
+	writeConfig(t, path, "admin:\n  token: "+rejectToken+"\n")
+	for sighup(t, applied).Admin.Token != rejectToken {
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchReload не завершился после отмены контекста")
+	}
+	// Поле с тегом reload обновлено, адрес сервера — только после перезапуска
+	if current.Admin.Token != "new" || current.Server.Addr != ":8080" {
+		t.Errorf("после перезагрузки token = %q, addr = %q, want new, :8080", current.Admin.Token, current.Server.Addr)
+	}
+}
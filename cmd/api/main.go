@@ -16,17 +16,38 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	// _ "pz12-notes-api/docs"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"github.com/ybotet/pz12-notes-api/internal/backup"
+	"github.com/ybotet/pz12-notes-api/internal/blob"
+	"github.com/ybotet/pz12-notes-api/internal/config"
+	"github.com/ybotet/pz12-notes-api/internal/core"
 	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/health"
+	apihttp "github.com/ybotet/pz12-notes-api/internal/http"
 	"github.com/ybotet/pz12-notes-api/internal/http/handlers"
+	"github.com/ybotet/pz12-notes-api/internal/imaging"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+	"github.com/ybotet/pz12-notes-api/internal/metrics"
+	"github.com/ybotet/pz12-notes-api/internal/ratelimit"
+	"github.com/ybotet/pz12-notes-api/internal/reminder"
 	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/tracing"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
 )
 
 // Intenta importar docs solo si existen
@@ -41,78 +62,265 @@ import (
 // }()
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if errors.Is(err, config.ErrUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
+	}
+
+	// Журнал; уровень меняется через /api/v1/admin/log-level и по SIGHUP.
+	// Стандартный log тоже пишет через него.
+	logLevel := new(slog.LevelVar)
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	logLevel.Set(level)
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
+	}
+	slog.SetDefault(logger)
+
+	// SIGINT и SIGTERM останавливают сервер и фоновые задачи;
+	// повторный сигнал во время остановки завершает процесс сразу
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stopSignals()
+	}()
+	var background sync.WaitGroup
+
 	// Crear repositorio
+	linkRepo := repo.NewLinkRepoMem()
+	attachmentRepo := repo.NewAttachmentRepoMem()
+	templateRepo := repo.NewTemplateRepoMem()
+	commentRepo := repo.NewCommentRepoMem()
 	repo := repo.NewNoteRepoMem()
 
-	// Crear servicio
-	noteService := service.NewNoteService(repo)
+	// Трассировка OpenTelemetry; хранилище и сервис заметок оборачиваются
+	// декораторами, чтобы их вызовы были видны дочерними спанами запроса
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		ServiceName: cfg.Tracing.ServiceName,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		fatal("не удалось настроить трассировку", err)
+	}
+	noteRepo := tracing.TraceNoteRepository(repo)
 
-	// Crear handlers
-	handler := handlers.NewHandler(noteService)
+	// Хранилище содержимого вложений
+	blobStore, err := blob.NewFSStore(cfg.Storage.BlobDir)
+	if err != nil {
+		fatal("не удалось открыть хранилище вложений", err)
+	}
 
-	// Crear router
-	r := chi.NewRouter()
-
-	// Middlewares
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.RequestID)
-
-	// Rutas de la API
-	r.Route("/api/v1/notes", func(r chi.Router) {
-		r.Get("/", handler.GetAllNotes)
-		r.Post("/", handler.CreateNote)
-		r.Route("/{id}", func(r chi.Router) {
-			r.Get("/", handler.GetNote)
-			r.Put("/", handler.UpdateNote)
-			r.Delete("/", handler.DeleteNote)
+	// Правила валидации полей; по умолчанию встроенные
+	rules, err := loadValidationRules(cfg.Validation.RulesFile)
+	if err != nil {
+		fatal("не удалось загрузить правила валидации", err)
+	}
+	validator := validation.New(rules)
+
+	// Токен административных маршрутов меняется при перезагрузке конфигурации
+	var adminToken atomic.Value
+	adminToken.Store(cfg.Admin.Token)
+
+	// Токены пользователей API; также меняются при перезагрузке
+	var userTokens atomic.Value
+	users, _ := config.ParseUserTokens(cfg.Auth.Tokens)
+	userTokens.Store(users)
+
+	// Crear servicio
+	noteService := tracing.TraceNoteService(service.NewNoteService(noteRepo, linkRepo, commentRepo, validator))
+
+	// Метрики Prometheus; сервис заметок измеряется для всех вызывающих,
+	// включая планировщик напоминаний
+	var serverMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		serverMetrics = metrics.New()
+		serverMetrics.RegisterGauge("repository", "notes", "Число заметок в хранилище.", func() float64 {
+			return float64(repo.Len())
 		})
+		noteService = metrics.InstrumentNoteService(noteService, serverMetrics)
+	}
+	templateService := service.NewTemplateService(templateRepo, validator)
+	commentService := service.NewCommentService(noteRepo, commentRepo, validator)
+	commentService.OnMention(func(ctx context.Context, m core.Mention) error {
+		logging.FromContext(ctx).InfoContext(ctx, "упоминание в комментарии",
+			slog.String("mentioned", m.UserID),
+			slog.Int64("comment_id", m.CommentID),
+			slog.Int64("note_id", m.NoteID),
+			slog.String("author", m.AuthorID),
+		)
+		return nil
 	})
+	workers := cfg.Attachments.ImageWorkers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	imagePool := imaging.NewPool(workers, cfg.Attachments.ImageQueue)
+	attachmentService := service.NewAttachmentService(noteRepo, attachmentRepo, blobStore, cfg.Attachments.Quota, imagePool)
+	noteService.OnDelete(attachmentService.DeleteNoteAttachments)
 
-	// Ruta de salud
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "ok", "service": "notes-api"}`))
-	})
+	// Периодически удалять вложения, оставшиеся без заметок, и блобы без ссылок
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		ticker := time.NewTicker(cfg.Attachments.GCInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if removed, err := attachmentService.CollectGarbage(ctx); err != nil {
+				logger.Error("ошибка очистки вложений", slog.Any("error", err))
+			} else if removed > 0 {
+				logger.Info("удалены неиспользуемые блобы", slog.Int("removed", removed))
+			}
+		}
+	}()
 
-	// Ruta de Swagger UI (condicional)
-	if _, err := os.Stat("docs/swagger.json"); err == nil {
-		// Configurar Swagger UI manualmente (sin importar http-swagger)
-		r.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
-			http.Redirect(w, r, "/docs/index.html", http.StatusMovedPermanently)
-		})
+	// Планировщик напоминаний восстанавливает расписание из хранилища
+	// при запуске и получает изменения заметок через обработчики сервиса
+	scheduler := reminder.NewScheduler(noteService.GetAllNotes, func(ctx context.Context, ev reminder.Event) error {
+		logging.FromContext(ctx).InfoContext(ctx, "напоминание",
+			slog.Int64("note_id", ev.NoteID),
+			slog.String("title", ev.Title),
+			slog.Time("at", ev.At),
+		)
+		return noteService.CompleteReminder(ctx, ev.NoteID, ev.At)
+	}, cfg.Reminders.Resync)
+	noteService.OnSave(scheduler.Schedule)
+	noteService.OnDelete(scheduler.Cancel)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		scheduler.Run(ctx)
+	}()
 
-		r.Get("/docs/*", func(w http.ResponseWriter, r *http.Request) {
-			http.StripPrefix("/docs", http.FileServer(http.Dir("docs"))).ServeHTTP(w, r)
-		})
+	// Проверки готовности зависимостей для /readyz
+	healthChecks := health.NewRegistry(cfg.Health.Timeout, cfg.Health.CacheTTL)
+	healthChecks.Register("notes_repo", repo)
+	healthChecks.Register("blob_store", blobStore)
+	healthChecks.Register("image_pool", imagePool)
+	healthChecks.Register("reminder_scheduler", scheduler)
+
+	// Лимиты частоты запросов; меняются при перезагрузке конфигурации
+	var rateLimits atomic.Pointer[ratelimit.Rules]
+	limits, err := ratelimit.ParseRules(cfg.RateLimit.Default, cfg.RateLimit.Routes)
+	if err != nil {
+		fatal("неверные лимиты частоты запросов", err)
+	}
+	rateLimits.Store(limits)
 
-		log.Println("📚 Swagger UI disponible en http://localhost:8081/docs/index.html")
+	// Crear router
+	opts := []apihttp.Option{
+		apihttp.WithHealth(healthChecks),
+		apihttp.WithTracing(),
+		apihttp.WithLogger(logger),
+		apihttp.WithMaxJSONBody(cfg.Server.MaxJSONBody),
+		apihttp.WithIdempotency(apihttp.NewIdempotencyStoreMem(cfg.Idempotency.TTL), cfg.Idempotency.LockTimeout, cfg.Idempotency.MaxBody),
+		apihttp.WithUsers(func() map[string]string { return userTokens.Load().(map[string]string) }, cfg.Auth.TrustUserHeader),
+	}
+	if cfg.Admin.Enabled {
+		opts = append(opts, apihttp.WithAdminToken(func() string { return adminToken.Load().(string) }))
+	}
+	if serverMetrics != nil {
+		opts = append(opts, apihttp.WithMetrics(serverMetrics))
+	}
+	if origins := config.SplitList(cfg.CORS.AllowedOrigins); len(origins) > 0 {
+		opts = append(opts, apihttp.WithCORS(apihttp.CORSOptions{
+			AllowedOrigins:   origins,
+			AllowedMethods:   config.SplitList(cfg.CORS.AllowedMethods),
+			AllowedHeaders:   config.SplitList(cfg.CORS.AllowedHeaders),
+			ExposedHeaders:   config.SplitList(cfg.CORS.ExposedHeaders),
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		}))
+	}
+	if cfg.Compression.Enabled {
+		opts = append(opts, apihttp.WithCompression(cfg.Compression.MinSize))
+	}
+	if cfg.RateLimit.Enabled {
+		opts = append(opts, apihttp.WithRateLimit(ratelimit.NewMemoryStore(), rateLimits.Load))
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Server.DocsDir, "swagger.json")); err == nil {
+		opts = append(opts, apihttp.WithDocs(cfg.Server.DocsDir))
+		logger.Info("документация Swagger доступна", slog.String("url", "http://"+displayAddr(cfg.Server.Addr)+"/docs/index.html"))
 	} else {
-		log.Println("⚠️  Swagger UI no disponible: ejecuta 'swag init' primero")
-	}
-
-	// Ruta de ReDoc (alternativa)
-	r.Get("/redoc", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		w.Write([]byte(`
-            <!DOCTYPE html>
-            <html>
-            <head>
-                <title>Notes API - ReDoc</title>
-                <meta charset="utf-8"/>
-                <meta name="viewport" content="width=device-width, initial-scale=1">
-            </head>
-            <body>
-                <redoc spec-url='/docs/swagger.json'></redoc>
-                <script src="https://cdn.jsdelivr.net/npm/redoc@next/bundles/redoc.standalone.js"></script>
-            </body>
-            </html>
-        `))
+		logger.Warn("документация Swagger не сгенерирована, выполните swag init")
+	}
+	r := apihttp.NewRouter(apihttp.Handlers{
+		Notes: handlers.NewHandler(noteService, templateService),
+		Admin: handlers.NewAdminHandler(backup.Store{
+			Notes:       noteRepo,
+			Comments:    commentRepo,
+			Attachments: attachmentRepo,
+			Templates:   templateRepo,
+			Blobs:       blobStore,
+		}, noteService),
+		Attachments: handlers.NewAttachmentHandler(attachmentService, cfg.Attachments.MaxSize),
+		Templates:   handlers.NewTemplateHandler(templateService),
+		Comments:    handlers.NewCommentHandler(commentService),
+		LogLevel:    handlers.NewLogLevelHandler(logLevel),
+	}, opts...)
+
+	// Перезагрузка безопасной части конфигурации по SIGHUP
+	// Уровень журнала меняется, только если он изменен в конфигурации,
+	// чтобы не сбрасывать уровень, заданный через API
+	configLevel := cfg.Log.Level
+	go watchReload(ctx, cfg, os.Args[1:], func(updated *config.Config) error {
+		rules, err := loadValidationRules(updated.Validation.RulesFile)
+		if err != nil {
+			return err
+		}
+		limits, err := ratelimit.ParseRules(updated.RateLimit.Default, updated.RateLimit.Routes)
+		if err != nil {
+			return err
+		}
+		users, err := config.ParseUserTokens(updated.Auth.Tokens)
+		if err != nil {
+			return err
+		}
+		validator.SetRules(rules)
+		adminToken.Store(updated.Admin.Token)
+		userTokens.Store(users)
+		rateLimits.Store(limits)
+		if updated.Log.Level != configLevel {
+			level, _ := logging.ParseLevel(updated.Log.Level)
+			logLevel.Set(level)
+			configLevel = updated.Log.Level
+		}
+		return nil
 	})
 
 	// Iniciar servidor
-	log.Println("🚀 Сервер запущен на http://localhost:8081")
-	log.Println("📝 API доступен по адресу http://localhost:8081/api/v1/notes")
-	log.Fatal(http.ListenAndServe(":8081", r))
+	logger.Info("сервер запущен",
+		slog.String("addr", cfg.Server.Addr),
+		slog.String("api", "http://"+displayAddr(cfg.Server.Addr)+"/api/v1/notes"),
+	)
+	// Сначала завершаются запросы, затем фоновые задачи и очередь
+	// обработки изображений, чтобы миниатюры не остались недописанными;
+	// последними отправляются накопленные спаны
+	srv := newServer(cfg.Server, r)
+	code := serve(ctx, srv, cfg.Server, healthChecks.Shutdown,
+		func(ctx context.Context) error { return waitGroup(ctx, &background) },
+		imagePool.Shutdown,
+		shutdownTracing,
+	)
+	os.Exit(code)
 }
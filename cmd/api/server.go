@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/config"
+)
+
+// Коды выхода процесса
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitShutdown = 3 // остановка не уложилась в отведенное время
+)
+
+// newServer создает HTTP-сервер с таймаутами из конфигурации
+func newServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// serve обслуживает запросы до отмены ctx, затем останавливает сервер:
+// снимает готовность через notReady и ждет cfg.ShutdownDelay, чтобы
+// балансировщик успел это заметить, прекращает прием соединений,
+// дожидается текущих запросов и выполняет stop по порядку. На каждый
+// этап отводится cfg.ShutdownTimeout, чтобы задержка запросов не лишала
+// фоновые задачи времени на завершение. Возвращает код выхода.
+func serve(ctx context.Context, srv *http.Server, cfg config.ServerConfig, notReady func(), stop ...func(context.Context) error) int {
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-serverErr:
+		slog.Error("ошибка сервера", slog.Any("error", err))
+		return exitError
+	case <-ctx.Done():
+	}
+
+	notReady()
+	if cfg.ShutdownDelay > 0 {
+		slog.Info("сервер снят с балансировки, ожидание перед остановкой", slog.Duration("delay", cfg.ShutdownDelay))
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	timeout := cfg.ShutdownTimeout
+	slog.Info("остановка сервера, ожидание текущих запросов", slog.Duration("timeout", timeout))
+	code := exitOK
+	if err := withTimeout(timeout, srv.Shutdown); err != nil {
+		slog.Error("не все запросы завершены, соединения закрыты принудительно", slog.Any("error", err))
+		srv.Close()
+		code = exitShutdown
+	}
+	for _, fn := range stop {
+		if err := withTimeout(timeout, fn); err != nil {
+			slog.Error("ошибка остановки фоновых задач", slog.Any("error", err))
+			if errors.Is(err, context.DeadlineExceeded) {
+				code = exitShutdown
+			} else if code == exitOK {
+				code = exitError
+			}
+		}
+	}
+
+	if code == exitOK {
+		slog.Info("сервер остановлен")
+	}
+	return code
+}
+
+// fatal записывает ошибку запуска в журнал и завершает процесс
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.Any("error", err))
+	os.Exit(exitError)
+}
+
+// withTimeout вызывает fn с контекстом, ограниченным timeout
+func withTimeout(timeout time.Duration, fn func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// waitGroup дожидается wg, но не дольше, чем живет ctx
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/config"
+)
+
+// freeAddr возвращает свободный локальный адрес для сервера
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// waitListening ждет, пока сервер начнет принимать соединения
+func waitListening(t *testing.T, addr string) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return
+		}
+	}
+	t.Fatalf("сервер на %s не запустился", addr)
+}
+
+// runServe запускает serve в горутине; код выхода приходит в канал
+func runServe(ctx context.Context, cfg config.ServerConfig, handler http.Handler, notReady func(), stop ...func(context.Context) error) <-chan int {
+	code := make(chan int, 1)
+	go func() { code <- serve(ctx, newServer(cfg, handler), cfg, notReady, stop...) }()
+	return code
+}
+
+func TestServeFinishesInFlightRequests(t *testing.T) {
+	cfg := config.ServerConfig{Addr: freeAddr(t), ShutdownTimeout: 5 * time.Second}
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "готово")
+	})
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	stopStep := func(name string) func(context.Context) error {
+		return func(context.Context) error { record(name); return nil }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	code := runServe(ctx, cfg, handler, func() { record("notReady") }, stopStep("background"), stopStep("tracing"))
+	waitListening(t, cfg.Addr)
+
+	type result struct {
+		body string
+		err  error
+	}
+	resp := make(chan result, 1)
+	go func() {
+		r, err := http.Get("http://" + cfg.Addr + "/")
+		if err != nil {
+			resp <- result{err: err}
+			return
+		}
+		defer r.Body.Close()
+		body, err := io.ReadAll(r.Body)
+		resp <- result{string(body), err}
+	}()
+	<-started
+
+	cancel()
+	// Сервер не завершается, пока запрос не обработан
+	select {
+	case c := <-code:
+		t.Fatalf("serve() = %d до завершения запроса", c)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	if r := <-resp; r.err != nil || r.body != "готово" {
+		t.Errorf("ответ = %q, %v", r.body, r.err)
+	}
+	if c := <-code; c != exitOK {
+		t.Errorf("serve() = %d, want %d", c, exitOK)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"notReady", "background", "tracing"}; !slices.Equal(events, want) {
+		t.Errorf("порядок остановки = %v, want %v", events, want)
+	}
+	if _, err := net.Dial("tcp", cfg.Addr); err == nil {
+		t.Error("порт не закрыт после остановки")
+	}
+}
+
+func TestServeShutdownDelayKeepsServing(t *testing.T) {
+	cfg := config.ServerConfig{Addr: freeAddr(t), ShutdownTimeout: time.Second, ShutdownDelay: 300 * time.Millisecond}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	notReady := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	code := runServe(ctx, cfg, handler, func() { close(notReady) })
+	waitListening(t, cfg.Addr)
+	cancel()
+	<-notReady
+
+	// После снятия готовности порт открыт до истечения ShutdownDelay
+	r, err := http.Get("http://" + cfg.Addr + "/")
+	if err != nil {
+		t.Fatalf("запрос во время задержки: %v", err)
+	}
+	r.Body.Close()
+	if c := <-code; c != exitOK {
+		t.Errorf("serve() = %d, want %d", c, exitOK)
+	}
+}
+
+func TestServeExitCodes(t *testing.T) {
+	block := func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		request bool
+		stop    []func(context.Context) error
+		want    int
+	}{
+		{
+			name: "фоновая задача завершилась ошибкой",
+			stop: []func(context.Context) error{func(context.Context) error { return errors.New("сбой") }},
+			want: exitError,
+		},
+		{
+			name: "фоновая задача не уложилась во время",
+			stop: []func(context.Context) error{block},
+			want: exitShutdown,
+		},
+		{
+			name:    "запрос не уложился во время",
+			handler: func(w http.ResponseWriter, r *http.Request) { time.Sleep(time.Second) },
+			request: true,
+			want:    exitShutdown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.ServerConfig{Addr: freeAddr(t), ShutdownTimeout: 100 * time.Millisecond}
+			handler := http.Handler(http.NotFoundHandler())
+			if tt.handler != nil {
+				handler = tt.handler
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			code := runServe(ctx, cfg, handler, func() {}, tt.stop...)
+			waitListening(t, cfg.Addr)
+			if tt.request {
+				go http.Get("http://" + cfg.Addr + "/")
+				time.Sleep(50 * time.Millisecond)
+			}
+			cancel()
+
+			if c := <-code; c != tt.want {
+				t.Errorf("serve() = %d, want %d", c, tt.want)
+			}
+		})
+	}
+
+	t.Run("адрес занят", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		cfg := config.ServerConfig{Addr: l.Addr().String(), ShutdownTimeout: time.Second}
+		select {
+		case c := <-runServe(context.Background(), cfg, http.NotFoundHandler(), func() { t.Error("notReady при ошибке запуска") }):
+			if c != exitError {
+				t.Errorf("serve() = %d, want %d", c, exitError)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("serve() не вернулся при занятом адресе")
+		}
+	})
+}
+
+func TestWaitGroup(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := waitGroup(ctx, &wg); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waitGroup() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	wg.Done()
+	if err := waitGroup(context.Background(), &wg); err != nil {
+		t.Errorf("waitGroup() = %v, want nil", err)
+	}
+}
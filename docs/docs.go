@@ -18,27 +18,32 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/notes": {
+        "/api/v1/admin/export": {
             "get": {
-                "description": "Возвращает список всех заметок в системе",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает ZIP-архив со всеми заметками (JSON и Markdown), комментариями, вложениями с содержимым, шаблонами и манифестом с контрольными суммами SHA-256",
                 "produces": [
-                    "application/json"
+                    "application/zip"
                 ],
                 "tags": [
-                    "notes"
+                    "admin"
                 ],
-                "summary": "Получить все заметки",
+                "summary": "Резервная копия хранилища",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "ZIP-архив",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/core.Note"
-                            }
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
                         }
                     },
                     "500": {
@@ -48,35 +53,48 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/admin/import": {
             "post": {
-                "description": "Создает новую заметку с предоставленными данными",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Восстанавливает заметки из ZIP-архива, созданного /admin/export. Режим merge добавляет заметки к существующим, replace заменяет ими существующие вместе с их комментариями, вложениями и шаблонами. Архив и заметки проверяются до изменений. Заметки получают новые ID, ссылки [[#id]] между ними переписываются, комментарии и вложения привязываются к новым ID",
                 "consumes": [
-                    "application/json"
+                    "application/zip"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "admin"
                 ],
-                "summary": "Создать новую заметку",
+                "summary": "Восстановление из резервной копии",
                 "parameters": [
                     {
-                        "description": "Данные новой заметки",
-                        "name": "input",
+                        "type": "string",
+                        "description": "Режим восстановления: merge (по умолчанию) или replace",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "description": "ZIP-архив",
+                        "name": "archive",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/core.NoteCreateRequest"
+                            "type": "string"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/core.Note"
+                            "$ref": "#/definitions/core.BackupImportResponse"
                         }
                     },
                     "400": {
@@ -85,6 +103,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/core.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -94,9 +118,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/notes/{id}": {
+        "/api/v1/admin/log-level": {
             "get": {
-                "description": "Возвращает конкретную заметку по её ID",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает текущий уровень журнала сервера",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Уровень журнала",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.LogLevel"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет уровень журнала до перезапуска или перезагрузки конфигурации с другим log.level",
                 "consumes": [
                     "application/json"
                 ],
@@ -104,23 +162,90 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "admin"
                 ],
-                "summary": "Получить заметку по ID",
+                "summary": "Изменить уровень журнала",
+                "parameters": [
+                    {
+                        "description": "Новый уровень: debug, info, warn или error",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.LogLevel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.LogLevel"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/attachments/{id}": {
+            "get": {
+                "description": "Отдает содержимое вложения потоком; поддерживаются запросы Range и условные запросы. Типы, которые браузер может исполнить (HTML, SVG, скрипты), отдаются как application/octet-stream",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Скачать вложение",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID заметки",
+                        "description": "ID вложения",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Диапазон байт, например bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Содержимое вложения",
                         "schema": {
-                            "$ref": "#/definitions/core.Note"
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Часть содержимого",
+                        "schema": {
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -137,41 +262,83 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "Обновляет существующую заметку предоставленными данными (частичное обновление)",
-                "consumes": [
-                    "application/json"
+            "delete": {
+                "description": "Удаляет вложение; доступно только владельцу. Содержимое удаляется, если на него больше нет ссылок",
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Удалить вложение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID вложения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/attachments/{id}/thumb": {
+            "get": {
+                "description": "Возвращает уменьшенную копию изображения-вложения (JPEG, PNG, GIF). Ширина округляется вверх до одной из 64, 128, 256, 512, 1024; изображение не увеличивается",
                 "produces": [
-                    "application/json"
+                    "image/jpeg",
+                    "image/png"
                 ],
                 "tags": [
-                    "notes"
+                    "attachments"
                 ],
-                "summary": "Обновить существующую заметку",
+                "summary": "Миниатюра изображения",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID заметки",
+                        "description": "ID вложения",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Поля для обновления",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/core.NoteUpdateRequest"
-                        }
+                        "type": "integer",
+                        "description": "Желаемая ширина миниатюры",
+                        "name": "w",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Миниатюра",
                         "schema": {
-                            "$ref": "#/definitions/core.Note"
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -186,6 +353,82 @@ const docTemplate = `{
                             "$ref": "#/definitions/core.ErrorResponse"
                         }
                     },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes": {
+            "get": {
+                "description": "Возвращает список заметок; закрепленные заметки всегда идут первыми. Архивные заметки по умолчанию не возвращаются. С due_before или due_after возвращает только заметки со сроком в этом интервале, ближайшие сроки первыми",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Получить все заметки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Срок раньше указанного времени (RFC 3339 или ГГГГ-ММ-ДД)",
+                        "name": "due_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Срок позже указанного времени (RFC 3339 или ГГГГ-ММ-ДД)",
+                        "name": "due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "true — только архивные, all — все заметки; по умолчанию архивные скрыты",
+                        "name": "archived",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только закрепленные (true) или незакрепленные (false)",
+                        "name": "pinned",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только избранные (true) или остальные (false)",
+                        "name": "favorite",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Note"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -194,8 +437,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Удаляет конкретную заметку по её ID",
+            "post": {
+                "description": "Создает новую заметку с предоставленными данными. С параметром template заголовок и содержимое берутся из шаблона с подстановкой variables; явно переданный title заменяет заголовок шаблона",
                 "consumes": [
                     "application/json"
                 ],
@@ -205,24 +448,41 @@ const docTemplate = `{
                 "tags": [
                     "notes"
                 ],
-                "summary": "Удалить заметку",
+                "summary": "Создать новую заметку",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
                     {
                         "type": "integer",
-                        "description": "ID заметки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "ID шаблона",
+                        "name": "template",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Данные новой заметки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.NoteCreateRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/core.ErrorResponse"
+                            "$ref": "#/definitions/core.ValidationErrorResponse"
                         }
                     },
                     "404": {
@@ -231,17 +491,1882 @@ const docTemplate = `{
                             "$ref": "#/definitions/core.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
-                            "$ref": "#/definitions/core.ErrorResponse"
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
                         }
-                    }
-                }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/export/markdown": {
+            "get": {
+                "description": "Возвращает ZIP-архив, содержащий по одному Markdown-файлу на заметку",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Экспортировать все заметки в Markdown",
+                "responses": {
+                    "200": {
+                        "description": "ZIP-архив с Markdown-файлами",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/import/markdown": {
+            "post": {
+                "description": "Создает заметки из загруженных Markdown-файлов с YAML front matter (или ZIP-архивов с ними). Содержимое сохраняется как есть, ссылки [[#id]] между импортируемыми заметками переписываются на новые ID. Ошибки сообщаются по каждому файлу",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Импортировать заметки из Markdown",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Markdown-файлы или ZIP-архив",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.NoteImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/links/broken": {
+            "get": {
+                "description": "Возвращает все вики-ссылки, цель которых не найдена",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Битые ссылки",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.NoteLink"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}": {
+            "get": {
+                "description": "Возвращает конкретную заметку по её ID. С format=html или Accept: text/html возвращает содержимое, отрендеренное из Markdown в безопасный HTML",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Получить заметку по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Формат ответа: json (по умолчанию) или html",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет существующую заметку предоставленными данными (частичное обновление)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Обновить существующую заметку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Поля для обновления",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.NoteUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет конкретную заметку по её ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Удалить заметку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}.md": {
+            "get": {
+                "description": "Возвращает заметку в виде Markdown-файла с YAML front matter (id, title, tags, created_at, updated_at и остальные поля заметки)",
+                "produces": [
+                    "text/markdown"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Экспортировать заметку в Markdown",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Markdown-файл",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/archive": {
+            "put": {
+                "description": "Архивные заметки не показываются в списке по умолчанию; архивирование снимает закрепление",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Архивировать заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Возвращает заметку из архива",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Вернуть заметку из архива",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/attachments": {
+            "get": {
+                "description": "Возвращает метаданные всех вложений заметки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Вложения заметки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Attachment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Прикрепляет файл к заметке. Одинаковое содержимое хранится один раз; объем вложений пользователя ограничен квотой",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Загрузить вложение",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Файл",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор пользователя; учитывается только при auth.trust_user_header, иначе пользователь определяется по токену",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/core.Attachment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/backlinks": {
+            "get": {
+                "description": "Возвращает ссылки из других заметок, указывающие на данную",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Обратные ссылки на заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.NoteLink"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/comments": {
+            "get": {
+                "description": "Возвращает комментарии заметки деревом: ответы вложены в Replies. Удаленные комментарии с ответами остаются в ветке с Deleted=true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Комментарии заметки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Comment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет комментарий от имени аутентифицированного пользователя; с parent_id — ответ на комментарий. Упомянутые через @имя пользователи получают уведомление",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Добавить комментарий",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор пользователя; учитывается только при auth.trust_user_header, иначе пользователь определяется по токену",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст комментария",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.CommentCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/core.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/comments/{commentId}": {
+            "put": {
+                "description": "Изменяет текст комментария; доступно только автору",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Изменить комментарий",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор пользователя; учитывается только при auth.trust_user_header, иначе пользователь определяется по токену",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый текст",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.CommentUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ValidationErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет комментарий; доступно только автору. Если на комментарий есть ответы, он остается в ветке как удаленный",
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Удалить комментарий",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор пользователя; учитывается только при auth.trust_user_header, иначе пользователь определяется по токену",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID комментария",
+                        "name": "commentId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/favorite": {
+            "put": {
+                "description": "Отмечает заметку как избранную",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Добавить в избранное",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Снимает отметку избранного",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Убрать из избранного",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/items": {
+            "post": {
+                "description": "Добавляет пункт в конец заметки-списка",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checklist"
+                ],
+                "summary": "Добавить пункт списка",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст пункта",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.ChecklistItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/items/order": {
+            "put": {
+                "description": "Задает новый порядок пунктов; список должен содержать ID всех пунктов ровно один раз",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checklist"
+                ],
+                "summary": "Изменить порядок пунктов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый порядок пунктов",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.ChecklistReorderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/items/{itemId}": {
+            "delete": {
+                "description": "Удаляет пункт из заметки-списка",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checklist"
+                ],
+                "summary": "Удалить пункт списка",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пункта",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/items/{itemId}/toggle": {
+            "post": {
+                "description": "Переключает отметку выполнения пункта",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checklist"
+                ],
+                "summary": "Отметить пункт списка",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID пункта",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/outlinks": {
+            "get": {
+                "description": "Возвращает вики-ссылки ([[заголовок]] или [[#id]]) из содержимого заметки, включая битые",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Исходящие ссылки заметки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.NoteLink"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes/{id}/pin": {
+            "put": {
+                "description": "Закрепленные заметки всегда идут первыми в списке; закрепление возвращает заметку из архива",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Закрепить заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Снимает закрепление заметки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Открепить заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notes:batch": {
+            "post": {
+                "description": "Выполняет набор операций create/update/delete. При atomic=true применяются все операции или ни одной, иначе каждая выполняется независимо",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Пакетные операции над заметками",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Операции пакета",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.NoteBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.NoteBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/core.NoteBatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/templates": {
+            "get": {
+                "description": "Возвращает список всех шаблонов заметок",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Получить все шаблоны",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Template"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает шаблон заметки. Синтаксис шаблона проверяется при сохранении; циклы и вложенные шаблоны не поддерживаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Создать шаблон",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные шаблона",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.TemplateCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/core.Template"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ValidationErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/templates/{id}": {
+            "get": {
+                "description": "Возвращает шаблон заметки по его ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Получить шаблон по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID шаблона",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Template"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Частично обновляет шаблон заметки",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Обновить шаблон",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID шаблона",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Поля для обновления",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core.TemplateUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/core.Template"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/core.DecodeErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет шаблон; заметки, созданные по нему, не затрагиваются",
+                "tags": [
+                    "templates"
+                ],
+                "summary": "Удалить шаблон",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности для безопасного повтора запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID шаблона",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/core.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "core.Attachment": {
+            "description": "Метаданные вложения; содержимое хранится по хешу SHA-256",
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "noteID": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "ownerID": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "thumbnails": {
+                    "description": "Thumbnails сопоставляет ширину миниатюры с хешем её содержимого",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "width": {
+                    "description": "Width и Height заполняются после обработки изображения",
+                    "type": "integer"
+                }
+            }
+        },
+        "core.BackupIDMapping": {
+            "description": "Соответствие ID заметки в архиве и нового ID",
+            "type": "object",
+            "properties": {
+                "new": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "old": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "core.BackupImportResponse": {
+            "description": "Итог восстановления: режим, количество восстановленных записей и переназначенные ID заметок",
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "comments": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "deleted": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "id_map": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/core.BackupIDMapping"
+                    }
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "merge"
+                },
+                "templates": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "core.ChecklistItem": {
+            "description": "Пункт списка дел",
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "order": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "core.ChecklistItemCreateInput": {
+            "description": "Пункт списка дел",
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Купить молоко"
+                }
+            }
+        },
+        "core.ChecklistItemRequest": {
+            "description": "Текст добавляемого пункта списка",
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "example": "Позвонить в банк"
+                }
+            }
+        },
+        "core.ChecklistProgress": {
+            "description": "Прогресс выполнения списка (например, 3 из 7)",
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "core.ChecklistReorderRequest": {
+            "description": "ID всех пунктов списка в новом порядке",
+            "type": "object",
+            "properties": {
+                "item_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        1,
+                        2
+                    ]
+                }
+            }
+        },
+        "core.Comment": {
+            "description": "Комментарий; ответы на него вложены в Replies",
+            "type": "object",
+            "properties": {
+                "authorID": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "description": "Deleted означает, что комментарий удален, но у него остались ответы",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "mentions": {
+                    "description": "Mentions — пользователи, упомянутые через @имя",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "noteID": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "parentID": {
+                    "description": "ParentID указывает на комментарий, на который дан ответ",
+                    "type": "integer"
+                },
+                "replies": {
+                    "description": "Replies заполняется сервисом при построении ветки и не хранится",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/core.Comment"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
             }
-        }
-    },
-    "definitions": {
+        },
+        "core.CommentCreateRequest": {
+            "description": "Комментарий или ответ на комментарий; @имя в тексте упоминает пользователя",
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "@anna посмотри, пожалуйста"
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "core.CommentUpdateRequest": {
+            "description": "Новый текст комментария",
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "Исправленный текст"
+                }
+            }
+        },
+        "core.DecodeErrorResponse": {
+            "description": "Ошибка разбора JSON-тела запроса: поле и позиция в байтах, если известны",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "неизвестное поле"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "titel"
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 14
+                },
+                "trace_id": {
+                    "description": "TraceID — идентификатор трассировки запроса для поиска в журналах",
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
+                }
+            }
+        },
         "core.ErrorResponse": {
             "description": "Общий ответ об ошибке для API",
             "type": "object",
@@ -256,28 +2381,177 @@ const docTemplate = `{
                 }
             }
         },
+        "core.LogLevel": {
+            "description": "Уровень журнала: debug, info, warn или error",
+            "type": "object",
+            "properties": {
+                "level": {
+                    "type": "string",
+                    "example": "info"
+                }
+            }
+        },
         "core.Note": {
             "description": "Основная структура заметки",
             "type": "object",
             "properties": {
+                "archived": {
+                    "type": "boolean"
+                },
+                "commentCount": {
+                    "description": "CommentCount вычисляется сервисом для ответов API и не хранится",
+                    "type": "integer"
+                },
                 "content": {
                     "type": "string"
                 },
                 "createdAt": {
                     "type": "string"
                 },
+                "dueAt": {
+                    "type": "string"
+                },
+                "favorite": {
+                    "type": "boolean"
+                },
                 "id": {
                     "type": "integer",
                     "format": "int64"
                 },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/core.ChecklistItem"
+                    }
+                },
+                "pinned": {
+                    "description": "Закрепленные заметки всегда идут первыми, архивные скрыты из списка по умолчанию",
+                    "type": "boolean"
+                },
+                "progress": {
+                    "description": "Progress вычисляется сервисом для списков и не хранится",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.ChecklistProgress"
+                        }
+                    ]
+                },
+                "recurrence": {
+                    "type": "string"
+                },
+                "remindAt": {
+                    "description": "RemindAt — время ближайшего напоминания; снимается после срабатывания\nили переносится на следующее повторение по правилу Recurrence",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags — метки заметки без повторов без учета регистра",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "title": {
                     "type": "string"
                 },
+                "type": {
+                    "$ref": "#/definitions/core.NoteType"
+                },
                 "updatedAt": {
                     "type": "string"
                 }
             }
         },
+        "core.NoteBatchItemResult": {
+            "description": "Результат операции пакета с HTTP-подобным статусом",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "заметка не найдена"
+                },
+                "fields": {
+                    "description": "Fields перечисляет нарушения правил валидации полей",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.FieldError"
+                    }
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "type": "string",
+                    "example": "create"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 201
+                }
+            }
+        },
+        "core.NoteBatchOperation": {
+            "description": "Операция пакетного запроса: create, update или delete",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Новый текст"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "op": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Новый заголовок"
+                }
+            }
+        },
+        "core.NoteBatchRequest": {
+            "description": "Набор операций; при atomic=true применяются все или ни одной",
+            "type": "object",
+            "properties": {
+                "atomic": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/core.NoteBatchOperation"
+                    }
+                }
+            }
+        },
+        "core.NoteBatchResponse": {
+            "description": "Итог пакетной обработки с результатами по каждой операции",
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "atomic": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/core.NoteBatchItemResult"
+                    }
+                }
+            }
+        },
         "core.NoteCreateRequest": {
             "description": "Структура для создания новой заметки",
             "type": "object",
@@ -286,12 +2560,129 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Текст заметки"
                 },
+                "due_at": {
+                    "description": "Время в формате RFC 3339",
+                    "type": "string",
+                    "example": "2026-10-25T18:00:00Z"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/core.ChecklistItemCreateInput"
+                    }
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO,FR"
+                },
+                "remind_at": {
+                    "type": "string",
+                    "example": "2026-10-25T09:00:00Z"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "работа",
+                        "идеи"
+                    ]
+                },
                 "title": {
                     "type": "string",
                     "example": "Моя первая заметка"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "text",
+                        "checklist"
+                    ],
+                    "example": "text"
+                },
+                "variables": {
+                    "description": "Переменные для подстановки при создании заметки из шаблона",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "core.NoteImportItem": {
+            "description": "Результат импорта файла: новый ID заметки или ошибка",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "отсутствует front matter"
+                },
+                "file": {
+                    "type": "string",
+                    "example": "note-1.md"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "source_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "core.NoteImportResponse": {
+            "description": "Итог импорта с результатами по каждому файлу",
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/core.NoteImportItem"
+                    }
+                }
+            }
+        },
+        "core.NoteLink": {
+            "description": "Ссылка между заметками; Broken=true, если цель не найдена",
+            "type": "object",
+            "properties": {
+                "broken": {
+                    "type": "boolean"
+                },
+                "ref": {
+                    "type": "string"
+                },
+                "sourceID": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "targetID": {
+                    "type": "integer",
+                    "format": "int64"
                 }
             }
         },
+        "core.NoteType": {
+            "type": "string",
+            "enum": [
+                "text",
+                "checklist"
+            ],
+            "x-enum-varnames": [
+                "NoteTypeText",
+                "NoteTypeChecklist"
+            ]
+        },
         "core.NoteUpdateRequest": {
             "description": "Структура для обновления существующей заметки (частично)",
             "type": "object",
@@ -300,9 +2691,140 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Обновленный текст"
                 },
+                "due_at": {
+                    "description": "Время в формате RFC 3339; пустая строка снимает срок или напоминание",
+                    "type": "string",
+                    "example": "2026-10-25T18:00:00Z"
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=DAILY;COUNT=5"
+                },
+                "remind_at": {
+                    "type": "string",
+                    "example": "2026-10-25T09:00:00Z"
+                },
+                "tags": {
+                    "description": "Tags заменяет все метки заметки; пустой список снимает их",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "работа"
+                    ]
+                },
                 "title": {
                     "type": "string",
                     "example": "Обновленный заголовок"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "text",
+                        "checklist"
+                    ],
+                    "example": "checklist"
+                }
+            }
+        },
+        "core.Template": {
+            "description": "Шаблон заметки; Title и Content поддерживают подстановки вида {{date}}, {{user}}, {{title}} и {{.имя}}",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "core.TemplateCreateRequest": {
+            "description": "Шаблон заметки; Title и Content поддерживают {{date}}, {{time}}, {{datetime}}, {{user}}, {{title}}, {{.имя}}, {{upper ...}}, {{lower ...}} и условия if/with",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Участники: {{user}}\nПроект: {{.project}}"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Протокол встречи"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Встреча {{date}}"
+                }
+            }
+        },
+        "core.TemplateUpdateRequest": {
+            "description": "Структура для частичного обновления шаблона",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Ответственный: {{user}}"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Разбор инцидента"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Инцидент {{.id}} от {{date}}"
+                }
+            }
+        },
+        "core.ValidationErrorResponse": {
+            "description": "Все нарушения правил валидации по полям",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "ошибка валидации"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.FieldError"
+                    }
+                },
+                "trace_id": {
+                    "description": "TraceID — идентификатор трассировки запроса для поиска в журналах",
+                    "type": "string",
+                    "example": "4bf92f3577b34da6a3ce929d0e0e4736"
+                }
+            }
+        },
+        "validation.FieldError": {
+            "description": "Ошибка проверки поля",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "max_length"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "не может превышать 200 символов"
                 }
             }
         }
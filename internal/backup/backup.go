@@ -0,0 +1,522 @@
+// Package backup создает и восстанавливает ZIP-архивы хранилища заметок.
+//
+// Архив содержит manifest.json с контрольными суммами SHA-256 всех файлов,
+// notes.json со всеми заметками, notes/*.md с их Markdown-представлением,
+// comments.json, attachments.json и templates.json с комментариями,
+// метаданными вложений и шаблонами, а также blobs/<хеш> с содержимым
+// вложений и миниатюр. При восстановлении источником истины служат
+// JSON-файлы.
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/blob"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/markdown"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+)
+
+const (
+	// FormatVersion — версия формата архива. Версия 1 содержала только
+	// заметки; такие архивы по-прежнему восстанавливаются.
+	FormatVersion = 2
+
+	manifestFile    = "manifest.json"
+	notesFile       = "notes.json"
+	commentsFile    = "comments.json"
+	attachmentsFile = "attachments.json"
+	templatesFile   = "templates.json"
+	markdownDir     = "notes"
+	blobsDir        = "blobs"
+)
+
+// Mode определяет способ восстановления архива
+type Mode string
+
+const (
+	// ModeMerge добавляет заметки из архива к существующим
+	ModeMerge Mode = "merge"
+	// ModeReplace удаляет существующие заметки перед восстановлением
+	ModeReplace Mode = "replace"
+)
+
+// Store объединяет хранилища, содержимое которых попадает в архив
+type Store struct {
+	Notes       repo.NoteRepository
+	Comments    repo.CommentRepository
+	Attachments repo.AttachmentRepository
+	Templates   repo.TemplateRepository
+	Blobs       blob.Store
+}
+
+// Manifest описывает содержимое архива
+type Manifest struct {
+	FormatVersion   int            `json:"format_version"`
+	Service         string         `json:"service"`
+	CreatedAt       time.Time      `json:"created_at"`
+	NoteCount       int            `json:"note_count"`
+	CommentCount    int            `json:"comment_count"`
+	AttachmentCount int            `json:"attachment_count"`
+	TemplateCount   int            `json:"template_count"`
+	Files           []ManifestFile `json:"files"`
+}
+
+// ManifestFile содержит размер и контрольную сумму файла архива
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// IDMapping связывает ID заметки в архиве с ID после восстановления
+type IDMapping struct {
+	Old int64
+	New int64
+}
+
+// ImportResult содержит итог восстановления
+type ImportResult struct {
+	Mode        Mode
+	Deleted     int
+	Imported    int
+	Comments    int
+	Attachments int
+	Templates   int
+	IDMap       []IDMapping
+}
+
+// contents — разобранное содержимое архива
+type contents struct {
+	notes       []core.Note
+	comments    []core.Comment
+	attachments []core.Attachment
+	templates   []core.Template
+	// blobs — содержимое вложений и миниатюр по хешу
+	blobs map[string][]byte
+}
+
+// Export записывает в w архив со всеми заметками, комментариями,
+// вложениями и шаблонами. Каждое хранилище читается одним вызовом;
+// блокировки на все хранилища сразу нет, поэтому изменения во время
+// выгрузки могут попасть в архив частично.
+func Export(ctx context.Context, st Store, w io.Writer) error {
+	notes, err := st.Notes.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+
+	var comments []core.Comment
+	for _, note := range notes {
+		noteComments, err := st.Comments.ListByNote(ctx, note.ID)
+		if err != nil {
+			return err
+		}
+		comments = append(comments, noteComments...)
+	}
+
+	// Вложения заметок, удаленных после чтения notes, не выгружаются
+	exported := make(map[int64]bool, len(notes))
+	for _, note := range notes {
+		exported[note.ID] = true
+	}
+	all, err := st.Attachments.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	attachments := make([]core.Attachment, 0, len(all))
+	for _, a := range all {
+		if exported[a.NoteID] {
+			attachments = append(attachments, a)
+		}
+	}
+
+	templates, err := st.Templates.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	manifest := Manifest{
+		FormatVersion:   FormatVersion,
+		Service:         "notes-api",
+		CreatedAt:       time.Now(),
+		NoteCount:       len(notes),
+		CommentCount:    len(comments),
+		AttachmentCount: len(attachments),
+		TemplateCount:   len(templates),
+	}
+
+	zw := zip.NewWriter(w)
+	create := func(name string, method uint16) (io.Writer, error) {
+		return zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   method,
+			Modified: manifest.CreatedAt,
+		})
+	}
+	add := func(name string, data []byte) error {
+		f, err := create(name, zip.Deflate)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:   name,
+			Size:   int64(len(data)),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+		return nil
+	}
+	addJSON := func(name string, v any) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		return add(name, data)
+	}
+
+	if err := addJSON(notesFile, notes); err != nil {
+		return err
+	}
+	for _, note := range notes {
+		data, err := markdown.Encode(note)
+		if err != nil {
+			return err
+		}
+		if err := add(path.Join(markdownDir, markdown.FileName(note)), data); err != nil {
+			return err
+		}
+	}
+	if err := addJSON(commentsFile, comments); err != nil {
+		return err
+	}
+	if err := addJSON(attachmentsFile, attachments); err != nil {
+		return err
+	}
+	if err := addJSON(templatesFile, templates); err != nil {
+		return err
+	}
+	if err := exportBlobs(ctx, st.Blobs, attachments, func(hash string) (io.Writer, error) {
+		// Содержимое уже адресовано хешем, поэтому контрольная сумма
+		// в манифесте совпадает с именем файла
+		return create(path.Join(blobsDir, hash), zip.Store)
+	}, &manifest); err != nil {
+		return err
+	}
+
+	f, err := create(manifestFile, zip.Deflate)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(manifest); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// exportBlobs копирует в архив содержимое вложений и миниатюр, не загружая
+// блобы в память целиком
+func exportBlobs(ctx context.Context, blobs blob.Store, attachments []core.Attachment, create func(hash string) (io.Writer, error), manifest *Manifest) error {
+	seen := make(map[string]bool)
+	for _, a := range attachments {
+		for _, hash := range attachmentHashes(a) {
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+
+			rc, err := blobs.Open(ctx, hash)
+			if err != nil {
+				return fmt.Errorf("вложение %d: %w", a.ID, err)
+			}
+			f, err := create(hash)
+			if err == nil {
+				h := sha256.New()
+				var size int64
+				size, err = io.Copy(io.MultiWriter(f, h), rc)
+				manifest.Files = append(manifest.Files, ManifestFile{
+					Path:   path.Join(blobsDir, hash),
+					Size:   size,
+					SHA256: hex.EncodeToString(h.Sum(nil)),
+				})
+			}
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// attachmentHashes возвращает хеши содержимого вложения и его миниатюр
+func attachmentHashes(a core.Attachment) []string {
+	hashes := []string{a.Hash}
+	widths := make([]int, 0, len(a.Thumbnails))
+	for w := range a.Thumbnails {
+		widths = append(widths, w)
+	}
+	sort.Ints(widths)
+	for _, w := range widths {
+		hashes = append(hashes, a.Thumbnails[w])
+	}
+	return hashes
+}
+
+// Import восстанавливает архив. Заметки восстанавливаются через сервис
+// заметок, чтобы сработали те же проверки и обработчики, что при обычном
+// создании и удалении (напоминания, граф ссылок); в режиме замены
+// обработчики удаления снимают комментарии и вложения прежних заметок.
+// Затем к новым заметкам восстанавливаются комментарии и вложения из
+// архива, а шаблоны добавляются или, в режиме замены, заменяются.
+//
+// Архив и все заметки проверяются до внесения изменений, а заметки
+// заменяются одним вызовом хранилища. Заметки получают новые ID, ссылки
+// [[#id]] между ними переписываются; соответствие старых и новых ID
+// возвращается в результате.
+func Import(ctx context.Context, notes service.NoteService, st Store, archive []byte, mode Mode) (*ImportResult, error) {
+	if mode != ModeMerge && mode != ModeReplace {
+		return nil, errors.New("неизвестный режим восстановления")
+	}
+
+	c, err := readArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := notes.ImportNotes(ctx, c.notes, service.ImportOptions{Replace: mode == ModeReplace, Atomic: true})
+	if err != nil {
+		return nil, fmt.Errorf("восстановление заметок: %w", err)
+	}
+	for i, err := range res.Errors {
+		if err != nil {
+			return nil, fmt.Errorf("заметка %d: %w", c.notes[i].ID, err)
+		}
+	}
+
+	result := &ImportResult{
+		Mode:     mode,
+		Deleted:  res.Deleted,
+		Imported: len(res.IDs),
+		IDMap:    make([]IDMapping, len(res.IDs)),
+	}
+	noteIDs := make(map[int64]int64, len(res.IDs))
+	for i, id := range res.IDs {
+		result.IDMap[i] = IDMapping{Old: c.notes[i].ID, New: id}
+		if _, ok := noteIDs[c.notes[i].ID]; !ok {
+			noteIDs[c.notes[i].ID] = id
+		}
+	}
+
+	if err := restore(ctx, st, c, noteIDs, mode, result); err != nil {
+		return nil, fmt.Errorf("восстановление заметок: %w", err)
+	}
+	return result, nil
+}
+
+// restore восстанавливает комментарии, вложения и шаблоны восстановленных
+// заметок. Метаданные вложений создаются раньше блобов, чтобы сборщик
+// мусора не удалил ещё не привязанное содержимое.
+func restore(ctx context.Context, st Store, c *contents, noteIDs map[int64]int64, mode Mode, result *ImportResult) error {
+	comments := make([]core.Comment, len(c.comments))
+	for i, comment := range c.comments {
+		comment.NoteID = noteIDs[comment.NoteID]
+		comments[i] = comment
+	}
+	created, err := st.Comments.Import(ctx, comments)
+	if err != nil {
+		return err
+	}
+	result.Comments = len(created)
+
+	attachments := make([]core.Attachment, len(c.attachments))
+	for i, a := range c.attachments {
+		a.NoteID = noteIDs[a.NoteID]
+		attachments[i] = a
+	}
+	created, err = st.Attachments.Import(ctx, attachments)
+	if err != nil {
+		return err
+	}
+	result.Attachments = len(created)
+
+	hashes := make([]string, 0, len(c.blobs))
+	for hash := range c.blobs {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	for _, hash := range hashes {
+		if _, _, err := st.Blobs.Put(ctx, bytes.NewReader(c.blobs[hash])); err != nil {
+			return err
+		}
+	}
+
+	created, _, err = st.Templates.Import(ctx, c.templates, mode == ModeReplace)
+	if err != nil {
+		return err
+	}
+	result.Templates = len(created)
+	return nil
+}
+
+const (
+	// maxEntrySize ограничивает размер одного распакованного файла архива
+	maxEntrySize = 64 << 20
+	// maxArchiveSize ограничивает суммарный размер распакованных файлов
+	maxArchiveSize = 256 << 20
+)
+
+// readArchive проверяет манифест и контрольные суммы и возвращает
+// содержимое архива. Читаются только манифест и перечисленные в нем файлы,
+// каждый не больше maxEntrySize и все вместе не больше maxArchiveSize.
+// Комментарии и вложения должны ссылаться на заметки архива, а содержимое
+// вложений — присутствовать в blobs/.
+func readArchive(archive []byte) (*contents, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, errors.New("неверный ZIP-архив")
+	}
+
+	entries := make(map[string]*zip.File, len(zr.File))
+	for _, zf := range zr.File {
+		entries[zf.Name] = zf
+	}
+	var total int64
+	read := func(name string) ([]byte, error) {
+		zf, ok := entries[name]
+		if !ok {
+			return nil, fmt.Errorf("в архиве отсутствует файл %s", name)
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, fmt.Errorf("чтение %s: %w", name, err)
+		}
+		defer rc.Close()
+
+		// Заявленный в архиве размер не проверяется: ему нельзя доверять
+		data, err := io.ReadAll(io.LimitReader(rc, min(maxEntrySize, maxArchiveSize-total)+1))
+		if err != nil {
+			return nil, fmt.Errorf("чтение %s: %w", name, err)
+		}
+		if len(data) > maxEntrySize {
+			return nil, fmt.Errorf("файл %s слишком большой", name)
+		}
+		total += int64(len(data))
+		if total > maxArchiveSize {
+			return nil, errors.New("распакованный архив слишком большой")
+		}
+		return data, nil
+	}
+
+	data, err := read(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("неверный manifest.json: %w", err)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("неподдерживаемая версия формата архива: %d", manifest.FormatVersion)
+	}
+
+	files := make(map[string][]byte, len(manifest.Files))
+	for _, mf := range manifest.Files {
+		if _, ok := files[mf.Path]; ok {
+			return nil, fmt.Errorf("файл %s повторяется в манифесте", mf.Path)
+		}
+		data, err := read(mf.Path)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != mf.Size || hex.EncodeToString(sum[:]) != mf.SHA256 {
+			return nil, fmt.Errorf("контрольная сумма файла %s не совпадает", mf.Path)
+		}
+		files[mf.Path] = data
+	}
+
+	c := &contents{blobs: make(map[string][]byte)}
+	decode := func(name string, count int, v any, n func() int) error {
+		data, ok := files[name]
+		if !ok {
+			return fmt.Errorf("в манифесте отсутствует %s", name)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("неверный %s: %w", name, err)
+		}
+		if n() != count {
+			return fmt.Errorf("количество записей в %s не совпадает с манифестом", name)
+		}
+		return nil
+	}
+	if err := decode(notesFile, manifest.NoteCount, &c.notes, func() int { return len(c.notes) }); err != nil {
+		return nil, err
+	}
+	if manifest.FormatVersion == 1 {
+		return c, nil
+	}
+	if err := decode(commentsFile, manifest.CommentCount, &c.comments, func() int { return len(c.comments) }); err != nil {
+		return nil, err
+	}
+	if err := decode(attachmentsFile, manifest.AttachmentCount, &c.attachments, func() int { return len(c.attachments) }); err != nil {
+		return nil, err
+	}
+	if err := decode(templatesFile, manifest.TemplateCount, &c.templates, func() int { return len(c.templates) }); err != nil {
+		return nil, err
+	}
+
+	noteIDs := make(map[int64]bool, len(c.notes))
+	for _, note := range c.notes {
+		noteIDs[note.ID] = true
+	}
+	sort.SliceStable(c.comments, func(i, j int) bool { return c.comments[i].ID < c.comments[j].ID })
+	commentIDs := make(map[int64]bool, len(c.comments))
+	for _, comment := range c.comments {
+		if !noteIDs[comment.NoteID] {
+			return nil, fmt.Errorf("комментарий %d ссылается на отсутствующую заметку %d", comment.ID, comment.NoteID)
+		}
+		// Родитель создан раньше ответа и потому идет в архиве перед ним
+		if comment.ParentID != nil && !commentIDs[*comment.ParentID] {
+			return nil, fmt.Errorf("комментарий %d ссылается на отсутствующий комментарий %d", comment.ID, *comment.ParentID)
+		}
+		if commentIDs[comment.ID] {
+			return nil, fmt.Errorf("комментарий %d повторяется", comment.ID)
+		}
+		commentIDs[comment.ID] = true
+	}
+	for _, a := range c.attachments {
+		if !noteIDs[a.NoteID] {
+			return nil, fmt.Errorf("вложение %d ссылается на отсутствующую заметку %d", a.ID, a.NoteID)
+		}
+		for _, hash := range attachmentHashes(a) {
+			data, ok := files[path.Join(blobsDir, hash)]
+			if !ok {
+				return nil, fmt.Errorf("в архиве отсутствует содержимое вложения %d", a.ID)
+			}
+			if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+				return nil, fmt.Errorf("содержимое вложения %d не совпадает с хешем", a.ID)
+			}
+			c.blobs[hash] = data
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,297 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/blob"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/imaging"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+type testStore struct {
+	Store
+	notes       *repo.NoteRepoMem
+	service     service.NoteService
+	comments    service.CommentService
+	attachments service.AttachmentService
+	deleted     []int64
+}
+
+// newTestStore собирает сервисы так же, как cmd/api: удаление заметки
+// снимает ее комментарии и вложения
+func newTestStore(t *testing.T, titles ...string) *testStore {
+	t.Helper()
+	s := &testStore{notes: repo.NewNoteRepoMem()}
+	commentRepo := repo.NewCommentRepoMem()
+	validator := validation.New(validation.DefaultRules())
+	s.Store = Store{
+		Notes:       s.notes,
+		Comments:    commentRepo,
+		Attachments: repo.NewAttachmentRepoMem(),
+		Templates:   repo.NewTemplateRepoMem(),
+		Blobs:       blob.NewMemStore(),
+	}
+	pool := imaging.NewPool(0, 0)
+	t.Cleanup(pool.Close)
+
+	s.service = service.NewNoteService(s.notes, repo.NewLinkRepoMem(), commentRepo, validator)
+	s.comments = service.NewCommentService(s.notes, commentRepo, validator)
+	s.attachments = service.NewAttachmentService(s.notes, s.Attachments, s.Blobs, 1<<20, pool)
+	s.service.OnDelete(s.attachments.DeleteNoteAttachments)
+	s.service.OnDelete(func(ctx context.Context, id int64) error {
+		s.deleted = append(s.deleted, id)
+		return nil
+	})
+	for _, title := range titles {
+		if _, err := s.service.CreateNote(context.Background(), core.Note{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// buildArchive собирает архив с заметками; tamper может испортить манифест
+func buildArchive(t *testing.T, notes []core.Note, extra map[string][]byte, tamper func(*Manifest)) []byte {
+	t.Helper()
+	data, err := json.Marshal(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{notesFile: data}
+	for name, data := range extra {
+		files[name] = data
+	}
+
+	// Архив версии 1 содержит только заметки
+	manifest := Manifest{FormatVersion: 1, NoteCount: len(notes)}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(data)
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, ManifestFile{Path: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	if tamper != nil {
+		tamper(&manifest)
+	}
+	data, _ = json.Marshal(manifest)
+	f, _ := zw.Create(manifestFile)
+	f.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// version2 возвращает файлы архива версии 2 с комментариями и вложениями
+func version2(comments, attachments string) map[string][]byte {
+	return map[string][]byte{
+		commentsFile:    []byte(comments),
+		attachmentsFile: []byte(attachments),
+		templatesFile:   []byte(`[]`),
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := core.WithUser(context.Background(), "alice")
+	src := newTestStore(t)
+	first, _ := src.service.CreateNote(ctx, core.Note{Title: "Первая", Content: "текст"})
+	src.service.CreateNote(ctx, core.Note{Title: "Вторая", Content: "см. [[#" + strconv.FormatInt(first, 10) + "|первую]] и [[#999]]"})
+
+	comment, err := src.comments.AddComment(ctx, first, nil, "Комментарий для @bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.comments.AddComment(ctx, first, &comment.ID, "Ответ"); err != nil {
+		t.Fatal(err)
+	}
+	attachment, err := src.attachments.Upload(ctx, first, "notes.txt", "text/plain", strings.NewReader("содержимое вложения"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Templates.Create(ctx, core.Template{Name: "Встреча", Title: "Встреча {{date}}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Export(ctx, src.Store, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// В хранилище уже есть заметки с комментариями и вложениями, поэтому
+	// ID после восстановления другие, а прежние данные заменяются
+	dst := newTestStore(t, "Старая 1", "Старая 2", "Старая 3")
+	if _, err := dst.comments.AddComment(ctx, 1, nil, "Старый комментарий"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.attachments.Upload(ctx, 1, "old.txt", "text/plain", strings.NewReader("старое")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.Templates.Create(ctx, core.Template{Name: "Старый"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Import(ctx, dst.service, dst.Store, buf.Bytes(), ModeReplace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deleted != 3 || result.Imported != 2 || len(dst.deleted) != 3 {
+		t.Fatalf("Deleted = %d, Imported = %d, обработчиков удаления = %d", result.Deleted, result.Imported, len(dst.deleted))
+	}
+	if result.Comments != 2 || result.Attachments != 1 || result.Templates != 1 {
+		t.Fatalf("Comments = %d, Attachments = %d, Templates = %d", result.Comments, result.Attachments, result.Templates)
+	}
+
+	ids := make(map[int64]int64)
+	for _, m := range result.IDMap {
+		ids[m.Old] = m.New
+	}
+	second, err := dst.notes.GetByID(ctx, ids[first+1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "см. [[#" + strconv.FormatInt(ids[first], 10) + "|первую]] и [[#999]]"
+	if second.Content != want {
+		t.Errorf("Content = %q, want %q", second.Content, want)
+	}
+
+	backlinks, err := dst.service.GetBacklinks(ctx, ids[first])
+	if err != nil || len(backlinks) != 1 {
+		t.Errorf("обратные ссылки после восстановления: %v, %v", backlinks, err)
+	}
+
+	thread, err := dst.comments.GetThread(ctx, ids[first])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread) != 1 || thread[0].Body != comment.Body || thread[0].AuthorID != "alice" ||
+		!thread[0].CreatedAt.Equal(comment.CreatedAt) || len(thread[0].Replies) != 1 || thread[0].Replies[0].Body != "Ответ" {
+		t.Errorf("комментарии после восстановления: %+v", thread)
+	}
+
+	restored, err := dst.attachments.ListAttachments(ctx, ids[first])
+	if err != nil || len(restored) != 1 {
+		t.Fatalf("вложения после восстановления: %v, %v", restored, err)
+	}
+	a, rc, err := dst.attachments.Open(ctx, restored[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "содержимое вложения" || a.Hash != attachment.Hash || a.OwnerID != "alice" || a.FileName != "notes.txt" {
+		t.Errorf("вложение после восстановления: %+v, %q", a, data)
+	}
+	if old, _ := dst.Attachments.GetAll(ctx); len(old) != 1 {
+		t.Errorf("вложений в хранилище %d, want 1", len(old))
+	}
+
+	templates, err := dst.Templates.GetAll(ctx)
+	if err != nil || len(templates) != 1 || templates[0].Name != "Встреча" {
+		t.Errorf("шаблоны после восстановления: %+v, %v", templates, err)
+	}
+}
+
+func TestImportMergeKeepsExistingData(t *testing.T) {
+	ctx := context.Background()
+	src := newTestStore(t, "Из архива")
+	var buf bytes.Buffer
+	if err := Export(ctx, src.Store, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newTestStore(t, "Существующая")
+	if _, err := dst.comments.AddComment(ctx, 1, nil, "Комментарий"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(ctx, dst.service, dst.Store, buf.Bytes(), ModeMerge); err != nil {
+		t.Fatal(err)
+	}
+	if dst.notes.Len() != 2 || len(dst.deleted) != 0 {
+		t.Errorf("заметок %d, удалено %d", dst.notes.Len(), len(dst.deleted))
+	}
+	if thread, _ := dst.comments.GetThread(ctx, 1); len(thread) != 1 {
+		t.Errorf("комментарии существующей заметки: %+v", thread)
+	}
+}
+
+func TestImportFailureLeavesStoreUntouched(t *testing.T) {
+	valid := core.Note{ID: 1, Title: "Заметка"}
+	tests := []struct {
+		name    string
+		archive func(t *testing.T) []byte
+		wantErr string
+	}{
+		{
+			name:    "заметка не проходит проверку",
+			archive: func(t *testing.T) []byte { return buildArchive(t, []core.Note{valid, {ID: 2, Title: ""}}, nil, nil) },
+			wantErr: "заметка 2",
+		},
+		{
+			name: "неверная контрольная сумма",
+			archive: func(t *testing.T) []byte {
+				return buildArchive(t, []core.Note{valid}, nil, func(m *Manifest) { m.Files[0].SHA256 = "00" })
+			},
+			wantErr: "контрольная сумма",
+		},
+		{
+			name: "файл больше предела",
+			archive: func(t *testing.T) []byte {
+				return buildArchive(t, []core.Note{valid}, map[string][]byte{"big.bin": make([]byte, maxEntrySize+1)}, nil)
+			},
+			wantErr: "слишком большой",
+		},
+		{
+			name: "комментарий к отсутствующей заметке",
+			archive: func(t *testing.T) []byte {
+				return buildArchive(t, []core.Note{valid}, version2(`[{"ID":1,"NoteID":5,"Body":"x"}]`, `[]`), func(m *Manifest) {
+					m.FormatVersion, m.CommentCount = 2, 1
+				})
+			},
+			wantErr: "отсутствующую заметку 5",
+		},
+		{
+			name: "нет содержимого вложения",
+			archive: func(t *testing.T) []byte {
+				hash := strings.Repeat("a", 64)
+				return buildArchive(t, []core.Note{valid}, version2(`[]`, `[{"ID":1,"NoteID":1,"Hash":"`+hash+`"}]`), func(m *Manifest) {
+					m.FormatVersion, m.AttachmentCount = 2, 1
+				})
+			},
+			wantErr: "отсутствует содержимое вложения",
+		},
+		{
+			name:    "не ZIP",
+			archive: func(t *testing.T) []byte { return []byte("not a zip") },
+			wantErr: "неверный ZIP-архив",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "Существующая")
+			_, err := Import(context.Background(), s.service, s.Store, tt.archive(t), ModeReplace)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if s.notes.Len() != 1 || len(s.deleted) != 0 {
+				t.Errorf("хранилище изменено: заметок %d, удалено %d", s.notes.Len(), len(s.deleted))
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package blob хранит содержимое файлов по адресу — хешу SHA-256.
+// Одинаковое содержимое хранится один раз.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound возвращается, если блоб с указанным хешем отсутствует
+var ErrNotFound = errors.New("блоб не найден")
+
+// Store определяет хранилище блобов
+type Store interface {
+	// Put сохраняет содержимое и возвращает его хеш SHA-256 и размер
+	Put(ctx context.Context, r io.Reader) (hash string, size int64, err error)
+	// Open открывает блоб для чтения с произвольным доступом
+	Open(ctx context.Context, hash string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, hash string) error
+	// List возвращает хеши всех хранимых блобов
+	List(ctx context.Context) ([]string, error)
+}
+
+// validHash проверяет, что строка является шестнадцатеричным SHA-256
+func validHash(hash string) bool {
+	if len(hash) != 64 {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FSStore реализует Store в каталоге локальной файловой системы.
+// Блоб с хешем abcd... хранится в файле <root>/ab/abcd...
+type FSStore struct {
+	root string
+}
+
+func NewFSStore(root string) (*FSStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &FSStore{root: root}, nil
+}
+
+// CheckHealth проверяет, что в каталог хранилища можно записывать
+func (s *FSStore) CheckHealth(ctx context.Context) error {
+	f, err := os.CreateTemp(s.root, ".health-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func (s *FSStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	// Записать во временный файл, одновременно вычисляя хеш
+	tmp, err := os.CreateTemp(s.root, ".upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	path := s.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		// Такое содержимое уже хранится
+		return hash, size, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+
+	return hash, size, nil
+}
+
+func (s *FSStore) Open(ctx context.Context, hash string) (io.ReadSeekCloser, error) {
+	if !validHash(hash) {
+		return nil, ErrNotFound
+	}
+
+	f, err := os.Open(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *FSStore) Delete(ctx context.Context, hash string) error {
+	if !validHash(hash) {
+		return ErrNotFound
+	}
+
+	err := os.Remove(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *FSStore) List(ctx context.Context) ([]string, error) {
+	var hashes []string
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && validHash(d.Name()) {
+			hashes = append(hashes, d.Name())
+		}
+		return nil
+	})
+
+	return hashes, err
+}
+
+func (s *FSStore) path(hash string) string {
+	return filepath.Join(s.root, hash[:2], hash)
+}
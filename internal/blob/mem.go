@@ -0,0 +1,83 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+)
+
+// MemStore реализует Store в памяти
+type MemStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		blobs: make(map[string][]byte),
+	}
+}
+
+func (s *MemStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", 0, err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.blobs[hash]; !exists {
+		s.blobs[hash] = data
+	}
+
+	return hash, int64(len(data)), nil
+}
+
+func (s *MemStore) Open(ctx context.Context, hash string) (io.ReadSeekCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, exists := s.blobs[hash]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+func (s *MemStore) Delete(ctx context.Context, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.blobs[hash]; !exists {
+		return ErrNotFound
+	}
+
+	delete(s.blobs, hash)
+	return nil
+}
+
+func (s *MemStore) List(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hashes := make([]string, 0, len(s.blobs))
+	for hash := range s.blobs {
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,336 @@
+// Package config описывает настройки сервера и загружает их из файла,
+// переменных окружения и флагов командной строки.
+//
+// Источники применяются по возрастанию приоритета: значения по умолчанию,
+// файл (YAML или TOML, путь в -config или NOTES_CONFIG), переменные
+// окружения NOTES_<РАЗДЕЛ>_<КЛЮЧ>, флаги -<раздел>.<ключ>. Например,
+// адрес сервера задается ключом server.addr в файле, переменной
+// NOTES_SERVER_ADDR или флагом -server.addr.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/ratelimit"
+)
+
+// Config — полная конфигурация сервера.
+//
+// Теги полей: yaml/toml — ключ в файле (из него же строятся имена
+// переменной окружения и флага), usage — описание флага, secret — значение
+// скрывается при выводе, reload — поле применяется по SIGHUP без перезапуска.
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Storage     StorageConfig     `yaml:"storage" toml:"storage"`
+	Attachments AttachmentsConfig `yaml:"attachments" toml:"attachments"`
+	Validation  ValidationConfig  `yaml:"validation" toml:"validation"`
+	Reminders   RemindersConfig   `yaml:"reminders" toml:"reminders"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Admin       AdminConfig       `yaml:"admin" toml:"admin"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Health      HealthConfig      `yaml:"health" toml:"health"`
+	Metrics     MetricsConfig     `yaml:"metrics" toml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	CORS        CORSConfig        `yaml:"cors" toml:"cors"`
+	Compression CompressionConfig `yaml:"compression" toml:"compression"`
+}
+
+// ServerConfig — параметры HTTP-сервера
+type ServerConfig struct {
+	Addr    string `yaml:"addr" toml:"addr" usage:"адрес HTTP-сервера"`
+	DocsDir string `yaml:"docs_dir" toml:"docs_dir" usage:"каталог со сгенерированной документацией Swagger"`
+
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" usage:"время на чтение заголовков запроса"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout" usage:"время на чтение всего запроса вместе с телом"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout" usage:"время на запись ответа"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" usage:"время простоя keep-alive соединения"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" toml:"max_header_bytes" usage:"максимальный размер заголовков запроса в байтах"`
+	MaxJSONBody       int64         `yaml:"max_json_body" toml:"max_json_body" usage:"максимальный размер JSON-тела запроса в байтах"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" usage:"время на каждый этап остановки: запросы, затем фоновые задачи"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" usage:"пауза между отказом /readyz и закрытием порта при остановке"`
+}
+
+// StorageConfig — параметры хранилища
+type StorageConfig struct {
+	Driver  string `yaml:"driver" toml:"driver" usage:"хранилище заметок (поддерживается memory)"`
+	BlobDir string `yaml:"blob_dir" toml:"blob_dir" usage:"каталог содержимого вложений"`
+}
+
+// AttachmentsConfig — параметры вложений и обработки изображений
+type AttachmentsConfig struct {
+	MaxSize      int64         `yaml:"max_size" toml:"max_size" usage:"максимальный размер одного загружаемого вложения в байтах"`
+	Quota        int64         `yaml:"quota" toml:"quota" usage:"суммарный размер вложений одного пользователя в байтах"`
+	GCInterval   time.Duration `yaml:"gc_interval" toml:"gc_interval" usage:"период очистки неиспользуемых вложений"`
+	ImageWorkers int           `yaml:"image_workers" toml:"image_workers" usage:"число обработчиков изображений (0 — по числу CPU)"`
+	ImageQueue   int           `yaml:"image_queue" toml:"image_queue" usage:"длина очереди обработки изображений"`
+}
+
+// ValidationConfig — параметры проверки полей
+type ValidationConfig struct {
+	RulesFile string `yaml:"rules_file" toml:"rules_file" usage:"YAML-файл правил валидации (пусто — встроенные)" reload:"true"`
+}
+
+// RemindersConfig — параметры планировщика напоминаний
+type RemindersConfig struct {
+	Resync time.Duration `yaml:"resync" toml:"resync" usage:"период сверки расписания с хранилищем"`
+}
+
+// IdempotencyConfig — параметры повторов по Idempotency-Key
+type IdempotencyConfig struct {
+	TTL         time.Duration `yaml:"ttl" toml:"ttl" usage:"время хранения ответов для повторов"`
+	LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout" usage:"ожидание параллельного запроса с тем же ключом"`
+	MaxBody     int64         `yaml:"max_body" toml:"max_body" usage:"максимальный размер тела запроса с ключом в байтах"`
+}
+
+// AdminConfig — параметры административных маршрутов
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" usage:"включить административные маршруты /api/v1/admin (требует admin.token)"`
+	Token   string `yaml:"token" toml:"token" usage:"токен доступа к /api/v1/admin и подробному /readyz (обязателен при admin.enabled)" secret:"true" reload:"true"`
+}
+
+// AuthConfig — аутентификация пользователей API. Без токенов и без
+// доверия к X-User-ID все запросы анонимны.
+type AuthConfig struct {
+	Tokens          string `yaml:"tokens" toml:"tokens" usage:"токены пользователей через запятую: пользователь=токен" secret:"true" reload:"true"`
+	TrustUserHeader bool   `yaml:"trust_user_header" toml:"trust_user_header" usage:"брать пользователя из X-User-ID (только за шлюзом, который выставляет этот заголовок)"`
+}
+
+// HealthConfig — параметры проверок готовности
+type HealthConfig struct {
+	Timeout  time.Duration `yaml:"timeout" toml:"timeout" usage:"таймаут одной проверки готовности"`
+	CacheTTL time.Duration `yaml:"cache_ttl" toml:"cache_ttl" usage:"время кэширования результата проверок"`
+}
+
+// MetricsConfig — параметры метрик Prometheus
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" usage:"отдавать метрики Prometheus на /metrics"`
+}
+
+// TracingConfig — параметры трассировки OpenTelemetry
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" usage:"экспорт спанов: none, stdout или otlp"`
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint" usage:"адрес OTLP/HTTP-приемника host:port (пусто — OTEL_EXPORTER_OTLP_ENDPOINT)"`
+	Insecure    bool    `yaml:"insecure" toml:"insecure" usage:"отправлять OTLP без TLS"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" usage:"доля записываемых трассировок от 0 до 1"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" usage:"имя сервиса в трассировках"`
+}
+
+// LogConfig — параметры журнала
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format" usage:"формат журнала: json или text"`
+	Level  string `yaml:"level" toml:"level" usage:"уровень журнала: debug, info, warn или error" reload:"true"`
+}
+
+// RateLimitConfig — ограничение частоты запросов к API на клиента
+// (токен, пользователь или IP); формат лимитов описан в пакете ratelimit
+type RateLimitConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" usage:"ограничивать частоту запросов к /api/"`
+	Default string `yaml:"default" toml:"default" usage:"лимит по умолчанию: запросов/период, например 600/m (0 — без ограничения)" reload:"true"`
+	Routes  string `yaml:"routes" toml:"routes" usage:"лимиты маршрутов через запятую: [МЕТОД] шаблон=запросов/период" reload:"true"`
+}
+
+// CORSConfig — доступ к API из браузера с других источников; списки
+// задаются через запятую
+type CORSConfig struct {
+	AllowedOrigins   string        `yaml:"allowed_origins" toml:"allowed_origins" usage:"разрешенные источники: https://app.example.com, https://*.example.com или * (пусто — CORS выключен)"`
+	AllowedMethods   string        `yaml:"allowed_methods" toml:"allowed_methods" usage:"разрешенные методы"`
+	AllowedHeaders   string        `yaml:"allowed_headers" toml:"allowed_headers" usage:"разрешенные заголовки запроса (* — любые)"`
+	ExposedHeaders   string        `yaml:"exposed_headers" toml:"exposed_headers" usage:"заголовки ответа, доступные скриптам"`
+	AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials" usage:"разрешить запросы с cookie и другими учетными данными"`
+	MaxAge           time.Duration `yaml:"max_age" toml:"max_age" usage:"время кэширования ответа на предварительный запрос"`
+}
+
+// CompressionConfig — сжатие ответов и распаковка тел запросов
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" usage:"сжимать ответы (zstd, gzip, deflate) и принимать сжатые тела запросов"`
+	MinSize int  `yaml:"min_size" toml:"min_size" usage:"минимальный размер ответа в байтах для сжатия"`
+}
+
+// Default возвращает конфигурацию по умолчанию
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:    ":8081",
+			DocsDir: "docs",
+
+			ReadHeaderTimeout: 5 * time.Second,
+			// Запас на загрузку и скачивание вложений до 100 МБ
+			ReadTimeout:     2 * time.Minute,
+			WriteTimeout:    2 * time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			MaxHeaderBytes:  1 << 20,
+			MaxJSONBody:     1 << 20,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Storage: StorageConfig{
+			Driver:  "memory",
+			BlobDir: "data/blobs",
+		},
+		Attachments: AttachmentsConfig{
+			MaxSize:    64 << 20,
+			Quota:      100 << 20,
+			GCInterval: time.Hour,
+			ImageQueue: 64,
+		},
+		Reminders: RemindersConfig{
+			Resync: time.Minute,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:         24 * time.Hour,
+			LockTimeout: 5 * time.Second,
+			MaxBody:     64 << 20,
+		},
+		Health: HealthConfig{
+			Timeout:  2 * time.Second,
+			CacheTTL: time.Second,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRatio: 1,
+			ServiceName: "notes-api",
+		},
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Default: "600/m",
+			Routes:  "POST /api/v1/notes=60/m",
+		},
+		CORS: CORSConfig{
+			AllowedMethods: "GET, POST, PUT, DELETE",
+			AllowedHeaders: "Authorization, Content-Type, Content-Encoding, Idempotency-Key, X-User-ID",
+			ExposedHeaders: "X-Trace-Id, Idempotent-Replayed, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy",
+			MaxAge:         10 * time.Minute,
+		},
+		Compression: CompressionConfig{
+			Enabled: true,
+			MinSize: 1024,
+		},
+	}
+}
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr: неверный адрес %q", c.Server.Addr))
+	}
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout: должен быть положительным")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout: должен быть положительным")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout: должен быть положительным")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout: должен быть положительным")
+	check(c.Server.MaxHeaderBytes > 0, "server.max_header_bytes: должен быть положительным")
+	check(c.Server.MaxJSONBody > 0, "server.max_json_body: должен быть положительным")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: должен быть положительным")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay: не может быть отрицательным")
+	check(c.Storage.Driver == "memory", "storage.driver: неподдерживаемое хранилище %q", c.Storage.Driver)
+	check(c.Storage.BlobDir != "", "storage.blob_dir: не может быть пустым")
+	check(c.Attachments.MaxSize > 0, "attachments.max_size: должен быть положительным")
+	check(c.Attachments.Quota > 0, "attachments.quota: должна быть положительной")
+	check(c.Attachments.GCInterval > 0, "attachments.gc_interval: должен быть положительным")
+	check(c.Attachments.ImageWorkers >= 0, "attachments.image_workers: не может быть отрицательным")
+	check(c.Attachments.ImageQueue > 0, "attachments.image_queue: должен быть положительным")
+	check(c.Reminders.Resync > 0, "reminders.resync: должен быть положительным")
+	check(c.Idempotency.TTL > 0, "idempotency.ttl: должен быть положительным")
+	check(c.Idempotency.LockTimeout > 0, "idempotency.lock_timeout: должен быть положительным")
+	check(c.Idempotency.MaxBody > 0, "idempotency.max_body: должен быть положительным")
+	check(!c.Admin.Enabled || c.Admin.Token != "", "admin.token: обязателен, пока включены административные маршруты")
+	if _, err := ParseUserTokens(c.Auth.Tokens); err != nil {
+		errs = append(errs, fmt.Errorf("auth.tokens: %w", err))
+	}
+	check(c.Health.Timeout > 0, "health.timeout: должен быть положительным")
+	check(c.Health.CacheTTL >= 0, "health.cache_ttl: не может быть отрицательным")
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: неизвестный экспортер %q", c.Tracing.Exporter))
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: должна быть от 0 до 1")
+	check(c.Tracing.ServiceName != "", "tracing.service_name: не может быть пустым")
+	check(c.Log.Format == "json" || c.Log.Format == "text", "log.format: неизвестный формат %q", c.Log.Format)
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level: неизвестный уровень %q", c.Log.Level)
+	if _, err := ratelimit.ParseRules(c.RateLimit.Default, c.RateLimit.Routes); err != nil {
+		errs = append(errs, fmt.Errorf("rate_limit: %w", err))
+	}
+	for _, origin := range SplitList(c.CORS.AllowedOrigins) {
+		if !validOrigin(origin) {
+			errs = append(errs, fmt.Errorf("cors.allowed_origins: неверный источник %q: ожидается схема://хост[:порт], схема://*.домен или *", origin))
+		}
+		check(origin != "*" || !c.CORS.AllowCredentials, "cors.allow_credentials: несовместим с источником *")
+	}
+	check(c.CORS.MaxAge >= 0, "cors.max_age: не может быть отрицательным")
+	check(c.Compression.MinSize >= 0, "compression.min_size: не может быть отрицательным")
+
+	return errors.Join(errs...)
+}
+
+// SplitList разбирает список через запятую, пропуская пустые элементы
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validOrigin проверяет источник CORS: *, схема://хост[:порт]
+// или шаблон поддоменов схема://*.домен[:порт]
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "*.")
+	return host != "" && !strings.Contains(host, "*")
+}
+
+// ParseUserTokens разбирает список пользователь=токен через запятую
+// в соответствие пользователь → токен
+func ParseUserTokens(s string) (map[string]string, error) {
+	users := make(map[string]string)
+	tokens := make(map[string]bool)
+	for _, item := range SplitList(s) {
+		user, token, ok := strings.Cut(item, "=")
+		user, token = strings.TrimSpace(user), strings.TrimSpace(token)
+		switch {
+		case !ok || user == "" || token == "":
+			return nil, errors.New("ожидается пользователь=токен")
+		case user == "anonymous":
+			return nil, errors.New("имя anonymous зарезервировано за неаутентифицированными запросами")
+		case len(user) > 128:
+			return nil, fmt.Errorf("слишком длинное имя пользователя %.20q…", user)
+		case users[user] != "":
+			return nil, fmt.Errorf("пользователь %q указан дважды", user)
+		case tokens[token]:
+			return nil, fmt.Errorf("токен пользователя %q совпадает с токеном другого пользователя", user)
+		}
+		users[user] = token
+		tokens[token] = true
+	}
+	return users, nil
+}
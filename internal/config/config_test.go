@@ -0,0 +1,52 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{name: "по умолчанию", modify: func(c *Config) {}},
+		{name: "административные маршруты без токена", modify: func(c *Config) { c.Admin.Enabled = true }, wantErr: "admin.token"},
+		{name: "административные маршруты с токеном", modify: func(c *Config) { c.Admin.Enabled = true; c.Admin.Token = "secret" }},
+		{
+			name:    "нулевая квота вложений",
+			modify:  func(c *Config) { c.Attachments.Quota = 0 },
+			wantErr: "attachments.quota",
+		},
+		{
+			name:    "неверный токен пользователя",
+			modify:  func(c *Config) { c.Auth.Tokens = "alice" },
+			wantErr: "auth.tokens",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.modify(c)
+			err := c.Validate()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSkipsValidation(t *testing.T) {
+	args := []string{"-admin.enabled"}
+	cfg, err := Parse("api", args)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !cfg.Admin.Enabled {
+		t.Error("Parse() не применил флаг admin.enabled")
+	}
+	if _, err := Load("api", args); err == nil || !strings.Contains(err.Error(), "admin.token") {
+		t.Errorf("Load() error = %v, want admin.token", err)
+	}
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix — префикс переменных окружения конфигурации
+const EnvPrefix = "NOTES_"
+
+// ConfigEnv — переменная окружения с путем к файлу конфигурации
+const ConfigEnv = EnvPrefix + "CONFIG"
+
+// ErrUsage возвращается при неверных флагах; сообщение и справка
+// к этому моменту уже выведены
+var ErrUsage = errors.New("неверные аргументы командной строки")
+
+// Load собирает конфигурацию из файла, окружения и флагов args
+// и проверяет результат
+func Load(name string, args []string) (*Config, error) {
+	cfg, err := Parse(name, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("неверная конфигурация:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// Parse собирает конфигурацию как Load, но не проверяет ее, чтобы
+// неверную конфигурацию можно было показать целиком
+func Parse(name string, args []string) (*Config, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", os.Getenv(ConfigEnv), "файл конфигурации (YAML или TOML); также "+ConfigEnv)
+	// Значения флагов применяются после файла и окружения,
+	// поэтому при разборе они только запоминаются
+	var flagValues []func() error
+	for _, f := range fields {
+		f := f
+		record := func(s string) error {
+			if err := f.parse(s); err != nil {
+				return err
+			}
+			flagValues = append(flagValues, func() error { return f.set(s) })
+			return nil
+		}
+		// Логические флаги можно указывать без значения: -metrics.enabled
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(f.flag, f.usage, record)
+		} else {
+			fs.Func(f.flag, f.usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, ErrUsage
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("лишние аргументы: %s", strings.Join(fs.Args(), " "))
+	}
+
+	if *path != "" {
+		if err := loadFile(*path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range fields {
+		if s, ok := os.LookupEnv(f.env); ok {
+			if err := f.set(s); err != nil {
+				return nil, fmt.Errorf("%s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, apply := range flagValues {
+		if err := apply(); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// loadFile читает файл конфигурации; формат определяется по расширению.
+// Неизвестные ключи считаются ошибкой, чтобы опечатки не терялись молча.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, cfg)
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.NewDecoder(bytes.NewReader(data)).Decode(cfg)
+		if err == nil {
+			if undecoded := md.Undecoded(); len(undecoded) > 0 {
+				err = fmt.Errorf("неизвестный ключ %s", undecoded[0])
+			}
+		}
+	default:
+		return fmt.Errorf("неподдерживаемый формат файла конфигурации %s: ожидается .yaml, .yml или .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("неверный файл конфигурации %s: %w", path, err)
+	}
+	return nil
+}
+
+// field — одно поле конфигурации вместе с его именами в источниках
+type field struct {
+	key    string // server.addr
+	env    string // NOTES_SERVER_ADDR
+	flag   string // server.addr
+	usage  string
+	secret bool
+	reload bool
+	value  reflect.Value
+}
+
+// fields перечисляет поля разделов конфигурации
+func (c *Config) fields() []field {
+	var fields []field
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i)
+		sectionKey := section.Tag.Get("yaml")
+		for j := 0; j < section.Type.NumField(); j++ {
+			sf := section.Type.Field(j)
+			key := sectionKey + "." + sf.Tag.Get("yaml")
+			fields = append(fields, field{
+				key:    key,
+				env:    EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_")),
+				flag:   key,
+				usage:  sf.Tag.Get("usage"),
+				secret: sf.Tag.Get("secret") == "true",
+				reload: sf.Tag.Get("reload") == "true",
+				value:  root.Field(i).Field(j),
+			})
+		}
+	}
+	return fields
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parse проверяет строковое значение, не изменяя поле
+func (f field) parse(s string) error {
+	_, err := f.convert(s)
+	return err
+}
+
+// set присваивает полю строковое значение
+func (f field) set(s string) error {
+	v, err := f.convert(s)
+	if err != nil {
+		return err
+	}
+	f.value.Set(v)
+	return nil
+}
+
+func (f field) convert(s string) (reflect.Value, error) {
+	t := f.value.Type()
+	switch {
+	case t == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("неверная длительность %q", s)
+		}
+		return reflect.ValueOf(d), nil
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(s).Convert(t), nil
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("неверное логическое значение %q", s)
+		}
+		return reflect.ValueOf(b).Convert(t), nil
+	case t.Kind() == reflect.Float64:
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("неверное число %q", s)
+		}
+		return reflect.ValueOf(x).Convert(t), nil
+	case t.Kind() == reflect.Int || t.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("неверное число %q", s)
+		}
+		return reflect.ValueOf(n).Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("неподдерживаемый тип поля %s", f.key)
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// redacted заменяет значения секретных полей при выводе
+const redacted = "***"
+
+// Redacted возвращает копию конфигурации со скрытыми секретами
+func (c *Config) Redacted() *Config {
+	cp := *c
+	for _, f := range cp.fields() {
+		if f.secret && !f.value.IsZero() {
+			f.value.SetString(redacted)
+		}
+	}
+	return &cp
+}
+
+// Print выводит конфигурацию в YAML со скрытыми секретами
+func (c *Config) Print(w io.Writer) error {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Diff сравнивает конфигурации и делит измененные ключи на те, что
+// применяются на лету (reload), и те, что требуют перезапуска
+func Diff(old, updated *Config) (reload, restart []string) {
+	oldFields := old.fields()
+	for i, f := range updated.fields() {
+		if reflect.DeepEqual(f.value.Interface(), oldFields[i].value.Interface()) {
+			continue
+		}
+		if f.reload {
+			reload = append(reload, f.key)
+		} else {
+			restart = append(restart, f.key)
+		}
+	}
+	return reload, restart
+}
+
+// ApplyReloadable переносит из updated значения полей, применяемых на лету;
+// остальные поля сохраняют значения, с которыми сервер запущен
+func (c *Config) ApplyReloadable(updated *Config) {
+	updatedFields := updated.fields()
+	for i, f := range c.fields() {
+		if f.reload {
+			f.value.Set(updatedFields[i].value)
+		}
+	}
+}
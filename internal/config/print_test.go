@@ -0,0 +1,55 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(c *Config)
+		wantReload  []string
+		wantRestart []string
+	}{
+		{name: "без изменений", modify: func(c *Config) {}},
+		{name: "токен и уровень журнала", modify: func(c *Config) { c.Admin.Token = "new"; c.Log.Level = "debug" }, wantReload: []string{"admin.token", "log.level"}},
+		{name: "адрес сервера", modify: func(c *Config) { c.Server.Addr = ":9090" }, wantRestart: []string{"server.addr"}},
+		{
+			name:        "оба вида",
+			modify:      func(c *Config) { c.RateLimit.Default = "10/s"; c.Admin.Enabled = true },
+			wantReload:  []string{"rate_limit.default"},
+			wantRestart: []string{"admin.enabled"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := Default()
+			tt.modify(updated)
+			reload, restart := Diff(Default(), updated)
+			if !slices.Equal(reload, tt.wantReload) || !slices.Equal(restart, tt.wantRestart) {
+				t.Errorf("Diff() = %v, %v, want %v, %v", reload, restart, tt.wantReload, tt.wantRestart)
+			}
+		})
+	}
+}
+
+func TestApplyReloadable(t *testing.T) {
+	current := Default()
+	updated := Default()
+	updated.Admin.Token = "new"
+	updated.Validation.RulesFile = "rules.yaml"
+	updated.Server.Addr = ":9090"
+	updated.Admin.Enabled = true
+
+	current.ApplyReloadable(updated)
+	if current.Admin.Token != "new" || current.Validation.RulesFile != "rules.yaml" {
+		t.Errorf("поля reload не перенесены: %+v, %+v", current.Admin, current.Validation)
+	}
+	if current.Server.Addr != Default().Server.Addr || current.Admin.Enabled {
+		t.Errorf("перенесены поля, требующие перезапуска: addr = %q, admin.enabled = %v", current.Server.Addr, current.Admin.Enabled)
+	}
+	if reload, restart := Diff(current, updated); len(reload) != 0 || len(restart) != 2 {
+		t.Errorf("после ApplyReloadable Diff() = %v, %v", reload, restart)
+	}
+}
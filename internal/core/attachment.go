@@ -0,0 +1,21 @@
+package core
+
+import "time"
+
+// Attachment представляет файл, прикрепленный к заметке
+// @Description Метаданные вложения; содержимое хранится по хешу SHA-256
+type Attachment struct {
+	ID          int64
+	NoteID      int64
+	OwnerID     string
+	FileName    string
+	ContentType string
+	Size        int64
+	Hash        string
+	CreatedAt   time.Time
+	// Width и Height заполняются после обработки изображения
+	Width  int
+	Height int
+	// Thumbnails сопоставляет ширину миниатюры с хешем её содержимого
+	Thumbnails map[int]string
+}
@@ -0,0 +1,31 @@
+package core
+
+// BatchOpType определяет вид операции в пакетном запросе
+type BatchOpType string
+
+const (
+	BatchCreate BatchOpType = "create"
+	BatchUpdate BatchOpType = "update"
+	BatchDelete BatchOpType = "delete"
+)
+
+// BatchOp описывает одну операцию пакетной обработки заметок.
+// Для update заполняются только изменяемые поля.
+type BatchOp struct {
+	Type    BatchOpType
+	ID      int64
+	Title   *string
+	Content *string
+}
+
+// BatchResult содержит итог выполнения одной операции пакета
+type BatchResult struct {
+	Index   int
+	Type    BatchOpType
+	ID      int64
+	Applied bool
+	Err     error
+	// OldTitle — заголовок заметки до применения операции update;
+	// читается в той же блокировке, что и изменение
+	OldTitle string
+}
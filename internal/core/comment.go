@@ -0,0 +1,30 @@
+package core
+
+import "time"
+
+// Comment представляет комментарий к заметке
+// @Description Комментарий; ответы на него вложены в Replies
+type Comment struct {
+	ID     int64
+	NoteID int64
+	// ParentID указывает на комментарий, на который дан ответ
+	ParentID *int64 `json:",omitempty"`
+	AuthorID string
+	Body     string
+	// Mentions — пользователи, упомянутые через @имя
+	Mentions  []string `json:",omitempty"`
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+	// Deleted означает, что комментарий удален, но у него остались ответы
+	Deleted bool `json:",omitempty"`
+	// Replies заполняется сервисом при построении ветки и не хранится
+	Replies []Comment `json:",omitempty"`
+}
+
+// Mention — упоминание пользователя в комментарии
+type Mention struct {
+	CommentID int64
+	NoteID    int64
+	AuthorID  string
+	UserID    string
+}
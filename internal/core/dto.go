@@ -1,10 +1,32 @@
 package core
 
+import (
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
 // NoteCreateRequest представляет данные для создания заметки
 // @Description Структура для создания новой заметки
 type NoteCreateRequest struct {
-	Title   string `json:"title" example:"Моя первая заметка"`
-	Content string `json:"content" example:"Текст заметки"`
+	Title   string                     `json:"title" example:"Моя первая заметка"`
+	Content string                     `json:"content" example:"Текст заметки"`
+	Tags    []string                   `json:"tags,omitempty" example:"работа,идеи"`
+	Type    string                     `json:"type,omitempty" example:"text" enums:"text,checklist"`
+	Items   []ChecklistItemCreateInput `json:"items,omitempty"`
+	// Время в формате RFC 3339
+	DueAt      *time.Time `json:"due_at,omitempty" example:"2026-10-25T18:00:00Z"`
+	RemindAt   *time.Time `json:"remind_at,omitempty" example:"2026-10-25T09:00:00Z"`
+	Recurrence string     `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,FR"`
+	// Переменные для подстановки при создании заметки из шаблона
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// ChecklistItemCreateInput представляет пункт списка при создании заметки
+// @Description Пункт списка дел
+type ChecklistItemCreateInput struct {
+	Text    string `json:"text" example:"Купить молоко"`
+	Checked bool   `json:"checked" example:"false"`
 }
 
 // NoteUpdateRequest представляет данные для обновления заметки
@@ -12,6 +34,54 @@ type NoteCreateRequest struct {
 type NoteUpdateRequest struct {
 	Title   *string `json:"title,omitempty" example:"Обновленный заголовок"`
 	Content *string `json:"content,omitempty" example:"Обновленный текст"`
+	// Tags заменяет все метки заметки; пустой список снимает их
+	Tags *[]string `json:"tags,omitempty" example:"работа"`
+	Type *string   `json:"type,omitempty" example:"checklist" enums:"text,checklist"`
+	// Время в формате RFC 3339; пустая строка снимает срок или напоминание
+	DueAt      *string `json:"due_at,omitempty" example:"2026-10-25T18:00:00Z"`
+	RemindAt   *string `json:"remind_at,omitempty" example:"2026-10-25T09:00:00Z"`
+	Recurrence *string `json:"recurrence,omitempty" example:"FREQ=DAILY;COUNT=5"`
+}
+
+// ChecklistItemRequest представляет данные нового пункта списка
+// @Description Текст добавляемого пункта списка
+type ChecklistItemRequest struct {
+	Text string `json:"text" example:"Позвонить в банк"`
+}
+
+// ChecklistReorderRequest представляет новый порядок пунктов списка
+// @Description ID всех пунктов списка в новом порядке
+type ChecklistReorderRequest struct {
+	ItemIDs []int64 `json:"item_ids" example:"3,1,2"`
+}
+
+// TemplateCreateRequest представляет данные для создания шаблона
+// @Description Шаблон заметки; Title и Content поддерживают {{date}}, {{time}}, {{datetime}}, {{user}}, {{title}}, {{.имя}}, {{upper ...}}, {{lower ...}} и условия if/with
+type TemplateCreateRequest struct {
+	Name    string `json:"name" example:"Протокол встречи"`
+	Title   string `json:"title" example:"Встреча {{date}}"`
+	Content string `json:"content" example:"Участники: {{user}}\nПроект: {{.project}}"`
+}
+
+// TemplateUpdateRequest представляет данные для обновления шаблона
+// @Description Структура для частичного обновления шаблона
+type TemplateUpdateRequest struct {
+	Name    *string `json:"name,omitempty" example:"Разбор инцидента"`
+	Title   *string `json:"title,omitempty" example:"Инцидент {{.id}} от {{date}}"`
+	Content *string `json:"content,omitempty" example:"Ответственный: {{user}}"`
+}
+
+// CommentCreateRequest представляет данные нового комментария
+// @Description Комментарий или ответ на комментарий; @имя в тексте упоминает пользователя
+type CommentCreateRequest struct {
+	Body     string `json:"body" example:"@anna посмотри, пожалуйста"`
+	ParentID *int64 `json:"parent_id,omitempty" example:"1"`
+}
+
+// CommentUpdateRequest представляет новый текст комментария
+// @Description Новый текст комментария
+type CommentUpdateRequest struct {
+	Body string `json:"body" example:"Исправленный текст"`
 }
 
 // ErrorResponse представляет стандартный ответ об ошибке
@@ -20,3 +90,100 @@ type ErrorResponse struct {
 	Error   string `json:"error" example:"сообщение об ошибке"`
 	Message string `json:"message,omitempty" example:"Дополнительное описание"`
 }
+
+// DecodeErrorResponse представляет ошибку разбора тела запроса
+// @Description Ошибка разбора JSON-тела запроса: поле и позиция в байтах, если известны
+type DecodeErrorResponse struct {
+	Error  string `json:"error" example:"неизвестное поле"`
+	Field  string `json:"field,omitempty" example:"titel"`
+	Offset int64  `json:"offset,omitempty" example:"14"`
+	// TraceID — идентификатор трассировки запроса для поиска в журналах
+	TraceID string `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+}
+
+// ValidationErrorResponse представляет ответ с нарушениями правил валидации
+// @Description Все нарушения правил валидации по полям
+type ValidationErrorResponse struct {
+	Error  string                  `json:"error" example:"ошибка валидации"`
+	Fields []validation.FieldError `json:"fields"`
+	// TraceID — идентификатор трассировки запроса для поиска в журналах
+	TraceID string `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+}
+
+// NoteBatchOperation представляет одну операцию пакетного запроса
+// @Description Операция пакетного запроса: create, update или delete
+type NoteBatchOperation struct {
+	Op      string  `json:"op" example:"update"`
+	ID      int64   `json:"id,omitempty" example:"1"`
+	Title   *string `json:"title,omitempty" example:"Новый заголовок"`
+	Content *string `json:"content,omitempty" example:"Новый текст"`
+}
+
+// NoteBatchRequest представляет пакетный запрос над заметками
+// @Description Набор операций; при atomic=true применяются все или ни одной
+type NoteBatchRequest struct {
+	Atomic     bool                 `json:"atomic" example:"true"`
+	Operations []NoteBatchOperation `json:"operations"`
+}
+
+// NoteBatchItemResult представляет результат одной операции пакета
+// @Description Результат операции пакета с HTTP-подобным статусом
+type NoteBatchItemResult struct {
+	Index  int    `json:"index" example:"0"`
+	Op     string `json:"op" example:"create"`
+	ID     int64  `json:"id,omitempty" example:"42"`
+	Status int    `json:"status" example:"201"`
+	Error  string `json:"error,omitempty" example:"заметка не найдена"`
+	// Fields перечисляет нарушения правил валидации полей
+	Fields []validation.FieldError `json:"fields,omitempty"`
+}
+
+// NoteBatchResponse представляет ответ на пакетный запрос
+// @Description Итог пакетной обработки с результатами по каждой операции
+type NoteBatchResponse struct {
+	Atomic  bool                  `json:"atomic" example:"true"`
+	Applied bool                  `json:"applied" example:"true"`
+	Results []NoteBatchItemResult `json:"results"`
+}
+
+// NoteImportItem представляет результат импорта одного файла
+// @Description Результат импорта файла: новый ID заметки или ошибка
+type NoteImportItem struct {
+	File     string `json:"file" example:"note-1.md"`
+	SourceID int64  `json:"source_id,omitempty" example:"1"`
+	ID       int64  `json:"id,omitempty" example:"7"`
+	Error    string `json:"error,omitempty" example:"отсутствует front matter"`
+}
+
+// NoteImportResponse представляет итог импорта заметок
+// @Description Итог импорта с результатами по каждому файлу
+type NoteImportResponse struct {
+	Imported int              `json:"imported" example:"1"`
+	Failed   int              `json:"failed" example:"0"`
+	Results  []NoteImportItem `json:"results"`
+}
+
+// BackupIDMapping представляет соответствие ID заметки до и после восстановления
+// @Description Соответствие ID заметки в архиве и нового ID
+type BackupIDMapping struct {
+	Old int64 `json:"old" example:"1"`
+	New int64 `json:"new" example:"12"`
+}
+
+// BackupImportResponse представляет итог восстановления из архива
+// @Description Итог восстановления: режим, количество восстановленных записей и переназначенные ID заметок
+type BackupImportResponse struct {
+	Mode        string            `json:"mode" example:"merge"`
+	Deleted     int               `json:"deleted" example:"0"`
+	Imported    int               `json:"imported" example:"10"`
+	Comments    int               `json:"comments" example:"4"`
+	Attachments int               `json:"attachments" example:"2"`
+	Templates   int               `json:"templates" example:"1"`
+	IDMap       []BackupIDMapping `json:"id_map"`
+}
+
+// LogLevel представляет уровень журнала сервера
+// @Description Уровень журнала: debug, info, warn или error
+type LogLevel struct {
+	Level string `json:"level" example:"info"`
+}
@@ -0,0 +1,10 @@
+package core
+
+// NoteLink представляет вики-ссылку из одной заметки на другую
+// @Description Ссылка между заметками; Broken=true, если цель не найдена
+type NoteLink struct {
+	SourceID int64
+	TargetID int64
+	Ref      string
+	Broken   bool
+}
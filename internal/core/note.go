@@ -2,12 +2,96 @@ package core
 
 import "time"
 
+// NoteType определяет вид заметки
+type NoteType string
+
+const (
+	NoteTypeText      NoteType = "text"
+	NoteTypeChecklist NoteType = "checklist"
+)
+
 // Note представляет сущность заметки в системе
 // @Description Основная структура заметки
 type Note struct {
-	ID        int64
-	Title     string
-	Content   string
+	ID      int64
+	Title   string
+	Content string
+	// Tags — метки заметки без повторов без учета регистра
+	Tags      []string `json:",omitempty"`
 	CreatedAt time.Time
 	UpdatedAt *time.Time
+	Type      NoteType
+	Items     []ChecklistItem `json:",omitempty"`
+	// Progress вычисляется сервисом для списков и не хранится
+	Progress *ChecklistProgress `json:",omitempty"`
+	// CommentCount вычисляется сервисом для ответов API и не хранится
+	CommentCount int        `json:",omitempty"`
+	DueAt        *time.Time `json:",omitempty"`
+	// RemindAt — время ближайшего напоминания; снимается после срабатывания
+	// или переносится на следующее повторение по правилу Recurrence
+	RemindAt   *time.Time `json:",omitempty"`
+	Recurrence string     `json:",omitempty"`
+	// Закрепленные заметки всегда идут первыми, архивные скрыты из списка по умолчанию
+	Pinned   bool
+	Archived bool
+	Favorite bool
+}
+
+// NoteFlag определяет переключаемое состояние заметки
+type NoteFlag string
+
+const (
+	FlagPinned   NoteFlag = "pinned"
+	FlagArchived NoteFlag = "archived"
+	FlagFavorite NoteFlag = "favorite"
+)
+
+// NoteFilter задает условия выборки заметок; пустые поля не ограничивают выборку
+type NoteFilter struct {
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	Pinned    *bool
+	Archived  *bool
+	Favorite  *bool
+}
+
+// Matches сообщает, подходит ли заметка под фильтр
+func (f NoteFilter) Matches(n Note) bool {
+	if f.DueBefore != nil && (n.DueAt == nil || !n.DueAt.Before(*f.DueBefore)) {
+		return false
+	}
+	if f.DueAfter != nil && (n.DueAt == nil || !n.DueAt.After(*f.DueAfter)) {
+		return false
+	}
+	if f.Pinned != nil && n.Pinned != *f.Pinned {
+		return false
+	}
+	if f.Archived != nil && n.Archived != *f.Archived {
+		return false
+	}
+	if f.Favorite != nil && n.Favorite != *f.Favorite {
+		return false
+	}
+	return true
+}
+
+// ChecklistItem представляет пункт заметки-списка
+// @Description Пункт списка дел
+type ChecklistItem struct {
+	ID      int64
+	Text    string
+	Checked bool
+	Order   int
+}
+
+// ChecklistProgress показывает, сколько пунктов списка выполнено
+// @Description Прогресс выполнения списка (например, 3 из 7)
+type ChecklistProgress struct {
+	Done  int
+	Total int
+}
+
+// IsChecklist сообщает, является ли заметка списком дел
+func (n Note) IsChecklist() bool {
+	return n.Type == NoteTypeChecklist
 }
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/imaging"
+)
+
+const (
+	// maxImageSize ограничивает размер обрабатываемых изображений
+	maxImageSize = 32 << 20
+	// defaultThumbnailWidth — ширина миниатюры, создаваемой сразу после загрузки
+	defaultThumbnailWidth = 256
+)
+
+// thumbnailWidths — допустимые ширины миниатюр; запрошенная ширина
+// округляется вверх до ближайшей, чтобы число вариантов было ограничено
+var thumbnailWidths = []int{64, 128, 256, 512, 1024}
+
+// ThumbnailWidth возвращает допустимую ширину миниатюры для запрошенной
+func ThumbnailWidth(width int) int {
+	if width <= 0 {
+		return defaultThumbnailWidth
+	}
+	for _, w := range thumbnailWidths {
+		if width <= w {
+			return w
+		}
+	}
+	return thumbnailWidths[len(thumbnailWidths)-1]
+}
+
+func (s *attachmentServiceImpl) Thumbnail(ctx context.Context, id int64, width int) (*core.Attachment, io.ReadSeekCloser, error) {
+	width = ThumbnailWidth(width)
+
+	a, err := s.GetAttachment(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !imaging.Supported(a.ContentType) {
+		return nil, nil, errors.New("вложение не является изображением")
+	}
+
+	if _, ok := a.Thumbnails[width]; !ok {
+		// Миниатюра строится в пуле, запрос лишь ожидает результата
+		done := make(chan error, 1)
+		if err := s.images.Submit(func() { done <- s.makeThumbnail(context.Background(), id, width) }); err != nil {
+			return nil, nil, err
+		}
+		select {
+		case err := <-done:
+			if err != nil {
+				return nil, nil, err
+			}
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+
+		if a, err = s.GetAttachment(ctx, id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rc, err := s.blobs.Open(ctx, a.Thumbnails[width])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, rc, nil
+}
+
+// processImage записывает размеры и MIME-тип изображения и создает
+// миниатюру по умолчанию. Метаданные удалены еще при загрузке.
+// Выполняется в пуле.
+func (s *attachmentServiceImpl) processImage(ctx context.Context, id int64) error {
+	a, err := s.attachments.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if a.Width > 0 {
+		return nil
+	}
+
+	data, err := s.readBlob(ctx, a.Hash)
+	if err != nil {
+		return err
+	}
+	info, err := imaging.Inspect(data)
+	if err != nil {
+		return err
+	}
+	thumb, _, err := imaging.Thumbnail(data, defaultThumbnailWidth)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.attachments.GetByID(ctx, id)
+	if err != nil {
+		// Вложение удалено во время обработки
+		return nil
+	}
+
+	thumbHash, _, err := s.blobs.Put(ctx, bytes.NewReader(thumb))
+	if err != nil {
+		return err
+	}
+
+	current.ContentType = info.ContentType
+	current.Width = info.Width
+	current.Height = info.Height
+	if current.Thumbnails == nil {
+		current.Thumbnails = make(map[int]string)
+	}
+	current.Thumbnails[defaultThumbnailWidth] = thumbHash
+	return s.attachments.Update(ctx, *current)
+}
+
+// makeThumbnail создает миниатюру заданной ширины. Выполняется в пуле.
+func (s *attachmentServiceImpl) makeThumbnail(ctx context.Context, id int64, width int) error {
+	// Размеры и миниатюра по умолчанию, если пул еще не обработал изображение
+	if err := s.processImage(ctx, id); err != nil {
+		return err
+	}
+
+	a, err := s.attachments.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, ok := a.Thumbnails[width]; ok {
+		return nil
+	}
+
+	data, err := s.readBlob(ctx, a.Hash)
+	if err != nil {
+		return err
+	}
+	thumb, _, err := imaging.Thumbnail(data, width)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.attachments.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	hash, _, err := s.blobs.Put(ctx, bytes.NewReader(thumb))
+	if err != nil {
+		return err
+	}
+	if current.Thumbnails == nil {
+		current.Thumbnails = make(map[int]string)
+	}
+	current.Thumbnails[width] = hash
+
+	return s.attachments.Update(ctx, *current)
+}
+
+func (s *attachmentServiceImpl) readBlob(ctx context.Context, hash string) ([]byte, error) {
+	rc, err := s.blobs.Open(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return imaging.ReadAll(rc, maxImageSize)
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ybotet/pz12-notes-api/internal/blob"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/imaging"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+)
+
+// AttachmentService определяет интерфейс бизнес-логики вложений
+type AttachmentService interface {
+	Upload(ctx context.Context, noteID int64, fileName, contentType string, r io.Reader) (*core.Attachment, error)
+	GetAttachment(ctx context.Context, id int64) (*core.Attachment, error)
+	ListAttachments(ctx context.Context, noteID int64) ([]core.Attachment, error)
+	// Open возвращает метаданные и содержимое вложения; вызывающий закрывает reader
+	Open(ctx context.Context, id int64) (*core.Attachment, io.ReadSeekCloser, error)
+	DeleteAttachment(ctx context.Context, id int64) error
+	// DeleteNoteAttachments удаляет вложения заметки и освободившиеся блобы
+	DeleteNoteAttachments(ctx context.Context, noteID int64) error
+	// CollectGarbage удаляет вложения удаленных заметок и блобы без ссылок
+	CollectGarbage(ctx context.Context) (int, error)
+	// Thumbnail возвращает миниатюру изображения шириной не меньше width
+	Thumbnail(ctx context.Context, id int64, width int) (*core.Attachment, io.ReadSeekCloser, error)
+}
+
+// attachmentServiceImpl реализует AttachmentService
+type attachmentServiceImpl struct {
+	notes       repo.NoteRepository
+	attachments repo.AttachmentRepository
+	blobs       blob.Store
+	quota       int64
+	images      *imaging.Pool
+
+	// mu упорядочивает сохранение вложений и сборку мусора,
+	// чтобы только что загруженный блоб не был удален как лишний
+	mu sync.Mutex
+}
+
+// NewAttachmentService создает сервис вложений с квотой quota байт на пользователя.
+// Изображения обрабатываются в пуле images, а не в горутине запроса.
+func NewAttachmentService(notes repo.NoteRepository, attachments repo.AttachmentRepository, blobs blob.Store, quota int64, images *imaging.Pool) AttachmentService {
+	return &attachmentServiceImpl{
+		notes:       notes,
+		attachments: attachments,
+		blobs:       blobs,
+		quota:       quota,
+		images:      images,
+	}
+}
+
+func (s *attachmentServiceImpl) Upload(ctx context.Context, noteID int64, fileName, contentType string, r io.Reader) (*core.Attachment, error) {
+	if noteID <= 0 {
+		return nil, errors.New("неверный ID")
+	}
+	if _, err := s.notes.GetByID(ctx, noteID); err != nil {
+		return nil, err
+	}
+
+	fileName = filepath.Base(strings.TrimSpace(fileName))
+	if fileName == "" || fileName == "." || fileName == string(filepath.Separator) {
+		return nil, errors.New("имя файла не может быть пустым")
+	}
+
+	br := bufio.NewReader(r)
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = detectContentType(fileName, br)
+	}
+
+	owner := core.UserFromContext(ctx)
+	used, err := s.usage(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if used >= s.quota {
+		return nil, errors.New("превышена квота хранилища вложений")
+	}
+
+	// Содержимое принимается от клиента без блокировки: не больше
+	// остатка квоты плюс один байт, чтобы обнаружить превышение
+	content, size, err := readContent(br, s.quota-used+1)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	// Под блокировкой остаются проверка квоты с учетом параллельных
+	// загрузок и сохранение, чтобы блоб не был удален сборкой мусора
+	// до создания ссылающегося на него вложения
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if used, err = s.usage(ctx, owner); err != nil {
+		return nil, err
+	}
+	if used+size > s.quota {
+		return nil, errors.New("превышена квота хранилища вложений")
+	}
+
+	hash, size, err := s.blobs.Put(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	a := core.Attachment{
+		NoteID:      noteID,
+		OwnerID:     owner,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        size,
+		Hash:        hash,
+	}
+	id, err := s.attachments.Create(ctx, a)
+	if err != nil {
+		s.deleteIfUnreferenced(ctx, hash)
+		return nil, err
+	}
+
+	// Если очередь заполнена, изображение будет обработано при запросе миниатюры
+	// Обработка идет после ответа, поэтому в задачу переносится только
+	// журнал запроса, а не его контекст
+	if imaging.Supported(contentType) {
+		logger := logging.FromContext(ctx)
+		err := s.images.Submit(func() {
+			if err := s.processImage(logging.WithLogger(context.Background(), logger), id); err != nil {
+				logger.Error("ошибка обработки изображения", slog.Int64("attachment_id", id), slog.Any("error", err))
+			}
+		})
+		if err != nil {
+			logger.DebugContext(ctx, "обработка изображения отложена", slog.Int64("attachment_id", id), slog.Any("error", err))
+		}
+	}
+
+	return s.attachments.GetByID(ctx, id)
+}
+
+func (s *attachmentServiceImpl) GetAttachment(ctx context.Context, id int64) (*core.Attachment, error) {
+	if id <= 0 {
+		return nil, errors.New("неверный ID")
+	}
+
+	return s.attachments.GetByID(ctx, id)
+}
+
+func (s *attachmentServiceImpl) ListAttachments(ctx context.Context, noteID int64) ([]core.Attachment, error) {
+	if noteID <= 0 {
+		return nil, errors.New("неверный ID")
+	}
+	if _, err := s.notes.GetByID(ctx, noteID); err != nil {
+		return nil, err
+	}
+
+	return s.attachments.ListByNote(ctx, noteID)
+}
+
+func (s *attachmentServiceImpl) Open(ctx context.Context, id int64) (*core.Attachment, io.ReadSeekCloser, error) {
+	a, err := s.GetAttachment(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rc, err := s.blobs.Open(ctx, a.Hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, rc, nil
+}
+
+func (s *attachmentServiceImpl) DeleteAttachment(ctx context.Context, id int64) error {
+	a, err := s.GetAttachment(ctx, id)
+	if err != nil {
+		return err
+	}
+	if a.OwnerID != core.UserFromContext(ctx) {
+		return errors.New("нет прав: вложение может удалить только его владелец")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.attachments.Delete(ctx, id); err != nil {
+		return err
+	}
+	for _, hash := range attachmentHashes(*a) {
+		s.deleteIfUnreferenced(ctx, hash)
+	}
+
+	return nil
+}
+
+func (s *attachmentServiceImpl) DeleteNoteAttachments(ctx context.Context, noteID int64) error {
+	attachments, err := s.attachments.ListByNote(ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range attachments {
+		if err := s.attachments.Delete(ctx, a.ID); err != nil {
+			return err
+		}
+		for _, hash := range attachmentHashes(a) {
+			s.deleteIfUnreferenced(ctx, hash)
+		}
+	}
+
+	return nil
+}
+
+func (s *attachmentServiceImpl) CollectGarbage(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attachments, err := s.attachments.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	referenced := make(map[string]bool, len(attachments))
+	for _, a := range attachments {
+		if _, err := s.notes.GetByID(ctx, a.NoteID); err != nil {
+			if !strings.Contains(err.Error(), "не найдена") {
+				return removed, err
+			}
+			if err := s.attachments.Delete(ctx, a.ID); err != nil {
+				return removed, err
+			}
+			continue
+		}
+		for _, hash := range attachmentHashes(a) {
+			referenced[hash] = true
+		}
+	}
+
+	hashes, err := s.blobs.List(ctx)
+	if err != nil {
+		return removed, err
+	}
+	for _, hash := range hashes {
+		if referenced[hash] {
+			continue
+		}
+		if err := s.blobs.Delete(ctx, hash); err != nil && !errors.Is(err, blob.ErrNotFound) {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// usage возвращает суммарный размер вложений пользователя
+func (s *attachmentServiceImpl) usage(ctx context.Context, owner string) (int64, error) {
+	attachments, err := s.attachments.ListByOwner(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, a := range attachments {
+		total += a.Size
+	}
+	return total, nil
+}
+
+// deleteIfUnreferenced удаляет блоб, если на него не ссылается ни одно вложение.
+// Вызывается под s.mu.
+func (s *attachmentServiceImpl) deleteIfUnreferenced(ctx context.Context, hash string) {
+	attachments, err := s.attachments.GetAll(ctx)
+	if err != nil {
+		return
+	}
+	for _, a := range attachments {
+		for _, h := range attachmentHashes(a) {
+			if h == hash {
+				return
+			}
+		}
+	}
+	// Ошибка не критична: блоб будет удален при следующей сборке мусора
+	s.blobs.Delete(ctx, hash)
+}
+
+// detectContentType определяет MIME-тип по расширению или первым байтам содержимого
+func detectContentType(fileName string, br *bufio.Reader) string {
+	if ct := mime.TypeByExtension(filepath.Ext(fileName)); ct != "" {
+		return ct
+	}
+
+	head, _ := br.Peek(512)
+	return http.DetectContentType(head)
+}
+
+// readContent читает не больше limit байт содержимого вложения. Из
+// изображений в памяти удаляются метаданные EXIF и XMP, чтобы координаты
+// съемки не попадали в хранилище; формат определяется по содержимому,
+// а не по заявленному типу. Остальное содержимое пишется во временный
+// файл, который удаляется при закрытии.
+func readContent(br *bufio.Reader, limit int64) (io.ReadCloser, int64, error) {
+	head, _ := br.Peek(512)
+	if imaging.Supported(http.DetectContentType(head)) {
+		data, err := imaging.ReadAll(io.LimitReader(br, limit), maxImageSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		// После удаления метаданных изображение может уложиться в квоту,
+		// поэтому превышение проверяется по исходному размеру
+		if int64(len(data)) >= limit {
+			return nil, 0, errors.New("превышена квота хранилища вложений")
+		}
+		if data, err = imaging.StripEXIF(data); err != nil {
+			return nil, 0, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+	}
+
+	f, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	tmp := &tempFile{File: f}
+	size, err := io.Copy(f, io.LimitReader(br, limit))
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		return nil, 0, err
+	}
+	return tmp, size, nil
+}
+
+// tempFile удаляет временный файл при закрытии
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// attachmentHashes возвращает хеши всех блобов вложения, включая миниатюры
+func attachmentHashes(a core.Attachment) []string {
+	hashes := []string{a.Hash}
+	for _, hash := range a.Thumbnails {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
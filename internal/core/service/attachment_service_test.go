@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/blob"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/imaging"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+)
+
+// newTestAttachmentService создает сервис с пулом без обработчиков:
+// каждая отправка задачи завершается ErrPoolBusy
+func newTestAttachmentService(t *testing.T, quota int64) (AttachmentService, int64) {
+	t.Helper()
+	notes := repo.NewNoteRepoMem()
+	noteID, err := notes.Create(context.Background(), core.Note{Title: "Заметка"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := imaging.NewPool(0, 0)
+	t.Cleanup(pool.Close)
+	return NewAttachmentService(notes, repo.NewAttachmentRepoMem(), blob.NewMemStore(), quota, pool), noteID
+}
+
+func jpegWithEXIF(t *testing.T, exif string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	payload := "Exif\x00\x00" + exif
+	out := append([]byte{}, data[:2]...)
+	out = append(out, 0xFF, 0xE1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	out = append(out, payload...)
+	return append(out, data[2:]...)
+}
+
+func TestUploadStripsImageMetadataBeforeStoring(t *testing.T) {
+	const gps = "GPSLatitude=55.75"
+	photo := jpegWithEXIF(t, gps)
+
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		data        []byte
+		wantGPS     bool
+	}{
+		{name: "фото", fileName: "photo.jpg", contentType: "image/jpeg", data: photo},
+		// Формат определяется по содержимому, а не по имени и типу
+		{name: "фото под видом файла", fileName: "photo.bin", contentType: "application/octet-stream", data: photo},
+		{name: "текст", fileName: "notes.txt", contentType: "text/plain", data: []byte(gps), wantGPS: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, noteID := newTestAttachmentService(t, 1<<20)
+			ctx := context.Background()
+
+			a, err := svc.Upload(ctx, noteID, tt.fileName, tt.contentType, bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Обработка в пуле не выполнялась, но содержимое уже очищено
+			_, rc, err := svc.Open(ctx, a.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(got, []byte(gps)) != tt.wantGPS {
+				t.Errorf("содержит метаданные = %v, want %v", !tt.wantGPS, tt.wantGPS)
+			}
+			if a.Size != int64(len(got)) {
+				t.Errorf("Size = %d, want %d", a.Size, len(got))
+			}
+		})
+	}
+}
+
+func TestUploadRejectsOversizedImage(t *testing.T) {
+	svc, noteID := newTestAttachmentService(t, 1<<30)
+
+	data := append(jpegWithEXIF(t, ""), make([]byte, maxImageSize)...)
+	_, err := svc.Upload(context.Background(), noteID, "big.jpg", "image/jpeg", bytes.NewReader(data))
+	if err == nil {
+		t.Fatal("ожидалась ошибка для изображения больше maxImageSize")
+	}
+}
+
+func TestUploadQuota(t *testing.T) {
+	svc, noteID := newTestAttachmentService(t, 10)
+	alice := core.WithUser(context.Background(), "alice")
+	bob := core.WithUser(context.Background(), "bob")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		data    string
+		wantErr bool
+	}{
+		{name: "в пределах квоты", ctx: alice, data: "123456"},
+		{name: "превышение остатка", ctx: alice, data: "12345", wantErr: true},
+		{name: "ровно до квоты", ctx: alice, data: "1234"},
+		{name: "квота исчерпана", ctx: alice, data: "1", wantErr: true},
+		{name: "квота другого пользователя", ctx: bob, data: "1234567890"},
+	}
+	for _, tt := range tests {
+		_, err := svc.Upload(tt.ctx, noteID, "f.txt", "text/plain", strings.NewReader(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// blockingReader отдает данные только после закрытия release
+type blockingReader struct {
+	release chan struct{}
+	data    io.Reader
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	<-r.release
+	return r.data.Read(p)
+}
+
+func TestUploadDoesNotBlockOnSlowClient(t *testing.T) {
+	svc, noteID := newTestAttachmentService(t, 1<<20)
+	ctx := context.Background()
+
+	slow := &blockingReader{release: make(chan struct{}), data: strings.NewReader("медленно")}
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.Upload(ctx, noteID, "slow.txt", "text/plain", slow)
+		done <- err
+	}()
+
+	// Пока медленный клиент передает файл, остальные загрузки, удаление
+	// и сборка мусора не ждут его
+	a, err := svc.Upload(ctx, noteID, "fast.txt", "text/plain", strings.NewReader("быстро"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteAttachment(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CollectGarbage(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	close(slow.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteAttachmentOwnership(t *testing.T) {
+	svc, noteID := newTestAttachmentService(t, 1<<20)
+	alice := core.WithUser(context.Background(), "alice")
+	bob := core.WithUser(context.Background(), "bob")
+
+	a, err := svc.Upload(alice, noteID, "f.txt", "text/plain", strings.NewReader("данные"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr string
+	}{
+		{name: "чужое вложение", ctx: bob, wantErr: "нет прав"},
+		{name: "анонимный пользователь", ctx: context.Background(), wantErr: "нет прав"},
+		{name: "владелец", ctx: alice},
+		{name: "уже удалено", ctx: alice, wantErr: "не найдено"},
+	}
+	for _, tt := range tests {
+		err := svc.DeleteAttachment(tt.ctx, a.ID)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+	"github.com/ybotet/pz12-notes-api/internal/mention"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+// CommentService определяет интерфейс бизнес-логики комментариев
+type CommentService interface {
+	// AddComment добавляет комментарий от текущего пользователя;
+	// parentID задает комментарий, на который дан ответ
+	AddComment(ctx context.Context, noteID int64, parentID *int64, body string) (*core.Comment, error)
+	// GetThread возвращает комментарии заметки деревом: ответы вложены в Replies
+	GetThread(ctx context.Context, noteID int64) ([]core.Comment, error)
+	// EditComment и DeleteComment доступны только автору комментария
+	EditComment(ctx context.Context, noteID, id int64, body string) (*core.Comment, error)
+	DeleteComment(ctx context.Context, noteID, id int64) error
+	// OnMention регистрирует обработчик упоминаний; вызывается при запуске
+	OnMention(hook MentionHook)
+}
+
+// MentionHook вызывается для каждого нового упоминания пользователя
+type MentionHook func(ctx context.Context, m core.Mention) error
+
+// commentServiceImpl реализует CommentService
+type commentServiceImpl struct {
+	notes     repo.NoteRepository
+	comments  repo.CommentRepository
+	validator *validation.Validator
+	onMention []MentionHook
+}
+
+// NewCommentService создает новый экземпляр сервиса комментариев
+func NewCommentService(notes repo.NoteRepository, comments repo.CommentRepository, validator *validation.Validator) CommentService {
+	return &commentServiceImpl{notes: notes, comments: comments, validator: validator}
+}
+
+func (s *commentServiceImpl) AddComment(ctx context.Context, noteID int64, parentID *int64, body string) (*core.Comment, error) {
+	if err := s.checkNote(ctx, noteID); err != nil {
+		return nil, err
+	}
+	body, err := s.validateBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		parent, err := s.getComment(ctx, noteID, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Deleted {
+			return nil, errors.New("нельзя ответить на удаленный комментарий")
+		}
+	}
+
+	c := core.Comment{
+		NoteID:   noteID,
+		ParentID: parentID,
+		AuthorID: core.UserFromContext(ctx),
+		Body:     body,
+		Mentions: mention.Parse(body),
+	}
+	id, err := s.comments.Create(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.comments.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyMentions(ctx, *created, created.Mentions)
+	return created, nil
+}
+
+func (s *commentServiceImpl) GetThread(ctx context.Context, noteID int64) ([]core.Comment, error) {
+	if err := s.checkNote(ctx, noteID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.comments.ListByNote(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildThread(comments), nil
+}
+
+func (s *commentServiceImpl) EditComment(ctx context.Context, noteID, id int64, body string) (*core.Comment, error) {
+	c, err := s.authorComment(ctx, noteID, id)
+	if err != nil {
+		return nil, err
+	}
+	body, err = s.validateBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := c.Mentions
+	c.Body = body
+	c.Mentions = mention.Parse(body)
+	if err := s.comments.Update(ctx, *c); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.comments.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Уведомить только тех, кого упомянули при редактировании впервые
+	s.notifyMentions(ctx, *updated, mention.Added(previous, updated.Mentions))
+	return updated, nil
+}
+
+func (s *commentServiceImpl) DeleteComment(ctx context.Context, noteID, id int64) error {
+	c, err := s.authorComment(ctx, noteID, id)
+	if err != nil {
+		return err
+	}
+
+	comments, err := s.comments.ListByNote(ctx, noteID)
+	if err != nil {
+		return err
+	}
+
+	// Комментарий с ответами остается в ветке как удаленный, чтобы
+	// ответы не потеряли контекст
+	if hasReplies(comments, id) {
+		c.Body = ""
+		c.Mentions = nil
+		c.Deleted = true
+		return s.comments.Update(ctx, *c)
+	}
+
+	if err := s.comments.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	// Удаленные родители, у которых не осталось ответов, больше не нужны
+	for parentID := c.ParentID; parentID != nil; {
+		parent, err := s.comments.GetByID(ctx, *parentID)
+		if err != nil || !parent.Deleted {
+			break
+		}
+		comments, err = s.comments.ListByNote(ctx, noteID)
+		if err != nil {
+			return err
+		}
+		if hasReplies(comments, parent.ID) {
+			break
+		}
+		if err := s.comments.Delete(ctx, parent.ID); err != nil {
+			return err
+		}
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+func (s *commentServiceImpl) OnMention(hook MentionHook) {
+	s.onMention = append(s.onMention, hook)
+}
+
+// checkNote проверяет, что заметка существует
+func (s *commentServiceImpl) checkNote(ctx context.Context, noteID int64) error {
+	if noteID <= 0 {
+		return errors.New("неверный ID")
+	}
+	_, err := s.notes.GetByID(ctx, noteID)
+	return err
+}
+
+// getComment возвращает комментарий, если он относится к заметке noteID
+func (s *commentServiceImpl) getComment(ctx context.Context, noteID, id int64) (*core.Comment, error) {
+	if id <= 0 {
+		return nil, errors.New("неверный ID комментария")
+	}
+
+	c, err := s.comments.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.NoteID != noteID {
+		return nil, errors.New("комментарий не найден")
+	}
+	return c, nil
+}
+
+// authorComment возвращает комментарий, если текущий пользователь — его автор
+func (s *commentServiceImpl) authorComment(ctx context.Context, noteID, id int64) (*core.Comment, error) {
+	if err := s.checkNote(ctx, noteID); err != nil {
+		return nil, err
+	}
+	c, err := s.getComment(ctx, noteID, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Deleted {
+		return nil, errors.New("комментарий не найден")
+	}
+	if c.AuthorID != core.UserFromContext(ctx) {
+		return nil, errors.New("нет прав: комментарий может изменять только автор")
+	}
+	return c, nil
+}
+
+// notifyMentions вызывает обработчики для упомянутых пользователей,
+// кроме самого автора. Комментарий к этому моменту уже сохранен, поэтому
+// ошибки обработчиков только записываются в журнал: вернуть их клиенту
+// значило бы спровоцировать повтор запроса и дубликат комментария.
+func (s *commentServiceImpl) notifyMentions(ctx context.Context, c core.Comment, users []string) {
+	for _, user := range users {
+		if strings.EqualFold(user, c.AuthorID) {
+			continue
+		}
+		m := core.Mention{CommentID: c.ID, NoteID: c.NoteID, AuthorID: c.AuthorID, UserID: user}
+		for _, hook := range s.onMention {
+			if err := hook(ctx, m); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "ошибка обработки упоминания",
+					slog.Int64("comment_id", c.ID), slog.String("user_id", user), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// validateBody проверяет и нормализует текст комментария
+func (s *commentServiceImpl) validateBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	return body, s.validator.Check().Field(validation.FieldComment, body).Err()
+}
+
+// hasReplies сообщает, есть ли у комментария id ответы
+func hasReplies(comments []core.Comment, id int64) bool {
+	for _, c := range comments {
+		if c.ParentID != nil && *c.ParentID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// buildThread строит дерево комментариев; comments упорядочены по созданию
+func buildThread(comments []core.Comment) []core.Comment {
+	children := make(map[int64][]core.Comment)
+	var roots []core.Comment
+	for _, c := range comments {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var attach func(c core.Comment) core.Comment
+	attach = func(c core.Comment) core.Comment {
+		for _, reply := range children[c.ID] {
+			c.Replies = append(c.Replies, attach(reply))
+		}
+		return c
+	}
+
+	thread := make([]core.Comment, 0, len(roots))
+	for _, c := range roots {
+		thread = append(thread, attach(c))
+	}
+	return thread
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+func TestMentionHookErrorKeepsComment(t *testing.T) {
+	ctx := core.WithUser(context.Background(), "alice")
+	notes := repo.NewNoteRepoMem()
+	comments := repo.NewCommentRepoMem()
+	noteID, err := notes.Create(ctx, core.Note{Title: "Заметка"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewCommentService(notes, comments, validation.New(validation.DefaultRules()))
+	var notified []string
+	s.OnMention(func(ctx context.Context, m core.Mention) error {
+		notified = append(notified, m.UserID)
+		if m.UserID == "bob" {
+			return errors.New("уведомление не отправлено")
+		}
+		return nil
+	})
+
+	c, err := s.AddComment(ctx, noteID, nil, "@bob и @carol, посмотрите")
+	if err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+	if len(notified) != 2 {
+		t.Errorf("notified = %q, want bob and carol", notified)
+	}
+
+	edited, err := s.EditComment(ctx, noteID, c.ID, "@bob, @carol и @dave, посмотрите")
+	if err != nil {
+		t.Fatalf("EditComment() error = %v", err)
+	}
+	if edited.ID != c.ID {
+		t.Errorf("EditComment() ID = %d, want %d", edited.ID, c.ID)
+	}
+
+	thread, err := s.GetThread(ctx, noteID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread) != 1 {
+		t.Errorf("thread has %d comments, want 1", len(thread))
+	}
+}
+
+func TestCommentCount(t *testing.T) {
+	ctx := core.WithUser(context.Background(), "alice")
+	notes := repo.NewNoteRepoMem()
+	comments := repo.NewCommentRepoMem()
+	validator := validation.New(validation.DefaultRules())
+	noteService := NewNoteService(notes, repo.NewLinkRepoMem(), comments, validator)
+	s := NewCommentService(notes, comments, validator)
+	first := createNote(t, noteService, "Первая", "")
+	second := createNote(t, noteService, "Вторая", "")
+
+	// check сверяет счетчик с GetNote, ListNotes и перебором комментариев
+	check := func(step string, want map[int64]int) {
+		t.Helper()
+		listed, err := noteService.ListNotes(ctx, core.NoteFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, note := range listed {
+			if note.CommentCount != want[note.ID] {
+				t.Errorf("%s: ListNotes() заметка %d CommentCount = %d, want %d", step, note.ID, note.CommentCount, want[note.ID])
+			}
+		}
+		for noteID, n := range want {
+			all, _ := comments.ListByNote(ctx, noteID)
+			live := 0
+			for _, c := range all {
+				if !c.Deleted {
+					live++
+				}
+			}
+			if got, _ := comments.CountForNote(ctx, noteID); got != n || live != n {
+				t.Errorf("%s: CountForNote(%d) = %d, перебор %d, want %d", step, noteID, got, live, n)
+			}
+			if note, err := noteService.GetNote(ctx, noteID); err == nil && note.CommentCount != n {
+				t.Errorf("%s: GetNote(%d).CommentCount = %d, want %d", step, noteID, note.CommentCount, n)
+			}
+		}
+	}
+	add := func(noteID int64, parentID *int64, body string) int64 {
+		t.Helper()
+		c, err := s.AddComment(ctx, noteID, parentID, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.ID
+	}
+
+	root := add(first, nil, "вопрос")
+	reply := add(first, &root, "ответ")
+	other := add(first, nil, "еще")
+	add(second, nil, "к второй")
+	check("добавление", map[int64]int{first: 3, second: 1})
+
+	if _, err := s.EditComment(ctx, first, other, "исправлено"); err != nil {
+		t.Fatal(err)
+	}
+	check("изменение", map[int64]int{first: 3, second: 1})
+
+	// Комментарий с ответом остается в ветке, но не считается
+	if err := s.DeleteComment(ctx, first, root); err != nil {
+		t.Fatal(err)
+	}
+	check("удаление с ответами", map[int64]int{first: 2, second: 1})
+
+	// Вместе с последним ответом удаляется и удаленный родитель
+	if err := s.DeleteComment(ctx, first, reply); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.GetByID(ctx, root); err == nil {
+		t.Error("удаленный родитель без ответов остался")
+	}
+	check("удаление ответа", map[int64]int{first: 1, second: 1})
+
+	parentID := int64(10)
+	if _, err := comments.Import(ctx, []core.Comment{
+		{ID: 10, NoteID: second, Deleted: true},
+		{ID: 11, NoteID: second, ParentID: &parentID, Body: "ответ из копии"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	check("импорт", map[int64]int{first: 1, second: 2})
+
+	if err := noteService.DeleteNote(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	check("удаление заметки", map[int64]int{first: 0, second: 2})
+}
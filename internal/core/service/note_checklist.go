@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+// MaxChecklistItems ограничивает количество пунктов в списке
+const MaxChecklistItems = 200
+
+func (s *noteServiceImpl) AddChecklistItem(ctx context.Context, noteID int64, text string) (*core.Note, error) {
+	text = strings.TrimSpace(text)
+	if err := s.validator.Check().Field(validation.FieldChecklistItem, text).Err(); err != nil {
+		return nil, err
+	}
+
+	return s.modifyChecklist(ctx, noteID, func(note *core.Note) error {
+		if len(note.Items) >= MaxChecklistItems {
+			return errors.New("превышено количество пунктов списка")
+		}
+
+		var nextID int64 = 1
+		for _, item := range note.Items {
+			if item.ID >= nextID {
+				nextID = item.ID + 1
+			}
+		}
+		note.Items = append(note.Items, core.ChecklistItem{
+			ID:    nextID,
+			Text:  text,
+			Order: len(note.Items) + 1,
+		})
+		return nil
+	})
+}
+
+func (s *noteServiceImpl) ToggleChecklistItem(ctx context.Context, noteID, itemID int64) (*core.Note, error) {
+	return s.modifyChecklist(ctx, noteID, func(note *core.Note) error {
+		i := findItem(note.Items, itemID)
+		if i < 0 {
+			return errors.New("пункт списка не найден")
+		}
+		note.Items[i].Checked = !note.Items[i].Checked
+		return nil
+	})
+}
+
+func (s *noteServiceImpl) ReorderChecklistItems(ctx context.Context, noteID int64, itemIDs []int64) (*core.Note, error) {
+	return s.modifyChecklist(ctx, noteID, func(note *core.Note) error {
+		if len(itemIDs) != len(note.Items) {
+			return errors.New("порядок должен включать все пункты списка")
+		}
+
+		position := make(map[int64]int, len(itemIDs))
+		for i, id := range itemIDs {
+			if _, dup := position[id]; dup {
+				return errors.New("порядок должен включать все пункты списка")
+			}
+			position[id] = i + 1
+		}
+		for i := range note.Items {
+			order, ok := position[note.Items[i].ID]
+			if !ok {
+				return errors.New("порядок должен включать все пункты списка")
+			}
+			note.Items[i].Order = order
+		}
+
+		sortItems(note.Items)
+		return nil
+	})
+}
+
+func (s *noteServiceImpl) RemoveChecklistItem(ctx context.Context, noteID, itemID int64) (*core.Note, error) {
+	return s.modifyChecklist(ctx, noteID, func(note *core.Note) error {
+		i := findItem(note.Items, itemID)
+		if i < 0 {
+			return errors.New("пункт списка не найден")
+		}
+		note.Items = append(note.Items[:i], note.Items[i+1:]...)
+
+		// Сохранить непрерывную нумерацию порядка
+		for j := range note.Items {
+			note.Items[j].Order = j + 1
+		}
+		return nil
+	})
+}
+
+// modifyChecklist атомарно изменяет пункты заметки-списка
+func (s *noteServiceImpl) modifyChecklist(ctx context.Context, noteID int64, fn func(note *core.Note) error) (*core.Note, error) {
+	if noteID <= 0 {
+		return nil, errors.New("неверный ID")
+	}
+
+	note, err := s.repo.Modify(ctx, noteID, func(note *core.Note) error {
+		if !note.IsChecklist() {
+			return errors.New("заметка не является списком")
+		}
+		return fn(note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	withProgress(note)
+	return note, nil
+}
+
+// prepareChecklist проверяет тип заметки и нормализует начальные пункты списка.
+// Текст пунктов проверяется валидатором до вызова.
+func prepareChecklist(noteType core.NoteType, items []core.ChecklistItem) ([]core.ChecklistItem, error) {
+	switch noteType {
+	case core.NoteTypeText:
+		if len(items) > 0 {
+			return nil, errors.New("пункты допустимы только для заметки-списка")
+		}
+		return nil, nil
+	case core.NoteTypeChecklist:
+	default:
+		return nil, errors.New("неизвестный тип заметки")
+	}
+
+	if len(items) > MaxChecklistItems {
+		return nil, errors.New("превышено количество пунктов списка")
+	}
+
+	// Переданные ID сохраняются (например, при импорте), если они корректны и уникальны
+	keepIDs := true
+	seen := make(map[int64]bool, len(items))
+	for _, item := range items {
+		if item.ID <= 0 || seen[item.ID] {
+			keepIDs = false
+			break
+		}
+		seen[item.ID] = true
+	}
+
+	prepared := make([]core.ChecklistItem, len(items))
+	for i, item := range items {
+		id := int64(i + 1)
+		if keepIDs {
+			id = item.ID
+		}
+		prepared[i] = core.ChecklistItem{
+			ID:      id,
+			Text:    strings.TrimSpace(item.Text),
+			Checked: item.Checked,
+			Order:   i + 1,
+		}
+	}
+
+	return prepared, nil
+}
+
+// withProgress заполняет прогресс выполнения для заметки-списка
+func withProgress(note *core.Note) {
+	if !note.IsChecklist() {
+		return
+	}
+
+	progress := core.ChecklistProgress{Total: len(note.Items)}
+	for _, item := range note.Items {
+		if item.Checked {
+			progress.Done++
+		}
+	}
+	note.Progress = &progress
+}
+
+func findItem(items []core.ChecklistItem, id int64) int {
+	for i, item := range items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortItems(items []core.ChecklistItem) {
+	sort.Slice(items, func(i, j int) bool { return items[i].Order < items[j].Order })
+}
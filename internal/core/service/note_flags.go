@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+func (s *noteServiceImpl) SetNoteFlag(ctx context.Context, id int64, flag core.NoteFlag, value bool) (*core.Note, error) {
+	if id <= 0 {
+		return nil, errors.New("неверный ID")
+	}
+
+	note, err := s.repo.Modify(ctx, id, func(note *core.Note) error {
+		switch flag {
+		case core.FlagPinned:
+			note.Pinned = value
+			// Закрепление возвращает заметку из архива
+			if value {
+				note.Archived = false
+			}
+		case core.FlagArchived:
+			note.Archived = value
+			// Архивная заметка не может оставаться закрепленной
+			if value {
+				note.Pinned = false
+			}
+		case core.FlagFavorite:
+			note.Favorite = value
+		default:
+			return errors.New("неизвестное состояние заметки")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.notifySave(ctx, *note); err != nil {
+		return nil, err
+	}
+
+	withProgress(note)
+	return note, nil
+}
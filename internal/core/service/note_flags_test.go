@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+func TestSetNoteFlag(t *testing.T) {
+	type flags struct{ pinned, archived, favorite bool }
+	type step struct {
+		flag  core.NoteFlag
+		value bool
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		want  flags
+	}{
+		{name: "закрепление", steps: []step{{core.FlagPinned, true}}, want: flags{pinned: true}},
+		{name: "открепление", steps: []step{{core.FlagPinned, true}, {core.FlagPinned, false}}, want: flags{}},
+		{name: "архивирование снимает закрепление", steps: []step{{core.FlagPinned, true}, {core.FlagArchived, true}}, want: flags{archived: true}},
+		{name: "закрепление возвращает из архива", steps: []step{{core.FlagArchived, true}, {core.FlagPinned, true}}, want: flags{pinned: true}},
+		{name: "избранное не зависит от архива", steps: []step{{core.FlagFavorite, true}, {core.FlagArchived, true}}, want: flags{archived: true, favorite: true}},
+		{name: "повторная установка", steps: []step{{core.FlagFavorite, true}, {core.FlagFavorite, true}}, want: flags{favorite: true}},
+		{name: "снятие из архива не закрепляет", steps: []step{{core.FlagPinned, true}, {core.FlagArchived, true}, {core.FlagArchived, false}}, want: flags{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, notes := newTestNoteService(t)
+			ctx := context.Background()
+			id := createNote(t, s, "Заметка", "")
+
+			var note *core.Note
+			for _, st := range tt.steps {
+				var err error
+				if note, err = s.SetNoteFlag(ctx, id, st.flag, st.value); err != nil {
+					t.Fatalf("SetNoteFlag(%s, %v) error = %v", st.flag, st.value, err)
+				}
+			}
+			stored, _ := notes.GetByID(ctx, id)
+			for _, n := range []*core.Note{note, stored} {
+				if got := (flags{n.Pinned, n.Archived, n.Favorite}); got != tt.want {
+					t.Errorf("флаги = %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSetNoteFlagErrors(t *testing.T) {
+	s, _ := newTestNoteService(t)
+	ctx := context.Background()
+	id := createNote(t, s, "Заметка", "")
+
+	tests := []struct {
+		name    string
+		id      int64
+		flag    core.NoteFlag
+		wantErr string
+	}{
+		{name: "неверный ID", id: 0, flag: core.FlagPinned, wantErr: "неверный ID"},
+		{name: "нет заметки", id: 999, flag: core.FlagPinned, wantErr: "не найдена"},
+		{name: "неизвестное состояние", id: id, flag: "hidden", wantErr: "неизвестное состояние"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.SetNoteFlag(ctx, tt.id, tt.flag, true)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SetNoteFlag() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListNotesByFlags(t *testing.T) {
+	s, _ := newTestNoteService(t)
+	ctx := context.Background()
+	plain := createNote(t, s, "Обычная", "")
+	pinned := createNote(t, s, "Закрепленная", "")
+	archived := createNote(t, s, "Архивная", "")
+	favorite := createNote(t, s, "Избранная", "")
+	for _, f := range []struct {
+		id   int64
+		flag core.NoteFlag
+	}{{pinned, core.FlagPinned}, {archived, core.FlagArchived}, {favorite, core.FlagFavorite}} {
+		if _, err := s.SetNoteFlag(ctx, f.id, f.flag, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	yes, no := true, false
+
+	tests := []struct {
+		name   string
+		filter core.NoteFilter
+		want   []int64
+	}{
+		{name: "все, закрепленные первыми", want: []int64{pinned, plain, archived, favorite}},
+		{name: "без архива", filter: core.NoteFilter{Archived: &no}, want: []int64{pinned, plain, favorite}},
+		{name: "только архив", filter: core.NoteFilter{Archived: &yes}, want: []int64{archived}},
+		{name: "избранные", filter: core.NoteFilter{Favorite: &yes}, want: []int64{favorite}},
+		{name: "незакрепленные", filter: core.NoteFilter{Pinned: &no}, want: []int64{plain, archived, favorite}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := s.ListNotes(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, n := range notes {
+				got = append(got, n.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ListNotes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/links"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+)
+
+// ImportOptions — параметры импорта заметок
+type ImportOptions struct {
+	// Replace заменяет импортируемыми заметками все существующие
+	Replace bool
+	// Atomic отменяет импорт целиком, если хотя бы одна заметка не прошла
+	// проверку; импорт с Replace всегда атомарный
+	Atomic bool
+}
+
+// ImportResult — итог импорта заметок
+type ImportResult struct {
+	// IDs — новые ID в порядке импортируемых заметок, 0 для непринятых
+	IDs []int64
+	// Errors — ошибки проверки в порядке импортируемых заметок
+	Errors []error
+	// Deleted — число заметок, удаленных при замене
+	Deleted int
+}
+
+// Failed сообщает, не прошла ли проверку хотя бы одна заметка
+func (r *ImportResult) Failed() bool {
+	for _, err := range r.Errors {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *noteServiceImpl) ImportNotes(ctx context.Context, notes []core.Note, opts ImportOptions) (*ImportResult, error) {
+	result := &ImportResult{
+		IDs:    make([]int64, len(notes)),
+		Errors: make([]error, len(notes)),
+	}
+
+	// Проверить все заметки до изменения хранилища
+	valid := make([]core.Note, 0, len(notes))
+	index := make([]int, 0, len(notes))
+	for i, note := range notes {
+		if err := s.prepareNote(&note); err != nil {
+			result.Errors[i] = err
+			continue
+		}
+		valid = append(valid, note)
+		index = append(index, i)
+	}
+	if (result.Failed() && (opts.Atomic || opts.Replace)) || (len(valid) == 0 && !opts.Replace) {
+		return result, nil
+	}
+
+	// Заметки заменяются одним вызовом хранилища, поэтому ошибка
+	// не оставляет его пустым или частично восстановленным
+	created, deleted, err := s.repo.Import(ctx, valid, opts.Replace, func(note *core.Note, ids map[int64]int64) {
+		note.Content = links.RewriteIDs(note.Content, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for j, id := range created {
+		result.IDs[index[j]] = id
+	}
+	result.Deleted = len(deleted)
+
+	// Заметки уже сохранены, поэтому ошибки обработчиков журналируются
+	// и не отменяют импорт: повтор создал бы заметки второй раз
+	logger := logging.FromContext(ctx)
+	for _, id := range deleted {
+		s.renderer.Invalidate(id)
+		if err := s.afterDelete(ctx, id); err != nil {
+			logger.ErrorContext(ctx, "ошибка обработки удаленной заметки", slog.Int64("note_id", id), slog.Any("error", err))
+		}
+	}
+	if err := s.RebuildLinks(ctx); err != nil {
+		logger.ErrorContext(ctx, "ошибка построения графа ссылок", slog.Any("error", err))
+	}
+	for _, id := range created {
+		note, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			continue
+		}
+		if err := s.notifySave(ctx, *note); err != nil {
+			logger.ErrorContext(ctx, "ошибка обработки импортированной заметки", slog.Int64("note_id", id), slog.Any("error", err))
+		}
+	}
+
+	return result, nil
+}
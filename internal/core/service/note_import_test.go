@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/markdown"
+)
+
+func TestImportNotesRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src, _ := newTestNoteService(t)
+	code := createNote(t, src, "Код", "x")
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)
+	updated := created.Add(time.Hour)
+	due := created.Add(48 * time.Hour)
+	remind := created.Add(24 * time.Hour)
+
+	// В содержимом {code} заменяется на ID заметки «Код»: исходный при
+	// экспорте и новый в ожидаемом результате
+	tests := []struct {
+		name string
+		note core.Note
+	}{
+		{
+			name: "отступ и ссылка по ID",
+			// Содержимое, которое CreateNote обрезал бы: ведущий отступ кода и пустые строки
+			note: core.Note{ID: 2, Title: "Заметка", Content: "    indented code\n\nсм. [[#{code}|код]]\n\n", Type: core.NoteTypeText, CreatedAt: created},
+		},
+		{
+			name: "пробелы в конце",
+			note: core.Note{ID: 3, Title: "Пробелы", Content: "текст  \n", Type: core.NoteTypeText, CreatedAt: created},
+		},
+		{
+			name: "все поля",
+			note: core.Note{
+				ID:        4,
+				Title:     "Список",
+				Content:   "см. [[#{code}]]",
+				Tags:      []string{"работа", "идеи"},
+				CreatedAt: created,
+				UpdatedAt: &updated,
+				Type:      core.NoteTypeChecklist,
+				Items: []core.ChecklistItem{
+					{ID: 7, Text: "Первый", Checked: true, Order: 1},
+					{ID: 3, Text: "Второй", Order: 2},
+				},
+				DueAt:      &due,
+				RemindAt:   &remind,
+				Recurrence: "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=5",
+				Pinned:     true,
+				Archived:   true,
+				Favorite:   true,
+			},
+		},
+	}
+
+	dst, notes := newTestNoteService(t)
+	createNote(t, dst, "Существующая 1", "")
+	createNote(t, dst, "Существующая 2", "")
+
+	withCode := func(note core.Note, id int64) core.Note {
+		note.Content = strings.ReplaceAll(note.Content, "{code}", strconv.FormatInt(id, 10))
+		return note
+	}
+	imported := []core.Note{{ID: code, Title: "Код", Content: "x"}}
+	for _, tt := range tests {
+		data, err := markdown.Encode(withCode(tt.note, code))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := markdown.Decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imported = append(imported, decoded)
+	}
+
+	result, err := dst.ImportNotes(ctx, imported, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed() {
+		t.Fatalf("ошибки импорта: %v", result.Errors)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := notes.GetByID(ctx, result.IDs[i+1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := withCode(tt.note, result.IDs[0])
+			want.ID = result.IDs[i+1]
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("заметка после импорта:\n got %+v\nwant %+v", *got, want)
+			}
+		})
+	}
+
+	backlinks, err := dst.GetBacklinks(ctx, result.IDs[0])
+	if err != nil || len(backlinks) != 2 {
+		t.Errorf("обратные ссылки импортированной заметки: %+v, %v", backlinks, err)
+	}
+}
+
+func TestImportNotesValidation(t *testing.T) {
+	valid := core.Note{ID: 1, Title: "Заметка"}
+	invalid := core.Note{ID: 2, Title: ""}
+
+	tests := []struct {
+		name        string
+		opts        ImportOptions
+		wantCreated int
+		wantNotes   int
+	}{
+		{name: "по файлам", opts: ImportOptions{}, wantCreated: 1, wantNotes: 2},
+		{name: "атомарно", opts: ImportOptions{Atomic: true}, wantCreated: 0, wantNotes: 1},
+		{name: "замена всегда атомарна", opts: ImportOptions{Replace: true}, wantCreated: 0, wantNotes: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, notes := newTestNoteService(t)
+			createNote(t, s, "Существующая", "")
+
+			result, err := s.ImportNotes(context.Background(), []core.Note{valid, invalid}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Errors[1] == nil {
+				t.Error("ожидалась ошибка проверки второй заметки")
+			}
+			created := 0
+			for _, id := range result.IDs {
+				if id != 0 {
+					created++
+				}
+			}
+			if created != tt.wantCreated || notes.Len() != tt.wantNotes {
+				t.Errorf("создано %d, заметок %d; want %d, %d", created, notes.Len(), tt.wantCreated, tt.wantNotes)
+			}
+		})
+	}
+}
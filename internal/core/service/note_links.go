@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/links"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+)
+
+func (s *noteServiceImpl) GetOutlinks(ctx context.Context, id int64) ([]core.NoteLink, error) {
+	if _, err := s.GetNote(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.links.GetOutlinks(ctx, id)
+}
+
+func (s *noteServiceImpl) GetBacklinks(ctx context.Context, id int64) ([]core.NoteLink, error) {
+	if _, err := s.GetNote(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.links.GetBacklinks(ctx, id)
+}
+
+func (s *noteServiceImpl) GetBrokenLinks(ctx context.Context) ([]core.NoteLink, error) {
+	return s.links.GetBroken(ctx)
+}
+
+func (s *noteServiceImpl) RebuildLinks(ctx context.Context) error {
+	notes, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	resolver := &linkResolver{repo: s.repo, notes: notes, loaded: true}
+	for _, note := range notes {
+		if err := s.indexLinks(ctx, resolver, note); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// afterSave обновляет граф ссылок после создания или изменения заметки и
+// вызывает обработчики сохранения. Если заголовок изменился, ссылки на
+// старый заголовок в других заметках переписываются на новый.
+func (s *noteServiceImpl) afterSave(ctx context.Context, note core.Note, oldTitle string) error {
+	if err := s.indexLinks(ctx, &linkResolver{repo: s.repo}, note); err != nil {
+		return err
+	}
+
+	if oldTitle != "" && !links.SameTitle(oldTitle, note.Title) {
+		if err := s.rewriteBacklinks(ctx, note, oldTitle); err != nil {
+			return err
+		}
+	}
+
+	// Битые ссылки на новый заголовок или ID теперь могут разрешиться
+	sources, err := s.links.GetBrokenSources(ctx, links.Ref{ID: note.ID}.Key(), links.Ref{Title: note.Title}.Key())
+	if err != nil {
+		return err
+	}
+	if err := s.reindexSources(ctx, sources); err != nil {
+		return err
+	}
+
+	return s.notifySave(ctx, note)
+}
+
+// afterDelete удаляет исходящие ссылки и комментарии заметки, пересчитывает
+// ссылки на неё и вызывает зарегистрированные обработчики удаления
+func (s *noteServiceImpl) afterDelete(ctx context.Context, id int64) error {
+	backlinks, err := s.links.GetBacklinks(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.links.DeleteSource(ctx, id); err != nil {
+		return err
+	}
+	if err := s.comments.DeleteByNote(ctx, id); err != nil {
+		return err
+	}
+
+	sources := make([]int64, 0, len(backlinks))
+	for _, link := range backlinks {
+		if link.SourceID != id {
+			sources = append(sources, link.SourceID)
+		}
+	}
+	if err := s.reindexSources(ctx, sources); err != nil {
+		return err
+	}
+
+	for _, hook := range s.onDelete {
+		if err := hook(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// errNotModified прерывает Modify, если заметку менять не нужно
+var errNotModified = errors.New("заметка не изменена")
+
+// rewriteBacklinks заменяет в заметках-источниках ссылки на старый заголовок.
+// Источники изменяются через Modify, чтобы не потерять их параллельные правки.
+func (s *noteServiceImpl) rewriteBacklinks(ctx context.Context, target core.Note, oldTitle string) error {
+	backlinks, err := s.links.GetBacklinks(ctx, target.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range backlinks {
+		if !links.SameTitle(link.Ref, oldTitle) {
+			continue
+		}
+
+		source, err := s.repo.Modify(ctx, link.SourceID, func(source *core.Note) error {
+			content := links.RewriteTitle(source.Content, oldTitle, target.Title, target.ID)
+			if content == source.Content {
+				return errNotModified
+			}
+			source.Content = content
+			return nil
+		})
+		if errors.Is(err, errNotModified) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.renderer.Invalidate(source.ID)
+
+		if err := s.indexLinks(ctx, &linkResolver{repo: s.repo}, *source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *noteServiceImpl) reindexSources(ctx context.Context, sources []int64) error {
+	resolver := &linkResolver{repo: s.repo}
+	for _, id := range sources {
+		source, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			if strings.Contains(err.Error(), "не найдена") {
+				continue
+			}
+			return err
+		}
+		if err := s.indexLinks(ctx, resolver, *source); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexLinks разбирает ссылки в содержимом заметки и сохраняет их в графе
+func (s *noteServiceImpl) indexLinks(ctx context.Context, resolver *linkResolver, note core.Note) error {
+	refs := links.Parse(note.Content)
+	out := make([]core.NoteLink, 0, len(refs))
+	for _, ref := range refs {
+		targetID, err := resolver.resolve(ctx, ref)
+		if err != nil {
+			return err
+		}
+		out = append(out, core.NoteLink{
+			SourceID: note.ID,
+			TargetID: targetID,
+			Ref:      ref.Target,
+			Broken:   targetID == 0,
+		})
+	}
+
+	return s.links.ReplaceOutlinks(ctx, note.ID, out)
+}
+
+// linkResolver находит целевые заметки ссылок, загружая список заметок
+// только при первой ссылке по заголовку
+type linkResolver struct {
+	repo   repo.NoteRepository
+	notes  []core.Note
+	loaded bool
+}
+
+// resolve возвращает ID целевой заметки или 0, если ссылка битая
+func (r *linkResolver) resolve(ctx context.Context, ref links.Ref) (int64, error) {
+	if ref.IsID() {
+		if _, err := r.repo.GetByID(ctx, ref.ID); err != nil {
+			if strings.Contains(err.Error(), "не найдена") {
+				return 0, nil
+			}
+			return 0, err
+		}
+		return ref.ID, nil
+	}
+
+	if !r.loaded {
+		notes, err := r.repo.GetAll(ctx)
+		if err != nil {
+			return 0, err
+		}
+		r.notes = notes
+		r.loaded = true
+	}
+
+	// При совпадающих заголовках выбирается заметка с меньшим ID
+	var found int64
+	for _, note := range r.notes {
+		if links.SameTitle(note.Title, ref.Title) && (found == 0 || note.ID < found) {
+			found = note.ID
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+func newTestNoteService(t *testing.T) (NoteService, *repo.NoteRepoMem) {
+	t.Helper()
+	notes := repo.NewNoteRepoMem()
+	return NewNoteService(notes, repo.NewLinkRepoMem(), repo.NewCommentRepoMem(), validation.New(validation.DefaultRules())), notes
+}
+
+func createNote(t *testing.T, s NoteService, title, content string) int64 {
+	t.Helper()
+	id, err := s.CreateNote(context.Background(), core.Note{Title: title, Content: content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRenameRewritesBacklinks(t *testing.T) {
+	tests := []struct {
+		name, newTitle, want string
+	}{
+		{name: "обычный заголовок", newTitle: "Планы", want: "см. [[Планы|план]] и [[Планы]]"},
+		{name: "заголовок со скобками", newTitle: "[WIP] Планы", want: "см. [[#1|план]] и [[#1|План]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, notes := newTestNoteService(t)
+			ctx := context.Background()
+			target := createNote(t, s, "План", "")
+			source := createNote(t, s, "Источник", "см. [[План|план]] и [[План]]")
+
+			if err := s.UpdateNote(ctx, target, UpdateNoteRequest{Title: &tt.newTitle}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, _ := notes.GetByID(ctx, source)
+			if got.Content != tt.want {
+				t.Errorf("Content = %q, want %q", got.Content, tt.want)
+			}
+			backlinks, err := s.GetBacklinks(ctx, target)
+			if err != nil || len(backlinks) == 0 {
+				t.Errorf("после переименования нет обратных ссылок: %v, %v", backlinks, err)
+			}
+			if broken, _ := s.GetBrokenLinks(ctx); len(broken) != 0 {
+				t.Errorf("битые ссылки: %v", broken)
+			}
+		})
+	}
+}
+
+func TestBrokenLinksResolveOnSave(t *testing.T) {
+	s, _ := newTestNoteService(t)
+	ctx := context.Background()
+	source := createNote(t, s, "Источник", "[[Будущая]] и [[#3]] и [[Никогда]]")
+
+	if broken, _ := s.GetBrokenLinks(ctx); len(broken) != 3 {
+		t.Fatalf("битых ссылок %d, want 3", len(broken))
+	}
+
+	// Заметка с ID 2 разрешает ссылку по заголовку, с ID 3 — по ID
+	createNote(t, s, "будущая", "")
+	createNote(t, s, "Другая", "")
+
+	broken, err := s.GetBrokenLinks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(broken) != 1 || broken[0].Ref != "Никогда" {
+		t.Errorf("битые ссылки: %+v", broken)
+	}
+	out, _ := s.GetOutlinks(ctx, source)
+	if len(out) != 3 {
+		t.Errorf("исходящих ссылок %d, want 3", len(out))
+	}
+
+	// После удаления цель снова битая
+	if err := s.DeleteNote(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if broken, _ := s.GetBrokenLinks(ctx); len(broken) != 2 {
+		t.Errorf("после удаления битых ссылок %d, want 2", len(broken))
+	}
+}
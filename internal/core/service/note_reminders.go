@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/reminder"
+)
+
+// errReminderChanged означает, что напоминание перенесли или сняли
+// после того, как оно было запланировано
+var errReminderChanged = errors.New("напоминание изменилось")
+
+func (s *noteServiceImpl) CompleteReminder(ctx context.Context, id int64, at time.Time) error {
+	updated, err := s.repo.Modify(ctx, id, func(note *core.Note) error {
+		if note.RemindAt == nil || !note.RemindAt.Equal(at) {
+			return errReminderChanged
+		}
+		if note.Recurrence == "" {
+			note.RemindAt = nil
+			return nil
+		}
+
+		rule, err := reminder.ParseRule(note.Recurrence)
+		if err != nil {
+			return err
+		}
+		next, rest, ok := rule.Next(at, time.Now())
+		if !ok {
+			note.RemindAt = nil
+			note.Recurrence = ""
+			return nil
+		}
+
+		// Срок повторяющейся заметки сдвигается вместе с напоминанием
+		if note.DueAt != nil {
+			due := note.DueAt.Add(next.Sub(at))
+			note.DueAt = &due
+		}
+		note.RemindAt = &next
+		note.Recurrence = rest.String()
+		return nil
+	})
+	if errors.Is(err, errReminderChanged) || (err != nil && strings.Contains(err.Error(), "не найдена")) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.notifySave(ctx, *updated)
+}
+
+func (s *noteServiceImpl) OnSave(hook NoteSaveHook) {
+	s.onSave = append(s.onSave, hook)
+}
+
+// notifySave вызывает обработчики сохранения заметки
+func (s *noteServiceImpl) notifySave(ctx context.Context, note core.Note) error {
+	for _, hook := range s.onSave {
+		if err := hook(ctx, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepareSchedule проверяет срок, напоминание и правило повторения заметки
+// и приводит правило к каноническому виду
+func prepareSchedule(note *core.Note) error {
+	note.Recurrence = strings.TrimSpace(note.Recurrence)
+	if note.Recurrence == "" {
+		return nil
+	}
+	if note.RemindAt == nil {
+		return errors.New("правило повторения требует времени напоминания")
+	}
+
+	rule, err := reminder.ParseRule(note.Recurrence)
+	if err != nil {
+		return errors.New("неверное правило повторения: " + err.Error())
+	}
+	note.Recurrence = rule.String()
+	return nil
+}
+
+// parseScheduleTime разбирает время из запроса на изменение; пустая строка
+// означает, что значение нужно снять
+func parseScheduleTime(field, value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("неверный формат даты " + field + ", ожидается RFC 3339")
+	}
+	return &t, nil
+}
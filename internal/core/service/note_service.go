@@ -3,10 +3,17 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+	"github.com/ybotet/pz12-notes-api/internal/markdown"
 	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
 )
 
 // NoteService определяет интерфейс для бизнес-логики заметок
@@ -14,42 +21,152 @@ type NoteService interface {
 	CreateNote(ctx context.Context, note core.Note) (int64, error)
 	GetNote(ctx context.Context, id int64) (*core.Note, error)
 	GetAllNotes(ctx context.Context) ([]core.Note, error)
+	ListNotes(ctx context.Context, filter core.NoteFilter) ([]core.Note, error)
 	UpdateNote(ctx context.Context, id int64, updates UpdateNoteRequest) error
 	DeleteNote(ctx context.Context, id int64) error
+	ApplyBatch(ctx context.Context, ops []core.BatchOp, atomic bool) ([]core.BatchResult, error)
+	RenderNoteHTML(ctx context.Context, id int64) (string, error)
+	// SetNoteFlag устанавливает или снимает состояние заметки
+	SetNoteFlag(ctx context.Context, id int64, flag core.NoteFlag, value bool) (*core.Note, error)
+	GetOutlinks(ctx context.Context, id int64) ([]core.NoteLink, error)
+	GetBacklinks(ctx context.Context, id int64) ([]core.NoteLink, error)
+	GetBrokenLinks(ctx context.Context) ([]core.NoteLink, error)
+	// RebuildLinks заново строит граф ссылок по всем заметкам
+	RebuildLinks(ctx context.Context) error
+	// ImportNotes создает заметки из резервной копии или Markdown-файлов.
+	// Все заметки проверяются до изменения хранилища; содержимое
+	// сохраняется как есть, а ссылки [[#id]] между импортируемыми
+	// заметками переписываются на новые ID.
+	ImportNotes(ctx context.Context, notes []core.Note, opts ImportOptions) (*ImportResult, error)
+	AddChecklistItem(ctx context.Context, noteID int64, text string) (*core.Note, error)
+	ToggleChecklistItem(ctx context.Context, noteID, itemID int64) (*core.Note, error)
+	// ReorderChecklistItems задает новый порядок пунктов; itemIDs должен
+	// содержать каждый пункт списка ровно один раз
+	ReorderChecklistItems(ctx context.Context, noteID int64, itemIDs []int64) (*core.Note, error)
+	RemoveChecklistItem(ctx context.Context, noteID, itemID int64) (*core.Note, error)
+	// CompleteReminder снимает сработавшее в момент at напоминание или
+	// переносит его на следующее повторение
+	CompleteReminder(ctx context.Context, id int64, at time.Time) error
+	// OnSave регистрирует обработчик сохранения заметки; вызывается при запуске
+	OnSave(hook NoteSaveHook)
+	// OnDelete регистрирует обработчик удаления заметки; вызывается при запуске
+	OnDelete(hook NoteDeleteHook)
 }
 
+// NoteSaveHook вызывается после создания или изменения заметки
+type NoteSaveHook func(ctx context.Context, note core.Note) error
+
+// NoteDeleteHook вызывается после удаления заметки
+type NoteDeleteHook func(ctx context.Context, id int64) error
+
+// MaxBatchSize ограничивает количество операций в одном пакете
+const MaxBatchSize = 1000
+
 // UpdateNoteRequest представляет запрос на частичное обновление
 type UpdateNoteRequest struct {
-	Title   *string `json:"title,omitempty"`
-	Content *string `json:"content,omitempty"`
+	Title   *string        `json:"title,omitempty"`
+	Content *string        `json:"content,omitempty"`
+	Tags    *[]string      `json:"tags,omitempty"`
+	Type    *core.NoteType `json:"type,omitempty"`
+	// DueAt и RemindAt в формате RFC 3339; пустая строка снимает значение
+	DueAt      *string `json:"due_at,omitempty"`
+	RemindAt   *string `json:"remind_at,omitempty"`
+	Recurrence *string `json:"recurrence,omitempty"`
 }
 
 // noteServiceImpl реализует NoteService
 type noteServiceImpl struct {
-	repo repo.NoteRepository
+	repo      repo.NoteRepository
+	links     repo.LinkRepository
+	comments  repo.CommentRepository
+	validator *validation.Validator
+	renderer  *markdown.Renderer
+	onSave    []NoteSaveHook
+	onDelete  []NoteDeleteHook
 }
 
 // NewNoteService создает новый экземпляр сервиса
-func NewNoteService(repo repo.NoteRepository) NoteService {
-	return &noteServiceImpl{repo: repo}
+func NewNoteService(repo repo.NoteRepository, links repo.LinkRepository, comments repo.CommentRepository, validator *validation.Validator) NoteService {
+	return &noteServiceImpl{
+		repo:      repo,
+		links:     links,
+		comments:  comments,
+		validator: validator,
+		renderer:  markdown.NewRenderer(),
+	}
 }
 
 func (s *noteServiceImpl) CreateNote(ctx context.Context, note core.Note) (int64, error) {
-	// Валидации бизнес-правил
-	if strings.TrimSpace(note.Title) == "" {
-		return 0, errors.New("заголовок не может быть пустым")
+	if err := s.prepareNote(&note); err != nil {
+		return 0, err
 	}
+	note.Content = strings.TrimSpace(note.Content)
 
-	if len(note.Content) > 1000 {
-		return 0, errors.New("содержание не может превышать 1000 символов")
+	// Создать заметку
+	id, err := s.repo.Create(ctx, note)
+	if err != nil {
+		return 0, err
+	}
+
+	note.ID = id
+	if err := s.afterSave(ctx, note, ""); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// prepareNote проверяет бизнес-правила новой заметки и приводит ее
+// к виду для сохранения; содержимое не изменяется
+func (s *noteServiceImpl) prepareNote(note *core.Note) error {
+	check := s.validator.Check().
+		Field(validation.FieldTitle, note.Title).
+		Field(validation.FieldContent, note.Content)
+	for i, item := range note.Items {
+		check.Item(validation.FieldChecklistItem, i, item.Text)
+	}
+	note.Tags = checkTags(check, note.Tags)
+	if err := check.Err(); err != nil {
+		return err
+	}
+
+	if note.Type == "" {
+		note.Type = core.NoteTypeText
+	}
+	items, err := prepareChecklist(note.Type, note.Items)
+	if err != nil {
+		return err
+	}
+	if err := prepareSchedule(note); err != nil {
+		return err
 	}
 
-	// Обработка содержимого
 	note.Title = strings.TrimSpace(note.Title)
-	note.Content = strings.TrimSpace(note.Content)
+	note.Items = items
+	return nil
+}
 
-	// Создать заметку
-	return s.repo.Create(ctx, note)
+// MaxTags ограничивает число меток одной заметки
+const MaxTags = 20
+
+// checkTags проверяет метки и возвращает их без крайних пробелов и без
+// повторов без учета регистра; пустой список становится nil
+func checkTags(check *validation.Check, tags []string) []string {
+	if len(tags) > MaxTags {
+		check.Add("tags", validation.CodeMaxLength, fmt.Sprintf("не более %d меток", MaxTags))
+		return nil
+	}
+	var result []string
+	seen := make(map[string]bool, len(tags))
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		check.Item(validation.FieldTag, i, tag)
+		if key := strings.ToLower(tag); tag != "" && !seen[key] {
+			seen[key] = true
+			result = append(result, tag)
+		}
+	}
+	return result
 }
 
 func (s *noteServiceImpl) GetNote(ctx context.Context, id int64) (*core.Note, error) {
@@ -57,11 +174,64 @@ func (s *noteServiceImpl) GetNote(ctx context.Context, id int64) (*core.Note, er
 		return nil, errors.New("неверный ID")
 	}
 
-	return s.repo.GetByID(ctx, id)
+	note, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	withProgress(note)
+	if note.CommentCount, err = s.comments.CountForNote(ctx, id); err != nil {
+		return nil, err
+	}
+	return note, nil
 }
 
 func (s *noteServiceImpl) GetAllNotes(ctx context.Context) ([]core.Note, error) {
-	return s.repo.GetAll(ctx)
+	notes, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range notes {
+		withProgress(&notes[i])
+	}
+	return notes, nil
+}
+
+func (s *noteServiceImpl) ListNotes(ctx context.Context, filter core.NoteFilter) ([]core.Note, error) {
+	notes, err := s.GetAllNotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.comments.CountByNote(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := notes[:0]
+	for _, note := range notes {
+		if filter.Matches(note) {
+			note.CommentCount = counts[note.ID]
+			filtered = append(filtered, note)
+		}
+	}
+
+	// Закрепленные заметки идут первыми; при выборке по сроку — ближайшие
+	// сроки, иначе в порядке создания
+	byDue := filter.DueBefore != nil || filter.DueAfter != nil
+	sort.Slice(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if byDue && !a.DueAt.Equal(*b.DueAt) {
+			return a.DueAt.Before(*b.DueAt)
+		}
+		return a.ID < b.ID
+	})
+
+	return filtered, nil
 }
 
 func (s *noteServiceImpl) UpdateNote(ctx context.Context, id int64, updates UpdateNoteRequest) error {
@@ -69,31 +239,80 @@ func (s *noteServiceImpl) UpdateNote(ctx context.Context, id int64, updates Upda
 		return errors.New("неверный ID")
 	}
 
-	// Получить существующую заметку
-	existingNote, err := s.repo.GetByID(ctx, id)
-	if err != nil {
+	// Валидации частичных обновлений
+	check := s.validator.Check()
+	var title, content string
+	if updates.Title != nil {
+		title = strings.TrimSpace(*updates.Title)
+		check.Field(validation.FieldTitle, title)
+	}
+	if updates.Content != nil {
+		content = strings.TrimSpace(*updates.Content)
+		check.Field(validation.FieldContent, content)
+	}
+	var tags []string
+	if updates.Tags != nil {
+		tags = checkTags(check, *updates.Tags)
+	}
+	if err := check.Err(); err != nil {
 		return err
 	}
 
-	// Применить частичные обновления
-	if updates.Title != nil {
-		title := strings.TrimSpace(*updates.Title)
-		if title == "" {
-			return errors.New("заголовок не может быть пустым")
+	if updates.Type != nil {
+		if _, err := prepareChecklist(*updates.Type, nil); err != nil {
+			return err
 		}
-		existingNote.Title = title
 	}
 
-	if updates.Content != nil {
-		content := strings.TrimSpace(*updates.Content)
-		if len(content) > 1000 {
-			return errors.New("содержание не может превышать 1000 символов")
+	var dueAt, remindAt *time.Time
+	var err error
+	if updates.DueAt != nil {
+		if dueAt, err = parseScheduleTime("due_at", *updates.DueAt); err != nil {
+			return err
+		}
+	}
+	if updates.RemindAt != nil {
+		if remindAt, err = parseScheduleTime("remind_at", *updates.RemindAt); err != nil {
+			return err
+		}
+	}
+
+	// Применить частичные обновления атомарно
+	var oldTitle string
+	updated, err := s.repo.Modify(ctx, id, func(note *core.Note) error {
+		oldTitle = note.Title
+		if updates.Title != nil {
+			note.Title = title
 		}
-		existingNote.Content = content
+		if updates.Content != nil {
+			note.Content = content
+		}
+		if updates.Tags != nil {
+			note.Tags = tags
+		}
+		if updates.Type != nil {
+			note.Type = *updates.Type
+			if !note.IsChecklist() {
+				note.Items = nil
+			}
+		}
+		if updates.DueAt != nil {
+			note.DueAt = dueAt
+		}
+		if updates.RemindAt != nil {
+			note.RemindAt = remindAt
+		}
+		if updates.Recurrence != nil {
+			note.Recurrence = *updates.Recurrence
+		}
+		return prepareSchedule(note)
+	})
+	if err != nil {
+		return err
 	}
 
-	// Сохранить изменения
-	return s.repo.Update(ctx, id, *existingNote)
+	s.renderer.Invalidate(id)
+	return s.afterSave(ctx, *updated, oldTitle)
 }
 
 func (s *noteServiceImpl) DeleteNote(ctx context.Context, id int64) error {
@@ -101,5 +320,122 @@ func (s *noteServiceImpl) DeleteNote(ctx context.Context, id int64) error {
 		return errors.New("неверный ID")
 	}
 
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.renderer.Invalidate(id)
+	return s.afterDelete(ctx, id)
+}
+
+func (s *noteServiceImpl) ApplyBatch(ctx context.Context, ops []core.BatchOp, atomic bool) ([]core.BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("пакет не может быть пустым")
+	}
+	if len(ops) > MaxBatchSize {
+		return nil, errors.New("пакет превышает допустимое количество операций")
+	}
+
+	results := make([]core.BatchResult, len(ops))
+	valid := make([]core.BatchOp, 0, len(ops))
+	index := make([]int, 0, len(ops))
+	failed := false
+
+	// Валидации бизнес-правил для каждой операции
+	for i, op := range ops {
+		results[i] = core.BatchResult{Index: i, Type: op.Type, ID: op.ID}
+		prepared, err := s.prepareBatchOp(op)
+		if err != nil {
+			results[i].Err = err
+			failed = true
+			continue
+		}
+		valid = append(valid, prepared)
+		index = append(index, i)
+	}
+
+	// В атомарном режиме одна ошибка валидации отменяет весь пакет
+	if (atomic && failed) || len(valid) == 0 {
+		return results, nil
+	}
+
+	applied, err := s.repo.Batch(ctx, valid, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for j, res := range applied {
+		res.Index = index[j]
+		results[index[j]] = res
+	}
+
+	// Обновить кэш и граф ссылок для применённых операций. Пакет уже
+	// применен, поэтому ошибки обработчиков только записываются в журнал:
+	// клиент, получив ошибку, повторил бы уже выполненные операции.
+	logger := logging.FromContext(ctx)
+	for _, res := range applied {
+		if !res.Applied {
+			continue
+		}
+		s.renderer.Invalidate(res.ID)
+		err = nil
+		if res.Type == core.BatchDelete {
+			err = s.afterDelete(ctx, res.ID)
+		} else if note, getErr := s.repo.GetByID(ctx, res.ID); getErr == nil {
+			// Прежний заголовок репозиторий прочитал под блокировкой пакета
+			err = s.afterSave(ctx, *note, res.OldTitle)
+		}
+		if err != nil {
+			logger.ErrorContext(ctx, "ошибка обработки заметки из пакета", slog.Int64("note_id", res.ID), slog.Any("error", err))
+		}
+	}
+
+	return results, nil
+}
+
+// prepareBatchOp проверяет и нормализует одну операцию пакета
+func (s *noteServiceImpl) prepareBatchOp(op core.BatchOp) (core.BatchOp, error) {
+	switch op.Type {
+	case core.BatchCreate:
+		if op.Title == nil {
+			empty := ""
+			op.Title = &empty
+		}
+	case core.BatchUpdate, core.BatchDelete:
+		if op.ID <= 0 {
+			return op, errors.New("неверный ID")
+		}
+	default:
+		return op, errors.New("неизвестная операция")
+	}
+
+	if op.Type == core.BatchDelete {
+		return core.BatchOp{Type: op.Type, ID: op.ID}, nil
+	}
+
+	check := s.validator.Check()
+	if op.Title != nil {
+		title := strings.TrimSpace(*op.Title)
+		check.Field(validation.FieldTitle, title)
+		op.Title = &title
+	}
+	if op.Content != nil {
+		content := strings.TrimSpace(*op.Content)
+		check.Field(validation.FieldContent, content)
+		op.Content = &content
+	}
+
+	return op, check.Err()
+}
+
+func (s *noteServiceImpl) RenderNoteHTML(ctx context.Context, id int64) (string, error) {
+	note, err := s.GetNote(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	return s.renderer.Render(*note)
+}
+
+func (s *noteServiceImpl) OnDelete(hook NoteDeleteHook) {
+	s.onDelete = append(s.onDelete, hook)
 }
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+func TestNoteTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr string
+	}{
+		{name: "без меток", tags: nil, want: nil},
+		{name: "пробелы обрезаются", tags: []string{" работа ", "идеи"}, want: []string{"работа", "идеи"}},
+		{name: "повторы без учета регистра", tags: []string{"Работа", "работа", "идеи"}, want: []string{"Работа", "идеи"}},
+		{name: "пустая метка", tags: []string{"работа", " "}, wantErr: "tag[1]"},
+		{name: "запятая", tags: []string{"a,b"}, wantErr: "tag[0]"},
+		{name: "слишком много", tags: strings.Split(strings.Repeat("x,", MaxTags+1), ",")[:MaxTags+1], wantErr: "tags"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, notes := newTestNoteService(t)
+			ctx := context.Background()
+			id, err := s.CreateNote(ctx, core.Note{Title: "Заметка", Tags: tt.tags})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CreateNote() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := notes.GetByID(ctx, id)
+			if !reflect.DeepEqual(got.Tags, tt.want) {
+				t.Errorf("Tags = %q, want %q", got.Tags, tt.want)
+			}
+
+			// Обновление заменяет метки целиком; пустой список снимает их
+			if err := s.UpdateNote(ctx, id, UpdateNoteRequest{Tags: &tt.tags}); err != nil {
+				t.Fatal(err)
+			}
+			empty := []string{}
+			if err := s.UpdateNote(ctx, id, UpdateNoteRequest{Tags: &empty}); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := notes.GetByID(ctx, id); got.Tags != nil {
+				t.Errorf("после снятия меток Tags = %q", got.Tags)
+			}
+		})
+	}
+}
+
+// batchOps — пакет из всех видов операций; операции 3 и 4 ошибочны
+func batchOps(update, remove int64) []core.BatchOp {
+	str := func(s string) *string { return &s }
+	return []core.BatchOp{
+		{Type: core.BatchCreate, Title: str("Новая"), Content: str("про [[Планы]]")},
+		{Type: core.BatchUpdate, ID: update, Title: str("Планы")},
+		{Type: core.BatchDelete, ID: remove},
+		{Type: core.BatchUpdate, ID: 999, Content: str("нет такой")},
+		{Type: core.BatchCreate, Title: str("")},
+	}
+}
+
+func TestApplyBatchMixed(t *testing.T) {
+	s, notes := newTestNoteService(t)
+	ctx := context.Background()
+	plan := createNote(t, s, "План", "")
+	removed := createNote(t, s, "Удалить", "")
+	source := createNote(t, s, "Источник", "см. [[План]]")
+
+	results, err := s.ApplyBatch(ctx, batchOps(plan, removed), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantApplied := []bool{true, true, true, false, false}
+	for i, res := range results {
+		if res.Index != i || res.Applied != wantApplied[i] || (res.Err == nil) != wantApplied[i] {
+			t.Errorf("results[%d] = %+v, want Applied %v", i, res, wantApplied[i])
+		}
+	}
+	if results[3].Err != nil && !strings.Contains(results[3].Err.Error(), "не найдена") {
+		t.Errorf("results[3].Err = %v", results[3].Err)
+	}
+	if results[1].OldTitle != "План" {
+		t.Errorf("results[1].OldTitle = %q, want %q", results[1].OldTitle, "План")
+	}
+
+	if notes.Len() != 3 {
+		t.Errorf("заметок %d, want 3", notes.Len())
+	}
+	if _, err := s.GetNote(ctx, removed); err == nil {
+		t.Error("удаленная в пакете заметка осталась")
+	}
+	if got, _ := notes.GetByID(ctx, plan); got.Title != "Планы" {
+		t.Errorf("Title = %q, want %q", got.Title, "Планы")
+	}
+	// Переименование в пакете переписывает ссылки по прежнему заголовку,
+	// а созданная заметка ссылается на новый
+	if got, _ := notes.GetByID(ctx, source); got.Content != "см. [[Планы]]" {
+		t.Errorf("Content = %q, want %q", got.Content, "см. [[Планы]]")
+	}
+	backlinks, err := s.GetBacklinks(ctx, plan)
+	if err != nil || len(backlinks) != 2 {
+		t.Errorf("обратных ссылок %d, want 2: %v", len(backlinks), err)
+	}
+}
+
+func TestApplyBatchAtomicRollback(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     func(update, remove int64) []core.BatchOp
+		wantErr map[int]string
+	}{
+		{
+			name: "ошибка валидации",
+			ops: func(update, remove int64) []core.BatchOp {
+				ops := batchOps(update, remove)
+				return append(ops[:3], ops[4])
+			},
+			wantErr: map[int]string{3: "title"},
+		},
+		{
+			name:    "заметка не найдена",
+			ops:     func(update, remove int64) []core.BatchOp { return batchOps(update, remove)[:4] },
+			wantErr: map[int]string{3: "не найдена"},
+		},
+		{
+			name: "удаление и изменение одной заметки",
+			ops: func(update, remove int64) []core.BatchOp {
+				ops := batchOps(update, remove)[:3]
+				return append(ops, core.BatchOp{Type: core.BatchUpdate, ID: remove, Content: ops[0].Content})
+			},
+			wantErr: map[int]string{3: "не найдена"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, notes := newTestNoteService(t)
+			ctx := context.Background()
+			plan := createNote(t, s, "План", "")
+			removed := createNote(t, s, "Удалить", "")
+			source := createNote(t, s, "Источник", "см. [[План]]")
+
+			results, err := s.ApplyBatch(ctx, tt.ops(plan, removed), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, res := range results {
+				if res.Applied {
+					t.Errorf("results[%d] применена", i)
+				}
+				want, failed := tt.wantErr[i]
+				switch {
+				case failed && (res.Err == nil || !strings.Contains(res.Err.Error(), want)):
+					t.Errorf("results[%d].Err = %v, want %q", i, res.Err, want)
+				case !failed && res.Err != nil:
+					t.Errorf("results[%d].Err = %v, want nil", i, res.Err)
+				}
+			}
+
+			if notes.Len() != 3 {
+				t.Errorf("заметок %d, want 3", notes.Len())
+			}
+			if got, err := notes.GetByID(ctx, plan); err != nil || got.Title != "План" || got.UpdatedAt != nil {
+				t.Errorf("заметка изменена: %+v, %v", got, err)
+			}
+			if _, err := notes.GetByID(ctx, removed); err != nil {
+				t.Errorf("заметка удалена: %v", err)
+			}
+			if got, _ := notes.GetByID(ctx, source); got.Content != "см. [[План]]" {
+				t.Errorf("ссылки переписаны: %q", got.Content)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/templating"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+// TemplateService определяет интерфейс бизнес-логики шаблонов заметок
+type TemplateService interface {
+	CreateTemplate(ctx context.Context, t core.Template) (int64, error)
+	GetTemplate(ctx context.Context, id int64) (*core.Template, error)
+	GetAllTemplates(ctx context.Context) ([]core.Template, error)
+	UpdateTemplate(ctx context.Context, id int64, updates UpdateTemplateRequest) error
+	DeleteTemplate(ctx context.Context, id int64) error
+	// Instantiate подставляет переменные в шаблон и возвращает заметку,
+	// готовую к созданию. Если title пустой, заголовок берется из шаблона.
+	Instantiate(ctx context.Context, id int64, title string, vars map[string]string) (core.Note, error)
+}
+
+// UpdateTemplateRequest представляет запрос на частичное обновление шаблона
+type UpdateTemplateRequest struct {
+	Name    *string
+	Title   *string
+	Content *string
+}
+
+// templateServiceImpl реализует TemplateService
+type templateServiceImpl struct {
+	repo      repo.TemplateRepository
+	validator *validation.Validator
+}
+
+// NewTemplateService создает новый экземпляр сервиса шаблонов
+func NewTemplateService(repo repo.TemplateRepository, validator *validation.Validator) TemplateService {
+	return &templateServiceImpl{repo: repo, validator: validator}
+}
+
+func (s *templateServiceImpl) CreateTemplate(ctx context.Context, t core.Template) (int64, error) {
+	t.Name = strings.TrimSpace(t.Name)
+	if err := s.validateTemplate(t); err != nil {
+		return 0, err
+	}
+
+	return s.repo.Create(ctx, t)
+}
+
+func (s *templateServiceImpl) GetTemplate(ctx context.Context, id int64) (*core.Template, error) {
+	if id <= 0 {
+		return nil, errors.New("неверный ID")
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *templateServiceImpl) GetAllTemplates(ctx context.Context) ([]core.Template, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *templateServiceImpl) UpdateTemplate(ctx context.Context, id int64, updates UpdateTemplateRequest) error {
+	t, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if updates.Name != nil {
+		t.Name = strings.TrimSpace(*updates.Name)
+	}
+	if updates.Title != nil {
+		t.Title = *updates.Title
+	}
+	if updates.Content != nil {
+		t.Content = *updates.Content
+	}
+	if err := s.validateTemplate(*t); err != nil {
+		return err
+	}
+
+	return s.repo.Update(ctx, id, *t)
+}
+
+func (s *templateServiceImpl) DeleteTemplate(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return errors.New("неверный ID")
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *templateServiceImpl) Instantiate(ctx context.Context, id int64, title string, vars map[string]string) (core.Note, error) {
+	t, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return core.Note{}, err
+	}
+
+	c := templating.Context{
+		Now:   time.Now(),
+		User:  core.UserFromContext(ctx),
+		Title: strings.TrimSpace(title),
+		Vars:  vars,
+	}
+
+	// Без явного заголовка он строится по шаблону заголовка,
+	// а если его нет — берется название шаблона
+	if c.Title == "" {
+		c.Title = t.Name
+		if strings.TrimSpace(t.Title) != "" {
+			if c.Title, err = templating.Render(t.Title, c); err != nil {
+				return core.Note{}, err
+			}
+		}
+	}
+
+	content, err := templating.Render(t.Content, c)
+	if err != nil {
+		return core.Note{}, err
+	}
+
+	return core.Note{Title: c.Title, Content: content}, nil
+}
+
+// validateTemplate проверяет название и синтаксис шаблона
+func (s *templateServiceImpl) validateTemplate(t core.Template) error {
+	if err := s.validator.Check().Field(validation.FieldTemplateName, t.Name).Err(); err != nil {
+		return err
+	}
+	if err := templating.Validate(t.Title); err != nil {
+		return err
+	}
+	return templating.Validate(t.Content)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+func newTestTemplateService(t *testing.T) TemplateService {
+	t.Helper()
+	return NewTemplateService(repo.NewTemplateRepoMem(), validation.New(validation.DefaultRules()))
+}
+
+func TestCreateTemplateValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		template core.Template
+		wantErr  string
+	}{
+		{name: "верный шаблон", template: core.Template{Name: " Встреча ", Title: "Встреча {{date}}", Content: "{{.project}}"}},
+		{name: "без названия", template: core.Template{Name: "  ", Content: "x"}, wantErr: "template_name"},
+		{name: "перевод строки в названии", template: core.Template{Name: "a\nb"}, wantErr: "template_name"},
+		{name: "ошибка в заголовке", template: core.Template{Name: "a", Title: "{{if}}"}, wantErr: "ошибка шаблона"},
+		{name: "цикл в содержимом", template: core.Template{Name: "a", Content: "{{range .x}}{{end}}"}, wantErr: "циклы не поддерживаются"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestTemplateService(t)
+			id, err := s.CreateTemplate(context.Background(), tt.template)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("CreateTemplate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateTemplate() error = %v", err)
+			}
+			got, err := s.GetTemplate(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != strings.TrimSpace(tt.template.Name) {
+				t.Errorf("Name = %q, название не обрезано", got.Name)
+			}
+		})
+	}
+}
+
+func TestUpdateTemplate(t *testing.T) {
+	s := newTestTemplateService(t)
+	ctx := context.Background()
+	id, err := s.CreateTemplate(ctx, core.Template{Name: "Встреча", Title: "Встреча {{date}}", Content: "Участники:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "Участники: {{user}}"
+	if err := s.UpdateTemplate(ctx, id, UpdateTemplateRequest{Content: &content}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := s.GetTemplate(ctx, id)
+	if got.Name != "Встреча" || got.Title != "Встреча {{date}}" || got.Content != content || got.UpdatedAt == nil {
+		t.Errorf("после частичного обновления: %+v", got)
+	}
+
+	// Неверный шаблон не сохраняется
+	broken := "{{range .x}}{{end}}"
+	if err := s.UpdateTemplate(ctx, id, UpdateTemplateRequest{Title: &broken}); err == nil {
+		t.Error("UpdateTemplate() с циклом не вернул ошибку")
+	}
+	if got, _ := s.GetTemplate(ctx, id); got.Title != "Встреча {{date}}" {
+		t.Errorf("Title = %q, неверный шаблон сохранен", got.Title)
+	}
+
+	if err := s.UpdateTemplate(ctx, 999, UpdateTemplateRequest{Content: &content}); err == nil || !strings.Contains(err.Error(), "не найден") {
+		t.Errorf("UpdateTemplate() несуществующего = %v", err)
+	}
+}
+
+func TestInstantiate(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  core.Template
+		title     string
+		vars      map[string]string
+		wantTitle string
+		wantBody  string
+	}{
+		{
+			name:      "заголовок из запроса",
+			template:  core.Template{Name: "Встреча", Title: "Встреча {{.project}}", Content: "{{title}} / {{user}}"},
+			title:     "  Планерка ",
+			vars:      map[string]string{"project": "API"},
+			wantTitle: "Планерка",
+			wantBody:  "Планерка / alice",
+		},
+		{
+			name:      "заголовок по шаблону",
+			template:  core.Template{Name: "Встреча", Title: "Встреча {{.project}}", Content: "{{title}}"},
+			vars:      map[string]string{"project": "API"},
+			wantTitle: "Встреча API",
+			wantBody:  "Встреча API",
+		},
+		{
+			name:      "название шаблона",
+			template:  core.Template{Name: "Встреча", Content: "Проект: {{.project}}"},
+			wantTitle: "Встреча",
+			wantBody:  "Проект: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestTemplateService(t)
+			ctx := core.WithUser(context.Background(), "alice")
+			id, err := s.CreateTemplate(ctx, tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			note, err := s.Instantiate(ctx, id, tt.title, tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if note.Title != tt.wantTitle || note.Content != tt.wantBody {
+				t.Errorf("Instantiate() = %q / %q, want %q / %q", note.Title, note.Content, tt.wantTitle, tt.wantBody)
+			}
+		})
+	}
+
+	s := newTestTemplateService(t)
+	if _, err := s.Instantiate(context.Background(), 999, "", nil); err == nil || !strings.Contains(err.Error(), "не найден") {
+		t.Errorf("Instantiate() несуществующего = %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+package core
+
+import "time"
+
+// Template представляет шаблон для создания заметок
+// @Description Шаблон заметки; Title и Content поддерживают подстановки вида {{date}}, {{user}}, {{title}} и {{.имя}}
+type Template struct {
+	ID        int64
+	Name      string
+	Title     string
+	Content   string
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
@@ -0,0 +1,21 @@
+package core
+
+import "context"
+
+// AnonymousUser — пользователь запросов без идентификации
+const AnonymousUser = "anonymous"
+
+type userCtxKey struct{}
+
+// WithUser возвращает контекст с идентификатором пользователя
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userCtxKey{}, user)
+}
+
+// UserFromContext возвращает идентификатор пользователя из контекста
+func UserFromContext(ctx context.Context) string {
+	if user, ok := ctx.Value(userCtxKey{}).(string); ok && user != "" {
+		return user
+	}
+	return AnonymousUser
+}
@@ -0,0 +1,169 @@
+// Package health собирает проверки состояния зависимостей сервера
+// для маршрутов /livez и /readyz.
+//
+// Компоненты (хранилища, фоновые задачи) регистрируют в Registry свои
+// проверки. Проверки выполняются параллельно, каждая со своим таймаутом,
+// а результат кэшируется, чтобы частые опросы балансировщика не нагружали
+// зависимости.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Статусы проверок и отчета
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Checker проверяет состояние одной зависимости
+type Checker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// CheckerFunc позволяет использовать функцию как Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result — результат одной проверки
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report — сводный результат всех проверок
+type Report struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+// OK сообщает, что сервер готов принимать трафик
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type check struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+}
+
+// Registry хранит проверки и кэширует их результат
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	checks   []check
+	cached   *Report
+	stopping atomic.Bool
+}
+
+// NewRegistry создает реестр; timeout — таймаут проверки по умолчанию,
+// cacheTTL — время, в течение которого повторно отдается прошлый результат
+func NewRegistry(timeout, cacheTTL time.Duration) *Registry {
+	return &Registry{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// Register добавляет проверку с таймаутом по умолчанию
+func (r *Registry) Register(name string, c Checker) {
+	r.RegisterWithTimeout(name, c, r.timeout)
+}
+
+// RegisterWithTimeout добавляет проверку с собственным таймаутом
+func (r *Registry) RegisterWithTimeout(name string, c Checker, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, checker: c, timeout: timeout})
+	r.cached = nil
+}
+
+// Shutdown переводит готовность в отказ; вызывается в начале остановки,
+// чтобы балансировщик перестал направлять новые запросы
+func (r *Registry) Shutdown() {
+	r.stopping.Store(true)
+}
+
+// ShuttingDown сообщает, начата ли остановка
+func (r *Registry) ShuttingDown() bool {
+	return r.stopping.Load()
+}
+
+// Check выполняет проверки или возвращает кэшированный результат
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.cached == nil || now.Sub(r.cached.CheckedAt) >= r.cacheTTL {
+		// Результат разделяется между запросами, поэтому отмена
+		// запроса, запустившего проверки, не должна их прерывать
+		report := r.run(context.WithoutCancel(ctx), now)
+		r.cached = &report
+	}
+
+	report := *r.cached
+	if r.stopping.Load() {
+		report.Status = StatusShuttingDown
+	}
+	return report
+}
+
+// run выполняет все проверки параллельно
+func (r *Registry) run(ctx context.Context, now time.Time) Report {
+	report := Report{Status: StatusOK, CheckedAt: now, Checks: make([]Result, len(r.checks))}
+
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// runCheck выполняет проверку с таймаутом. Зависшая проверка не задерживает
+// ответ: по истечении таймаута она считается проваленной.
+func runCheck(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.checker.CheckHealth(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errors.New("превышено время проверки")
+	}
+
+	res := Result{Name: c.name, Status: StatusOK, Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
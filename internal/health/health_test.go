@@ -0,0 +1,160 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func TestRegistryCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     map[string]CheckerFunc
+		wantStatus string
+		wantErrors map[string]string
+	}{
+		{name: "без проверок", wantStatus: StatusOK},
+		{name: "все прошли", checks: map[string]CheckerFunc{"a": ok, "b": ok}, wantStatus: StatusOK},
+		{
+			name:       "одна провалилась",
+			checks:     map[string]CheckerFunc{"a": ok, "b": func(context.Context) error { return errors.New("нет диска") }},
+			wantStatus: StatusFail,
+			wantErrors: map[string]string{"b": "нет диска"},
+		},
+		{
+			name: "зависшая проверка",
+			checks: map[string]CheckerFunc{"a": ok, "slow": func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}},
+			wantStatus: StatusFail,
+			wantErrors: map[string]string{"slow": "превышено время проверки"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(50*time.Millisecond, time.Minute)
+			for name, c := range tt.checks {
+				r.Register(name, c)
+			}
+
+			start := time.Now()
+			report := r.Check(context.Background())
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Check() занял %v, таймаут не сработал", elapsed)
+			}
+			if report.Status != tt.wantStatus || report.OK() != (tt.wantStatus == StatusOK) {
+				t.Errorf("Status = %q, OK() = %v, want %q", report.Status, report.OK(), tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("проверок в отчете %d, want %d", len(report.Checks), len(tt.checks))
+			}
+			for _, res := range report.Checks {
+				want, failed := tt.wantErrors[res.Name]
+				if failed != (res.Status == StatusFail) || res.Error != want {
+					t.Errorf("%s: Status = %q, Error = %q, want ошибку %q", res.Name, res.Status, res.Error, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryRunsChecksInParallel(t *testing.T) {
+	r := NewRegistry(time.Second, 0)
+	for _, name := range []string{"a", "b", "c"} {
+		r.Register(name, CheckerFunc(func(context.Context) error {
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		}))
+	}
+
+	start := time.Now()
+	if report := r.Check(context.Background()); !report.OK() {
+		t.Fatalf("Check() = %+v", report)
+	}
+	if elapsed := time.Since(start); elapsed >= 250*time.Millisecond {
+		t.Errorf("Check() занял %v, проверки выполнялись последовательно", elapsed)
+	}
+}
+
+func TestRegistryOwnTimeout(t *testing.T) {
+	r := NewRegistry(10*time.Millisecond, 0)
+	r.RegisterWithTimeout("slow", CheckerFunc(func(ctx context.Context) error {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}), time.Second)
+
+	if report := r.Check(context.Background()); !report.OK() {
+		t.Errorf("Check() = %+v, собственный таймаут не учтен", report)
+	}
+}
+
+func TestRegistryCache(t *testing.T) {
+	var calls atomic.Int32
+	counting := CheckerFunc(func(context.Context) error { calls.Add(1); return nil })
+
+	r := NewRegistry(time.Second, 50*time.Millisecond)
+	r.Register("a", counting)
+	r.Check(context.Background())
+	r.Check(context.Background())
+	if n := calls.Load(); n != 1 {
+		t.Errorf("проверок в пределах cacheTTL: %d, want 1", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	r.Check(context.Background())
+	if n := calls.Load(); n != 2 {
+		t.Errorf("проверок после cacheTTL: %d, want 2", n)
+	}
+
+	// Новая проверка сбрасывает кэш
+	r.Register("b", CheckerFunc(func(context.Context) error { return errors.New("сбой") }))
+	if report := r.Check(context.Background()); report.OK() || calls.Load() != 3 {
+		t.Errorf("после Register: Status = %q, проверок %d", report.Status, calls.Load())
+	}
+}
+
+func TestRegistryIgnoresCallerCancel(t *testing.T) {
+	r := NewRegistry(time.Second, time.Minute)
+	r.Register("a", CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return ctx.Err()
+	}))
+
+	// Результат попадает в кэш для всех, поэтому отмена запроса,
+	// запустившего проверки, не должна их проваливать
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := r.Check(ctx); !report.OK() {
+		t.Errorf("Check() с отмененным контекстом = %+v", report)
+	}
+}
+
+func TestRegistryShutdown(t *testing.T) {
+	r := NewRegistry(time.Second, time.Minute)
+	r.Register("a", CheckerFunc(ok))
+	if report := r.Check(context.Background()); !report.OK() {
+		t.Fatalf("Check() = %+v", report)
+	}
+
+	r.Shutdown()
+	if !r.ShuttingDown() {
+		t.Error("ShuttingDown() = false после Shutdown()")
+	}
+	// Кэшированный успешный результат не скрывает остановку
+	report := r.Check(context.Background())
+	if report.Status != StatusShuttingDown || report.OK() {
+		t.Errorf("Status = %q, want %q", report.Status, StatusShuttingDown)
+	}
+	if len(report.Checks) != 1 || report.Checks[0].Status != StatusOK {
+		t.Errorf("Checks = %+v, результаты проверок потеряны", report.Checks)
+	}
+}
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireToken пропускает запрос только с заголовком Authorization: Bearer <token>.
+// Токен запрашивается при каждом запросе, чтобы его можно было сменить
+// без перезапуска; с пустым токеном запросы отклоняются.
+func RequireToken(token func() string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !authorized(r, token()) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "Требуется токен доступа", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorized сообщает, предъявлен ли в запросе токен want;
+// пустой want не совпадает ни с каким токеном
+func authorized(r *http.Request, want string) bool {
+	if want == "" {
+		return false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(want)) == 1
+}
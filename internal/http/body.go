@@ -0,0 +1,21 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/ybotet/pz12-notes-api/internal/http/handlers"
+)
+
+// MaxJSONBody ограничивает размер JSON-тел запросов; при превышении
+// чтение тела завершается ошибкой *http.MaxBytesError. Тела других типов
+// (файлы, архивы) ограничивают обработчики, которые их принимают.
+func MaxJSONBody(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && handlers.IsJSONRequest(r) {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxJSONBody(t *testing.T) {
+	big := `{"title":"` + strings.Repeat("x", 100) + `"}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     bool
+	}{
+		{name: "JSON в пределах лимита", contentType: "application/json", body: `{"title":"x"}`},
+		{name: "JSON больше лимита", contentType: "application/json", body: big, wantErr: true},
+		{name: "+json больше лимита", contentType: "application/merge-patch+json", body: big, wantErr: true},
+		{name: "файл не ограничивается", contentType: "application/octet-stream", body: big},
+		{name: "без Content-Type", body: big},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readErr error
+			h := MaxJSONBody(64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, readErr = io.ReadAll(r.Body)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if (readErr != nil) != tt.wantErr {
+				t.Errorf("чтение тела: error = %v, wantErr %v", readErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouterMaxJSONBody(t *testing.T) {
+	r := newTestRouter(t, WithMaxJSONBody(64))
+
+	if rec := serve(r, http.MethodPost, "/api/v1/notes", `{"title":"План"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("POST в пределах лимита = %d: %s", rec.Code, rec.Body)
+	}
+	rec := serve(r, http.MethodPost, "/api/v1/notes", `{"title":"`+strings.Repeat("x", 100)+`"}`)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("POST больше лимита = %d, want %d: %s", rec.Code, http.StatusRequestEntityTooLarge, rec.Body)
+	}
+	rec = serve(r, http.MethodPut, "/api/v1/notes/1", `{"title":"x","extra":1}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"extra"`) {
+		t.Errorf("PUT с неизвестным полем = %d: %s", rec.Code, rec.Body)
+	}
+}
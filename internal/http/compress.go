@@ -0,0 +1,332 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Поддерживаемые кодирования в порядке предпочтения сервера
+var encodings = []string{"zstd", "gzip", "deflate"}
+
+// encoder — общий интерфейс gzip.Writer, zlib.Writer и zstd.Encoder
+type encoder interface {
+	io.Writer
+	Flush() error
+	Close() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	"zstd": {New: func() any {
+		// Окно 1 МБ: меньше памяти на каждый ответ, браузеры
+		// принимают окна до 8 МБ
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(1<<20))
+		return e
+	}},
+	"gzip":    {New: func() any { return gzip.NewWriter(nil) }},
+	"deflate": {New: func() any { return zlib.NewWriter(nil) }},
+}
+
+// Compress сжимает ответы кодированием, выбранным по Accept-Encoding.
+// Ответ накапливается до minSize байт: меньшие ответы отправляются
+// без сжатия. Сжимаются только текстовые типы (JSON, text/*, XML);
+// ответы с Content-Encoding или поддержкой Range (файлы вложений)
+// передаются как есть. Flush отправляет уже сжатые данные клиенту,
+// поэтому потоковые ответы (SSE) не задерживаются.
+func Compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding выбирает кодирование с наибольшим q из Accept-Encoding;
+// при равных q — по порядку encodings. Элементы с неверным q пропускаются,
+// x-gzip считается синонимом gzip. Пустая строка означает ответ
+// без сжатия.
+func negotiateEncoding(accept string) string {
+	if accept == "" {
+		return ""
+	}
+	weights := make(map[string]float64)
+	for _, item := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(item, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q, ok := qualityValue(params)
+		if name == "" || !ok {
+			continue
+		}
+		if name == "x-gzip" {
+			if _, exists := weights["gzip"]; exists {
+				continue
+			}
+			name = "gzip"
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range encodings {
+		q, ok := weights[name]
+		if !ok {
+			q = weights["*"]
+		}
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// qualityValue находит q среди параметров элемента Accept-Encoding;
+// без q вес равен 1. ok=false, если q не число от 0 до 1.
+func qualityValue(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || !(q >= 0 && q <= 1) {
+			return 0, false
+		}
+		return q, true
+	}
+	return 1, true
+}
+
+// compressWriter решает, сжимать ли ответ, когда накоплено minSize байт,
+// при Flush или по завершении обработчика
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if status < http.StatusOK {
+		// Информационные ответы (103 Early Hints) не влияют на решение
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.start()
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.minSize {
+			return len(p), nil
+		}
+		if err := cw.start(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush отправляет накопленное клиенту; ответ, сброшенный до набора
+// minSize байт, считается потоковым и сжимается, если позволяет тип
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.start()
+	}
+	if cw.enc != nil {
+		cw.enc.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap дает http.ResponseController доступ к исходному writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start принимает решение о сжатии, отправляет заголовки
+// и накопленную часть тела
+func (cw *compressWriter) start() error {
+	cw.decided = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.compressible() {
+		h := cw.Header()
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		cw.enc = encoderPools[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (cw *compressWriter) compressible() bool {
+	if cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Accept-Ranges") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		if len(cw.buf) == 0 {
+			return false
+		}
+		// net/http определил бы тип по сжатым байтам, поэтому тип
+		// определяется здесь по исходным
+		contentType = http.DetectContentType(cw.buf)
+		h.Set("Content-Type", contentType)
+	}
+	return compressibleType(contentType)
+}
+
+// close завершает ответ: короткий ответ отправляется без сжатия,
+// сжатый поток закрывается, а кодировщик возвращается в пул
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return
+		}
+		cw.decided = true
+		cw.ResponseWriter.WriteHeader(cw.status)
+		cw.ResponseWriter.Write(cw.buf)
+		return
+	}
+	if cw.enc != nil {
+		cw.enc.Close()
+		cw.enc.Reset(nil)
+		encoderPools[cw.encoding].Put(cw.enc)
+		cw.enc = nil
+	}
+}
+
+// compressibleType сообщает, имеет ли смысл сжимать данные этого типа
+func compressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/xml",
+		"application/javascript", "application/yaml", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+var errUnsupportedEncoding = errors.New("неподдерживаемое кодирование")
+
+// DecompressRequest распаковывает тела запросов с Content-Encoding
+// gzip, deflate или zstd, например архивы массового импорта.
+// Ограничения размера тела применяются к распакованным данным.
+func DecompressRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+		if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := decodeBody(encoding, r.Body)
+		if errors.Is(err, errUnsupportedEncoding) {
+			w.Header().Set("Accept-Encoding", strings.Join(encodings, ", "))
+			http.Error(w, "Неподдерживаемое кодирование тела запроса", http.StatusUnsupportedMediaType)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Неверное сжатое тело запроса", http.StatusBadRequest)
+			return
+		}
+		defer body.Close()
+
+		r.Body = body
+		r.ContentLength = -1
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Ограничения памяти распаковщика zstd: окно до 8 МиБ, как требует
+// RFC 8878 для Content-Encoding: zstd, иначе заголовок кадра из
+// нескольких байт заставит выделить до 512 МиБ на запрос
+const (
+	zstdMaxWindow = 8 << 20
+	zstdMaxMemory = 16 << 20
+)
+
+func decodeBody(encoding string, body io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		return zlib.NewReader(body)
+	case "zstd":
+		d, err := zstd.NewReader(body,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxWindow(zstdMaxWindow),
+			zstd.WithDecoderMaxMemory(zstdMaxMemory),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return nil, errUnsupportedEncoding
+}
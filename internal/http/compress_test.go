@@ -0,0 +1,247 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: ""},
+		{accept: "identity", want: ""},
+		{accept: "gzip", want: "gzip"},
+		{accept: "GZIP", want: "gzip"},
+		{accept: "x-gzip", want: "gzip"},
+		{accept: "gzip, deflate, br, zstd", want: "zstd"},
+		{accept: "deflate, gzip", want: "gzip"},
+		{accept: "gzip;q=0.5, deflate;q=0.8", want: "deflate"},
+		{accept: "gzip; q=0.5, deflate; Q=0.8", want: "deflate"},
+		{accept: "zstd;q=0, gzip", want: "gzip"},
+		{accept: "gzip;level=1;q=0, deflate;q=0.1", want: "deflate"},
+		{accept: "*", want: "zstd"},
+		{accept: "*;q=0.1, gzip;q=0.5", want: "gzip"},
+		{accept: "*;q=0", want: ""},
+		{accept: "gzip;q=0, *", want: "zstd"},
+		{accept: "gzip;q=abc", want: ""},
+		{accept: "gzip;q=2, deflate;q=0.1", want: "deflate"},
+		{accept: "gzip;q=-1", want: ""},
+		{accept: "gzip;q=NaN", want: ""},
+		{accept: "br", want: ""},
+		{accept: " , gzip ,", want: "gzip"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.accept); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	const minSize = 64
+	large := `{"notes":"` + strings.Repeat("заметка ", 64) + `"}`
+
+	tests := []struct {
+		name         string
+		method       string
+		accept       string
+		status       int
+		header       http.Header
+		body         string
+		wantEncoding string
+	}{
+		{name: "большой JSON", accept: "gzip", header: http.Header{"Content-Type": {"application/json"}}, body: large, wantEncoding: "gzip"},
+		{name: "предпочтение zstd", accept: "gzip, zstd", header: http.Header{"Content-Type": {"application/json"}}, body: large, wantEncoding: "zstd"},
+		{name: "тип по содержимому", accept: "gzip", body: strings.Repeat("текст ", 64), wantEncoding: "gzip"},
+		{name: "короткий ответ", accept: "gzip", header: http.Header{"Content-Type": {"application/json"}}, body: `{"ok":true}`},
+		{name: "клиент не поддерживает сжатие", header: http.Header{"Content-Type": {"application/json"}}, body: large},
+		{name: "изображение", accept: "gzip", header: http.Header{"Content-Type": {"image/png"}}, body: large},
+		{name: "уже сжатый ответ", accept: "gzip", header: http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {"br"}}, body: large, wantEncoding: "br"},
+		{name: "ответ с Range", accept: "gzip", header: http.Header{"Content-Type": {"text/plain"}, "Accept-Ranges": {"bytes"}}, body: large},
+		{name: "HEAD", method: http.MethodHead, accept: "gzip", header: http.Header{"Content-Type": {"application/json"}}, body: large},
+		{name: "204", accept: "gzip", status: http.StatusNoContent},
+		{name: "ошибка с большим телом", accept: "gzip", status: http.StatusBadRequest, header: http.Header{"Content-Type": {"application/problem+json"}}, body: large, wantEncoding: "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			h := Compress(minSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(status)
+				io.WriteString(w, tt.body)
+			}))
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/api/v1/notes", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != status {
+				t.Errorf("status = %d, want %d", w.Code, status)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Accept-Encoding") {
+				t.Error("ответ без Vary: Accept-Encoding")
+			}
+			if tt.wantEncoding == "br" {
+				return
+			}
+			got := decodeResponse(t, tt.wantEncoding, w.Body.Bytes())
+			if got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func decodeResponse(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader = bytes.NewReader(body)
+	switch encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	case "zstd":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDecompressRequest(t *testing.T) {
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(s))
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "без сжатия", body: []byte("plain"), wantStatus: http.StatusOK, wantBody: "plain"},
+		{name: "identity", encoding: "identity", body: []byte("plain"), wantStatus: http.StatusOK, wantBody: "plain"},
+		{name: "gzip", encoding: "gzip", body: gzipped("заметки"), wantStatus: http.StatusOK, wantBody: "заметки"},
+		{name: "x-gzip", encoding: "X-Gzip", body: gzipped("заметки"), wantStatus: http.StatusOK, wantBody: "заметки"},
+		{name: "zstd", encoding: "zstd", body: zstdFrame(t, []byte("заметки"), 1<<20), wantStatus: http.StatusOK, wantBody: "заметки"},
+		{name: "поврежденный gzip", encoding: "gzip", body: []byte("не gzip"), wantStatus: http.StatusBadRequest},
+		{name: "неподдерживаемое", encoding: "br", body: []byte("x"), wantStatus: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := DecompressRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				got = string(data)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/notes/import", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				r.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+// zstdFrame сжимает data потоком, в заголовке кадра которого
+// объявлено окно window
+func zstdFrame(t *testing.T, data []byte, window int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf, zstd.WithWindowSize(window))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zstdRawFrame собирает кадр zstd из одного несжатого блока с окном
+// 2^windowLog байт в заголовке: кодировщик уменьшает окно до размера
+// данных, а распаковщик должен отклонять кадр по одному заголовку
+func zstdRawFrame(windowLog uint, data []byte) []byte {
+	frame := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, byte(windowLog-10) << 3}
+	header := uint32(len(data))<<3 | 1 // последний блок, без сжатия
+	frame = append(frame, byte(header), byte(header>>8), byte(header>>16))
+	return append(frame, data...)
+}
+
+func TestDecodeBodyZstdWindow(t *testing.T) {
+	data := []byte("заметка")
+
+	tests := []struct {
+		name      string
+		windowLog uint
+		wantErr   error
+	}{
+		{name: "окно 1 МиБ", windowLog: 20},
+		{name: "окно 8 МиБ", windowLog: 23},
+		{name: "окно 16 МиБ", windowLog: 24, wantErr: zstd.ErrWindowSizeExceeded},
+		{name: "окно 256 МиБ", windowLog: 28, wantErr: zstd.ErrWindowSizeExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := decodeBody("zstd", bytes.NewReader(zstdRawFrame(tt.windowLog, data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer body.Close()
+			got, err := io.ReadAll(body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadAll() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !bytes.Equal(got, data) {
+				t.Errorf("распаковано %q, want %q", got, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions — параметры доступа к API из браузера с других источников
+type CORSOptions struct {
+	// AllowedOrigins — точные источники (https://app.example.com),
+	// шаблоны поддоменов (https://*.example.com) или * для любого источника
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders — заголовки запроса; * разрешает любые
+	AllowedHeaders []string
+	// ExposedHeaders — заголовки ответа, доступные скриптам
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge — время кэширования ответа на предварительный запрос
+	MaxAge time.Duration
+}
+
+// CORS отвечает на предварительные запросы OPTIONS и добавляет заголовки
+// Access-Control-* к ответам на запросы с разрешенных источников.
+// Предварительные запросы обрабатываются до маршрутизации, поэтому
+// маршрутам chi не нужны обработчики OPTIONS.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	c := newCORS(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if r.Method == http.MethodOptions && origin != "" && r.Header.Get("Access-Control-Request-Method") != "" {
+				c.preflight(w, r, origin)
+				return
+			}
+
+			// Ответ зависит от Origin, даже если источник не разрешен
+			w.Header().Add("Vary", "Origin")
+			if origin != "" && c.allowOrigin(origin) {
+				c.setOrigin(w.Header(), origin)
+				if len(opts.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type cors struct {
+	opts       CORSOptions
+	anyOrigin  bool
+	origins    map[string]bool
+	subdomains []originPattern
+	methods    map[string]bool
+	anyHeader  bool
+	headers    map[string]bool
+}
+
+// originPattern — шаблон https://*.example.com: схема и суффикс хоста
+type originPattern struct {
+	scheme string
+	suffix string
+}
+
+func newCORS(opts CORSOptions) *cors {
+	c := &cors{
+		opts:    opts,
+		origins: make(map[string]bool),
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+	for _, o := range opts.AllowedOrigins {
+		o = strings.ToLower(o)
+		if o == "*" {
+			c.anyOrigin = true
+		} else if scheme, host, ok := strings.Cut(o, "://*."); ok {
+			c.subdomains = append(c.subdomains, originPattern{scheme: scheme + "://", suffix: "." + host})
+		} else {
+			c.origins[o] = true
+		}
+	}
+	for _, m := range opts.AllowedMethods {
+		c.methods[strings.ToUpper(m)] = true
+	}
+	for _, h := range opts.AllowedHeaders {
+		if h == "*" {
+			c.anyHeader = true
+		}
+		c.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	return c
+}
+
+func (c *cors) allowOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	if c.anyOrigin || c.origins[origin] {
+		return true
+	}
+	for _, p := range c.subdomains {
+		host, ok := strings.CutPrefix(origin, p.scheme)
+		if ok && len(host) > len(p.suffix) && strings.HasSuffix(host, p.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrigin разрешает ответ источнику. С учетными данными браузер
+// не принимает *, поэтому источник возвращается как есть.
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.anyOrigin && !c.opts.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.opts.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// preflight отвечает на предварительный запрос: 204 с разрешениями
+// или 403, если источник, метод или заголовки не разрешены
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	method := r.Header.Get("Access-Control-Request-Method")
+	requested := requestedHeaders(r)
+	if !c.allowOrigin(origin) || !c.methods[method] || !c.allowHeaders(requested) {
+		http.Error(w, "Запрос с этого источника не разрешен", http.StatusForbidden)
+		return
+	}
+
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(c.opts.AllowedMethods, ", "))
+	if len(requested) > 0 {
+		// Разрешаются ровно запрошенные заголовки: они уже проверены
+		h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if c.opts.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.opts.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *cors) allowHeaders(requested []string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, name := range requested {
+		if !c.headers[http.CanonicalHeaderKey(name)] {
+			return false
+		}
+	}
+	return true
+}
+
+// requestedHeaders разбирает Access-Control-Request-Headers
+func requestedHeaders(r *http.Request) []string {
+	var names []string
+	for _, v := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORSAllowOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "точное совпадение", allowed: []string{"https://app.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "без учета регистра", allowed: []string{"https://App.Example.com"}, origin: "https://app.EXAMPLE.com", want: true},
+		{name: "другая схема", allowed: []string{"https://app.example.com"}, origin: "http://app.example.com"},
+		{name: "другой порт", allowed: []string{"https://app.example.com"}, origin: "https://app.example.com:8443"},
+		{name: "любой источник", allowed: []string{"*"}, origin: "https://evil.test", want: true},
+		{name: "поддомен", allowed: []string{"https://*.example.com"}, origin: "https://app.example.com", want: true},
+		{name: "вложенный поддомен", allowed: []string{"https://*.example.com"}, origin: "https://a.b.example.com", want: true},
+		{name: "сам домен не поддомен", allowed: []string{"https://*.example.com"}, origin: "https://example.com"},
+		{name: "пустая метка", allowed: []string{"https://*.example.com"}, origin: "https://.example.com"},
+		{name: "суффикс без точки", allowed: []string{"https://*.example.com"}, origin: "https://evilexample.com"},
+		{name: "домен как префикс", allowed: []string{"https://*.example.com"}, origin: "https://app.example.com.evil.test"},
+		{name: "схема шаблона", allowed: []string{"https://*.example.com"}, origin: "http://app.example.com"},
+		{name: "порт в шаблоне", allowed: []string{"http://*.localhost:3000"}, origin: "http://app.localhost:3000", want: true},
+		{name: "порт не из шаблона", allowed: []string{"https://*.example.com"}, origin: "https://app.example.com:8443"},
+		{name: "null", allowed: []string{"https://app.example.com"}, origin: "null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCORS(CORSOptions{AllowedOrigins: tt.allowed})
+			if got := c.allowOrigin(tt.origin); got != tt.want {
+				t.Errorf("allowOrigin(%q) with %q = %v, want %v", tt.origin, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name        string
+		opts        func(o *CORSOptions)
+		method      string
+		header      http.Header
+		wantStatus  int
+		wantOrigin  string
+		wantHeaders string
+		wantCreds   bool
+		wantNext    bool
+	}{
+		{
+			name:       "простой запрос с разрешенного источника",
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://app.example.com"}},
+			wantStatus: http.StatusOK, wantOrigin: "https://app.example.com", wantNext: true,
+		},
+		{
+			name:       "простой запрос с чужого источника",
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://evil.test"}},
+			wantStatus: http.StatusOK, wantNext: true,
+		},
+		{
+			name:       "запрос без Origin",
+			method:     http.MethodOptions,
+			wantStatus: http.StatusOK, wantNext: true,
+		},
+		{
+			name:   "предварительный запрос",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.example.com"},
+				"Access-Control-Request-Method":  {"POST"},
+				"Access-Control-Request-Headers": {"content-type, authorization"},
+			},
+			wantStatus: http.StatusNoContent, wantOrigin: "https://app.example.com", wantHeaders: "content-type, authorization",
+		},
+		{
+			name:   "предварительный запрос с чужого источника",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                        {"https://evil.test"},
+				"Access-Control-Request-Method": {"GET"},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "неразрешенный метод",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                        {"https://app.example.com"},
+				"Access-Control-Request-Method": {"DELETE"},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "неразрешенный заголовок",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.example.com"},
+				"Access-Control-Request-Method":  {"POST"},
+				"Access-Control-Request-Headers": {"Content-Type, X-Secret"},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "любой заголовок",
+			opts:   func(o *CORSOptions) { o.AllowedHeaders = []string{"*"} },
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.example.com"},
+				"Access-Control-Request-Method":  {"POST"},
+				"Access-Control-Request-Headers": {"X-Secret"},
+			},
+			wantStatus: http.StatusNoContent, wantOrigin: "https://app.example.com", wantHeaders: "X-Secret",
+		},
+		{
+			name:       "любой источник без учетных данных",
+			opts:       func(o *CORSOptions) { o.AllowedOrigins = []string{"*"} },
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://evil.test"}},
+			wantStatus: http.StatusOK, wantOrigin: "*", wantNext: true,
+		},
+		{
+			name:       "любой источник с учетными данными",
+			opts:       func(o *CORSOptions) { o.AllowedOrigins = []string{"*"}; o.AllowCredentials = true },
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://evil.test"}},
+			wantStatus: http.StatusOK, wantOrigin: "https://evil.test", wantCreds: true, wantNext: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := opts
+			if tt.opts != nil {
+				tt.opts(&o)
+			}
+			var called bool
+			h := CORS(o)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+			r := httptest.NewRequest(tt.method, "/api/v1/notes", nil)
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if called != tt.wantNext {
+				t.Errorf("next called = %v, want %v", called, tt.wantNext)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Headers"); got != tt.wantHeaders {
+				t.Errorf("Access-Control-Allow-Headers = %q, want %q", got, tt.wantHeaders)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCreds {
+				t.Errorf("Access-Control-Allow-Credentials = %v, want %v", got, tt.wantCreds)
+			}
+			if tt.header.Get("Origin") != "" && len(w.Header().Values("Vary")) == 0 {
+				t.Error("ответ без Vary: Origin")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// redocPage — страница ReDoc, читающая спецификацию из /docs/swagger.json
+const redocPage = `
+            <!DOCTYPE html>
+            <html>
+            <head>
+                <title>Notes API - ReDoc</title>
+                <meta charset="utf-8"/>
+                <meta name="viewport" content="width=device-width, initial-scale=1">
+            </head>
+            <body>
+                <redoc spec-url='/docs/swagger.json'></redoc>
+                <script src="https://cdn.jsdelivr.net/npm/redoc@next/bundles/redoc.standalone.js"></script>
+            </body>
+            </html>
+        `
+
+// mountDocs подключает Swagger UI (/docs) и ReDoc (/redoc)
+func mountDocs(r chi.Router, dir string) {
+	// Configurar Swagger UI manualmente (sin importar http-swagger)
+	r.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs/index.html", http.StatusMovedPermanently)
+	})
+
+	r.Get("/docs/*", func(w http.ResponseWriter, r *http.Request) {
+		http.StripPrefix("/docs", http.FileServer(http.Dir(dir))).ServeHTTP(w, r)
+	})
+
+	// Ruta de ReDoc (alternativa)
+	r.Get("/redoc", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(redocPage))
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/backup"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+)
+
+// maxBackupSize ограничивает размер загружаемого архива
+const maxBackupSize = 256 << 20
+
+// AdminHandler обслуживает административные маршруты
+type AdminHandler struct {
+	Store       backup.Store
+	NoteService service.NoteService
+}
+
+func NewAdminHandler(store backup.Store, noteService service.NoteService) *AdminHandler {
+	return &AdminHandler{Store: store, NoteService: noteService}
+}
+
+// ExportBackup godoc
+// @Summary Резервная копия хранилища
+// @Description Возвращает ZIP-архив со всеми заметками (JSON и Markdown), комментариями, вложениями с содержимым, шаблонами и манифестом с контрольными суммами SHA-256
+// @Tags admin
+// @Produce application/zip
+// @Success 200 {file} file "ZIP-архив"
+// @Failure 500 {object} core.ErrorResponse
+// @Failure 401 {object} core.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/admin/export [get]
+func (h *AdminHandler) ExportBackup(w http.ResponseWriter, r *http.Request) {
+	// Собрать архив целиком, чтобы при ошибке вернуть корректный статус
+	var buf bytes.Buffer
+	if err := backup.Export(r.Context(), h.Store, &buf); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := fmt.Sprintf("notes-backup-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Write(buf.Bytes())
+}
+
+// ImportBackup godoc
+// @Summary Восстановление из резервной копии
+// @Description Восстанавливает заметки из ZIP-архива, созданного /admin/export. Режим merge добавляет заметки к существующим, replace заменяет ими существующие вместе с их комментариями, вложениями и шаблонами. Архив и заметки проверяются до изменений. Заметки получают новые ID, ссылки [[#id]] между ними переписываются, комментарии и вложения привязываются к новым ID
+// @Tags admin
+// @Accept application/zip
+// @Produce json
+// @Param mode query string false "Режим восстановления: merge (по умолчанию) или replace"
+// @Param archive body string true "ZIP-архив"
+// @Success 200 {object} core.BackupImportResponse
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 500 {object} core.ErrorResponse
+// @Failure 401 {object} core.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/admin/import [post]
+func (h *AdminHandler) ImportBackup(w http.ResponseWriter, r *http.Request) {
+	mode := backup.Mode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = backup.ModeMerge
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBackupSize))
+	if err != nil {
+		http.Error(w, "Неверный ввод", http.StatusBadRequest)
+		return
+	}
+
+	result, err := backup.Import(r.Context(), h.NoteService, h.Store, data, mode)
+	if err != nil {
+		if strings.Contains(err.Error(), "восстановление заметок") {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	resp := core.BackupImportResponse{
+		Mode:        string(result.Mode),
+		Deleted:     result.Deleted,
+		Imported:    result.Imported,
+		Comments:    result.Comments,
+		Attachments: result.Attachments,
+		Templates:   result.Templates,
+		IDMap:       make([]core.BackupIDMapping, len(result.IDMap)),
+	}
+	for i, m := range result.IDMap {
+		resp.IDMap[i] = core.BackupIDMapping{Old: m.Old, New: m.New}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
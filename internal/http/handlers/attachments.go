@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/blob"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/imaging"
+)
+
+// AttachmentHandler обслуживает маршруты вложений
+type AttachmentHandler struct {
+	AttachmentService service.AttachmentService
+	// MaxUploadSize ограничивает размер одного загружаемого вложения
+	MaxUploadSize int64
+}
+
+func NewAttachmentHandler(attachmentService service.AttachmentService, maxUploadSize int64) *AttachmentHandler {
+	return &AttachmentHandler{AttachmentService: attachmentService, MaxUploadSize: maxUploadSize}
+}
+
+// UploadAttachment godoc
+// @Summary Загрузить вложение
+// @Description Прикрепляет файл к заметке. Одинаковое содержимое хранится один раз; объем вложений пользователя ограничен квотой
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param file formData file true "Файл"
+// @Param X-User-ID header string false "Идентификатор пользователя; учитывается только при auth.trust_user_header, иначе пользователь определяется по токену"
+// @Success 201 {object} core.Attachment
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 413 {object} core.ErrorResponse
+// @Failure 500 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	noteID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxUploadSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Неверный ввод", http.StatusBadRequest)
+		return
+	}
+
+	// Найти часть с файлом и передать её в хранилище потоком
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			http.Error(w, "Не передан файл", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.AttachmentService.Upload(r.Context(), noteID, part.FileName(), part.Header.Get("Content-Type"), part)
+		part.Close()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			switch {
+			case errors.As(err, &maxBytesErr):
+				http.Error(w, "Файл слишком большой", http.StatusRequestEntityTooLarge)
+			case strings.Contains(err.Error(), "квота"), strings.Contains(err.Error(), "слишком большое"):
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			case strings.Contains(err.Error(), "не найдена"):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case strings.Contains(err.Error(), "неверный ID"), strings.Contains(err.Error(), "не может быть пустым"),
+				strings.Contains(err.Error(), "неверная"):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(attachment)
+		return
+	}
+}
+
+// ListAttachments godoc
+// @Summary Вложения заметки
+// @Description Возвращает метаданные всех вложений заметки
+// @Tags attachments
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {array} core.Attachment
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/attachments [get]
+func (h *AttachmentHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	noteID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	attachments, err := h.AttachmentService.ListAttachments(r.Context(), noteID)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найдена") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attachments)
+}
+
+// DownloadAttachment godoc
+// @Summary Скачать вложение
+// @Description Отдает содержимое вложения потоком; поддерживаются запросы Range и условные запросы. Типы, которые браузер может исполнить (HTML, SVG, скрипты), отдаются как application/octet-stream
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "ID вложения"
+// @Param Range header string false "Диапазон байт, например bytes=0-1023"
+// @Success 200 {file} file "Содержимое вложения"
+// @Success 206 {file} file "Часть содержимого"
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/attachments/{id} [get]
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := h.AttachmentService.Open(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найдено") || errors.Is(err, blob.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else if strings.Contains(err.Error(), "неверный ID") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer content.Close()
+
+	// Тип вложения задает клиент, поэтому браузеру отдаются только
+	// типы, которые он не исполнит как страницу или скрипт
+	w.Header().Set("Content-Type", downloadContentType(attachment.ContentType))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("ETag", `"`+attachment.Hash+`"`)
+	http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, content)
+}
+
+// safeContentTypes — типы вложений, которые отдаются как есть;
+// HTML, SVG, XML и прочие активные типы отдаются как двоичные данные
+var safeContentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+	"text/csv":        true,
+	"audio/mpeg":      true,
+	"video/mp4":       true,
+	"application/zip": true,
+}
+
+// downloadContentType возвращает Content-Type для скачивания вложения:
+// тип из списка safeContentTypes или application/octet-stream
+func downloadContentType(contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !safeContentTypes[mediaType] {
+		return "application/octet-stream"
+	}
+	if charset, ok := params["charset"]; ok {
+		return mime.FormatMediaType(mediaType, map[string]string{"charset": charset})
+	}
+	return mediaType
+}
+
+// GetThumbnail godoc
+// @Summary Миниатюра изображения
+// @Description Возвращает уменьшенную копию изображения-вложения (JPEG, PNG, GIF). Ширина округляется вверх до одной из 64, 128, 256, 512, 1024; изображение не увеличивается
+// @Tags attachments
+// @Produce image/jpeg
+// @Produce image/png
+// @Param id path int true "ID вложения"
+// @Param w query int false "Желаемая ширина миниатюры"
+// @Success 200 {file} file "Миниатюра"
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 415 {object} core.ErrorResponse
+// @Failure 503 {object} core.ErrorResponse
+// @Router /api/v1/attachments/{id}/thumb [get]
+func (h *AttachmentHandler) GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	width := 0
+	if wStr := r.URL.Query().Get("w"); wStr != "" {
+		if width, err = strconv.Atoi(wStr); err != nil || width <= 0 {
+			http.Error(w, "Неверная ширина", http.StatusBadRequest)
+			return
+		}
+	}
+
+	attachment, thumb, err := h.AttachmentService.Thumbnail(r.Context(), id, width)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrPoolBusy):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		case strings.Contains(err.Error(), "не является изображением"):
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		case strings.Contains(err.Error(), "не найдено"), errors.Is(err, blob.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case strings.Contains(err.Error(), "неверный ID"):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer thumb.Close()
+
+	// Тип содержимого определяется ServeContent по первым байтам
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.Thumbnails[service.ThumbnailWidth(width)]+`"`)
+	http.ServeContent(w, r, "", attachment.CreatedAt, thumb)
+}
+
+// DeleteAttachment godoc
+// @Summary Удалить вложение
+// @Description Удаляет вложение; доступно только владельцу. Содержимое удаляется, если на него больше нет ссылок
+// @Tags attachments
+// @Param id path int true "ID вложения"
+// @Success 204 "No Content"
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 403 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 500 {object} core.ErrorResponse
+// @Router /api/v1/attachments/{id} [delete]
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.AttachmentService.DeleteAttachment(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "нет прав") {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else if strings.Contains(err.Error(), "не найдено") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else if strings.Contains(err.Error(), "неверный ID") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/blob"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/imaging"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+)
+
+func TestDownloadAttachmentHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		want        string
+	}{
+		{name: "изображение", fileName: "photo.png", contentType: "image/png", want: "image/png"},
+		{name: "PDF", fileName: "doc.pdf", contentType: "application/pdf", want: "application/pdf"},
+		{name: "текст с кодировкой", fileName: "a.txt", contentType: "text/plain; charset=utf-8; x=1", want: "text/plain; charset=utf-8"},
+		{name: "HTML", fileName: "page.html", contentType: "text/html", want: "application/octet-stream"},
+		{name: "SVG", fileName: "logo.svg", contentType: "image/svg+xml", want: "application/octet-stream"},
+		{name: "XHTML", fileName: "page.xhtml", contentType: "application/xhtml+xml", want: "application/octet-stream"},
+		{name: "JavaScript", fileName: "app.js", contentType: "text/javascript", want: "application/octet-stream"},
+		{name: "регистр типа", fileName: "page.html", contentType: "TEXT/HTML", want: "application/octet-stream"},
+		{name: "неразбираемый тип", fileName: "x.bin", contentType: "text/html;;", want: "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			notes := repo.NewNoteRepoMem()
+			noteID, err := notes.Create(ctx, core.Note{Title: "Заметка"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			pool := imaging.NewPool(0, 0)
+			t.Cleanup(pool.Close)
+			attachments := service.NewAttachmentService(notes, repo.NewAttachmentRepoMem(), blob.NewMemStore(), 1<<20, pool)
+			a, err := attachments.Upload(ctx, noteID, tt.fileName, tt.contentType, strings.NewReader("<script>alert(1)</script>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := chi.NewRouter()
+			r.Get("/api/v1/attachments/{id}", NewAttachmentHandler(attachments, 1<<20).DownloadAttachment)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/attachments/"+strconv.FormatInt(a.ID, 10), nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.want {
+				t.Errorf("Content-Type = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+			}
+			if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment;") {
+				t.Errorf("Content-Disposition = %q, want attachment", got)
+			}
+		})
+	}
+}
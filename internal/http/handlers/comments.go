@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+)
+
+// CommentHandler обслуживает маршруты комментариев к заметкам
+type CommentHandler struct {
+	CommentService service.CommentService
+}
+
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{CommentService: commentService}
+}
+
+// ListComments godoc
+// @Summary Комментарии заметки
+// @Description Возвращает комментарии заметки деревом: ответы вложены в Replies. Удаленные комментарии с ответами остаются в ветке с Deleted=true
+// @Tags comments
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {array} core.Comment
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/comments [get]
+func (h *CommentHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	noteID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	thread, err := h.CommentService.GetThread(r.Context(), noteID)
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(thread)
+}
+
+// AddComment godoc
+// @Summary Добавить комментарий
+// @Description Добавляет комментарий от имени аутентифицированного пользователя; с parent_id — ответ на комментарий. Упомянутые через @имя пользователи получают уведомление
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param X-User-ID header string false "Идентификатор пользователя; учитывается только при auth.trust_user_header, иначе пользователь определяется по токену"
+// @Param id path int true "ID заметки"
+// @Param input body core.CommentCreateRequest true "Текст комментария"
+// @Success 201 {object} core.Comment
+// @Failure 400 {object} core.ValidationErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
+// @Router /api/v1/notes/{id}/comments [post]
+func (h *CommentHandler) AddComment(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	noteID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var commentReq core.CommentCreateRequest
+	if !decodeJSON(w, r, &commentReq) {
+		return
+	}
+
+	comment, err := h.CommentService.AddComment(r.Context(), noteID, commentReq.ParentID, commentReq.Body)
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// UpdateComment godoc
+// @Summary Изменить комментарий
+// @Description Изменяет текст комментария; доступно только автору
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param X-User-ID header string false "Идентификатор пользователя; учитывается только при auth.trust_user_header, иначе пользователь определяется по токену"
+// @Param id path int true "ID заметки"
+// @Param commentId path int true "ID комментария"
+// @Param input body core.CommentUpdateRequest true "Новый текст"
+// @Success 200 {object} core.Comment
+// @Failure 400 {object} core.ValidationErrorResponse
+// @Failure 403 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
+// @Router /api/v1/notes/{id}/comments/{commentId} [put]
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	noteID, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	var commentReq core.CommentUpdateRequest
+	if !decodeJSON(w, r, &commentReq) {
+		return
+	}
+
+	comment, err := h.CommentService.EditComment(r.Context(), noteID, commentID, commentReq.Body)
+	if err != nil {
+		writeCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// DeleteComment godoc
+// @Summary Удалить комментарий
+// @Description Удаляет комментарий; доступно только автору. Если на комментарий есть ответы, он остается в ветке как удаленный
+// @Tags comments
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param X-User-ID header string false "Идентификатор пользователя; учитывается только при auth.trust_user_header, иначе пользователь определяется по токену"
+// @Param id path int true "ID заметки"
+// @Param commentId path int true "ID комментария"
+// @Success 204 "No Content"
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 403 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/comments/{commentId} [delete]
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	noteID, commentID, ok := commentParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.CommentService.DeleteComment(r.Context(), noteID, commentID); err != nil {
+		writeCommentError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func commentParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	noteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "commentId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID комментария", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return noteID, commentID, true
+}
+
+func writeCommentError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "нет прав"):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case strings.Contains(err.Error(), "не найден"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case isValidationError(err), strings.Contains(err.Error(), "нельзя ответить"):
+		writeBadRequest(w, err)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
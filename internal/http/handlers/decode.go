@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/tracing"
+)
+
+// IsJSONRequest сообщает, что тело запроса объявлено как JSON:
+// application/json или тип с суффиксом +json
+func IsJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// decodeJSON строго разбирает JSON-тело запроса в dst: тело должно быть
+// объявлено как JSON, содержать ровно одно значение и только известные
+// поля. При ошибке отвечает клиенту сам (415, 413 или 400 с полем
+// и позицией ошибки) и возвращает false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if !IsJSONRequest(r) {
+		writeDecodeError(w, http.StatusUnsupportedMediaType, core.DecodeErrorResponse{
+			Error: "ожидается Content-Type: application/json",
+		})
+		return false
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(dst)
+	if err == nil {
+		// После значения допускаются только пробельные символы
+		if _, err = dec.Token(); err == io.EOF {
+			return true
+		}
+		err = errTrailingData
+	}
+
+	status, resp := describeDecodeError(err, dec.InputOffset())
+	writeDecodeError(w, status, resp)
+	return false
+}
+
+var errTrailingData = errors.New("лишние данные после JSON-значения")
+
+// describeDecodeError переводит ошибку разбора в ответ клиенту
+func describeDecodeError(err error, offset int64) (int, core.DecodeErrorResponse) {
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge, core.DecodeErrorResponse{
+			Error: fmt.Sprintf("тело запроса больше %d байт", maxBytesErr.Limit),
+		}
+	case errors.Is(err, io.EOF):
+		return http.StatusBadRequest, core.DecodeErrorResponse{Error: "пустое тело запроса"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return http.StatusBadRequest, core.DecodeErrorResponse{Error: "JSON оборван", Offset: offset}
+	case errors.As(err, &syntaxErr):
+		return http.StatusBadRequest, core.DecodeErrorResponse{
+			Error:  "синтаксическая ошибка JSON: " + strings.TrimPrefix(syntaxErr.Error(), "json: "),
+			Offset: syntaxErr.Offset,
+		}
+	case errors.As(err, &typeErr):
+		return http.StatusBadRequest, core.DecodeErrorResponse{
+			Error:  fmt.Sprintf("неверный тип значения: ожидается %s, получено %s", typeErr.Type, typeErr.Value),
+			Field:  typeErr.Field,
+			Offset: typeErr.Offset,
+		}
+	}
+
+	// encoding/json не экспортирует тип ошибки неизвестного поля
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return http.StatusBadRequest, core.DecodeErrorResponse{
+			Error:  "неизвестное поле",
+			Field:  strings.Trim(field, `"`),
+			Offset: offset,
+		}
+	}
+	if errors.Is(err, errTrailingData) {
+		return http.StatusBadRequest, core.DecodeErrorResponse{Error: err.Error(), Offset: offset}
+	}
+	// Ошибки UnmarshalJSON полей, например неверный формат времени
+	return http.StatusBadRequest, core.DecodeErrorResponse{
+		Error:  strings.TrimPrefix(err.Error(), "json: "),
+		Offset: offset,
+	}
+}
+
+func writeDecodeError(w http.ResponseWriter, status int, resp core.DecodeErrorResponse) {
+	resp.TraceID = w.Header().Get(tracing.TraceIDHeader)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/tracing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type request struct {
+		Title string     `json:"title"`
+		Tags  []string   `json:"tags"`
+		Due   *time.Time `json:"due"`
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		limit       int64
+		wantStatus  int
+		wantError   string
+		wantField   string
+		wantOffset  int64
+	}{
+		{name: "верное тело", contentType: "application/json", body: `{"title":"План","tags":["a"]}`},
+		{name: "charset и пробелы в конце", contentType: "application/json; charset=utf-8", body: "{\"title\":\"План\"}\n\t "},
+		{name: "суффикс +json", contentType: "application/merge-patch+json", body: `{}`},
+		{name: "без Content-Type", body: `{}`, wantStatus: http.StatusUnsupportedMediaType, wantError: "ожидается Content-Type"},
+		{name: "text/plain", contentType: "text/plain", body: `{}`, wantStatus: http.StatusUnsupportedMediaType, wantError: "ожидается Content-Type"},
+		{name: "пустое тело", contentType: "application/json", wantStatus: http.StatusBadRequest, wantError: "пустое тело"},
+		{name: "неизвестное поле", contentType: "application/json", body: `{"titel":"x"}`, wantStatus: http.StatusBadRequest, wantError: "неизвестное поле", wantField: "titel", wantOffset: 13},
+		{name: "неверный тип", contentType: "application/json", body: `{"tags":"a"}`, wantStatus: http.StatusBadRequest, wantError: "неверный тип значения", wantField: "tags", wantOffset: 11},
+		{name: "синтаксическая ошибка", contentType: "application/json", body: `{"title" "x"}`, wantStatus: http.StatusBadRequest, wantError: "синтаксическая ошибка", wantOffset: 10},
+		{name: "оборванный JSON", contentType: "application/json", body: `{"title":"Пл`, wantStatus: http.StatusBadRequest, wantError: "JSON оборван"},
+		{name: "два значения", contentType: "application/json", body: `{"title":"a"}{"title":"b"}`, wantStatus: http.StatusBadRequest, wantError: "лишние данные", wantOffset: 14},
+		{name: "мусор после значения", contentType: "application/json", body: `{"title":"a"} x`, wantStatus: http.StatusBadRequest, wantError: "лишние данные"},
+		{name: "неверная дата", contentType: "application/json", body: `{"due":"завтра"}`, wantStatus: http.StatusBadRequest, wantError: "parsing time"},
+		{name: "больше лимита", contentType: "application/json", body: `{"title":"` + strings.Repeat("x", 100) + `"}`, limit: 64, wantStatus: http.StatusRequestEntityTooLarge, wantError: "больше 64 байт"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			w.Header().Set(tracing.TraceIDHeader, "trace-1")
+			if tt.limit > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, tt.limit)
+			}
+
+			var dst request
+			ok := decodeJSON(w, r, &dst)
+			if ok != (tt.wantStatus == 0) {
+				t.Fatalf("decodeJSON() = %v, status %d: %s", ok, w.Code, w.Body)
+			}
+			if ok {
+				return
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var resp core.DecodeErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("ответ %q: %v", w.Body, err)
+			}
+			if !strings.Contains(resp.Error, tt.wantError) || resp.Field != tt.wantField || resp.TraceID != "trace-1" {
+				t.Errorf("ответ = %+v, want ошибку %q в поле %q", resp, tt.wantError, tt.wantField)
+			}
+			if tt.wantOffset != 0 && resp.Offset != tt.wantOffset {
+				t.Errorf("offset = %d, want %d", resp.Offset, tt.wantOffset)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+)
+
+// LogLevelHandler позволяет менять уровень журнала без перезапуска
+type LogLevelHandler struct {
+	Level *slog.LevelVar
+}
+
+func NewLogLevelHandler(level *slog.LevelVar) *LogLevelHandler {
+	return &LogLevelHandler{Level: level}
+}
+
+// GetLogLevel godoc
+// @Summary Уровень журнала
+// @Description Возвращает текущий уровень журнала сервера
+// @Tags admin
+// @Produce json
+// @Success 200 {object} core.LogLevel
+// @Failure 401 {object} core.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/admin/log-level [get]
+func (h *LogLevelHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.LogLevel{Level: strings.ToLower(h.Level.Level().String())})
+}
+
+// SetLogLevel godoc
+// @Summary Изменить уровень журнала
+// @Description Меняет уровень журнала до перезапуска или перезагрузки конфигурации с другим log.level
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param input body core.LogLevel true "Новый уровень: debug, info, warn или error"
+// @Success 200 {object} core.LogLevel
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 401 {object} core.ErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/admin/log-level [put]
+func (h *LogLevelHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req core.LogLevel
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	level, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	previous := h.Level.Level()
+	h.Level.Set(level)
+	logging.FromContext(r.Context()).InfoContext(r.Context(), "уровень журнала изменен",
+		slog.String("from", previous.String()), slog.String("to", level.String()))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.LogLevel{Level: strings.ToLower(level.String())})
+}
@@ -2,34 +2,76 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/ybotet/pz12-notes-api/internal/core"
 	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/tracing"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
 )
 
 type Handler struct {
-	NoteService service.NoteService
+	NoteService     service.NoteService
+	TemplateService service.TemplateService
 }
 
-func NewHandler(noteService service.NoteService) *Handler {
-	return &Handler{NoteService: noteService}
+func NewHandler(noteService service.NoteService, templateService service.TemplateService) *Handler {
+	return &Handler{NoteService: noteService, TemplateService: templateService}
 }
 
 // GetAllNotes godoc
 // @Summary Получить все заметки
-// @Description Возвращает список всех заметок в системе
+// @Description Возвращает список заметок; закрепленные заметки всегда идут первыми. Архивные заметки по умолчанию не возвращаются. С due_before или due_after возвращает только заметки со сроком в этом интервале, ближайшие сроки первыми
 // @Tags notes
 // @Accept json
 // @Produce json
+// @Param due_before query string false "Срок раньше указанного времени (RFC 3339 или ГГГГ-ММ-ДД)"
+// @Param due_after query string false "Срок позже указанного времени (RFC 3339 или ГГГГ-ММ-ДД)"
+// @Param archived query string false "true — только архивные, all — все заметки; по умолчанию архивные скрыты"
+// @Param pinned query bool false "Только закрепленные (true) или незакрепленные (false)"
+// @Param favorite query bool false "Только избранные (true) или остальные (false)"
 // @Success 200 {array} core.Note
+// @Failure 400 {object} core.ErrorResponse
 // @Failure 500 {object} core.ErrorResponse
 // @Router /api/v1/notes [get]
 func (h *Handler) GetAllNotes(w http.ResponseWriter, r *http.Request) {
-	notes, err := h.NoteService.GetAllNotes(r.Context())
+	var filter core.NoteFilter
+	var err error
+	if filter.DueBefore, err = parseTimeQuery(r, "due_before"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.DueAfter, err = parseTimeQuery(r, "due_after"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Pinned, err = parseBoolQuery(r, "pinned"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.Favorite, err = parseBoolQuery(r, "favorite"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Архивные заметки скрыты, если явно не запрошены
+	if r.URL.Query().Get("archived") != "all" {
+		if filter.Archived, err = parseBoolQuery(r, "archived"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if filter.Archived == nil {
+			filter.Archived = new(bool)
+		}
+	}
+
+	notes, err := h.NoteService.ListNotes(r.Context(), filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -41,32 +83,67 @@ func (h *Handler) GetAllNotes(w http.ResponseWriter, r *http.Request) {
 
 // CreateNote godoc
 // @Summary Создать новую заметку
-// @Description Создает новую заметку с предоставленными данными
+// @Description Создает новую заметку с предоставленными данными. С параметром template заголовок и содержимое берутся из шаблона с подстановкой variables; явно переданный title заменяет заголовок шаблона
 // @Tags notes
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param template query int false "ID шаблона"
 // @Param input body core.NoteCreateRequest true "Данные новой заметки"
 // @Success 201 {object} core.Note
-// @Failure 400 {object} core.ErrorResponse
+// @Failure 400 {object} core.ValidationErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
 // @Failure 500 {object} core.ErrorResponse
 // @Router /api/v1/notes [post]
 func (h *Handler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	var noteReq core.NoteCreateRequest
-	if err := json.NewDecoder(r.Body).Decode(&noteReq); err != nil {
-		http.Error(w, "Неверный ввод", http.StatusBadRequest)
+	if !decodeJSON(w, r, &noteReq) {
 		return
 	}
 
 	// Преобразовать DTO в сущность
 	note := core.Note{
-		Title:   noteReq.Title,
-		Content: noteReq.Content,
+		Title:      noteReq.Title,
+		Content:    noteReq.Content,
+		Tags:       noteReq.Tags,
+		Type:       core.NoteType(noteReq.Type),
+		DueAt:      noteReq.DueAt,
+		RemindAt:   noteReq.RemindAt,
+		Recurrence: noteReq.Recurrence,
+	}
+	for _, item := range noteReq.Items {
+		note.Items = append(note.Items, core.ChecklistItem{Text: item.Text, Checked: item.Checked})
+	}
+
+	// Заполнить заметку из шаблона
+	if templateStr := r.URL.Query().Get("template"); templateStr != "" {
+		templateID, err := strconv.ParseInt(templateStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Неверный ID шаблона", http.StatusBadRequest)
+			return
+		}
+
+		fromTemplate, err := h.TemplateService.Instantiate(r.Context(), templateID, noteReq.Title, noteReq.Variables)
+		if err != nil {
+			if strings.Contains(err.Error(), "не найден") {
+				http.Error(w, err.Error(), http.StatusNotFound)
+			} else if isValidationError(err) {
+				writeBadRequest(w, err)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		note.Title = fromTemplate.Title
+		note.Content = fromTemplate.Content
 	}
 
 	id, err := h.NoteService.CreateNote(r.Context(), note)
 	if err != nil {
-		if strings.Contains(err.Error(), "не может быть пустым") {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		if isValidationError(err) {
+			writeBadRequest(w, err)
 		} else {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
@@ -87,11 +164,13 @@ func (h *Handler) CreateNote(w http.ResponseWriter, r *http.Request) {
 
 // GetNote godoc
 // @Summary Получить заметку по ID
-// @Description Возвращает конкретную заметку по её ID
+// @Description Возвращает конкретную заметку по её ID. С format=html или Accept: text/html возвращает содержимое, отрендеренное из Markdown в безопасный HTML
 // @Tags notes
 // @Accept json
 // @Produce json
+// @Produce html
 // @Param id path int true "ID заметки"
+// @Param format query string false "Формат ответа: json (по умолчанию) или html"
 // @Success 200 {object} core.Note
 // @Failure 400 {object} core.ErrorResponse
 // @Failure 404 {object} core.ErrorResponse
@@ -104,6 +183,12 @@ func (h *Handler) GetNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Add("Vary", "Accept")
+	if wantsHTML(r) {
+		h.getNoteHTML(w, r, id)
+		return
+	}
+
 	note, err := h.NoteService.GetNote(r.Context(), id)
 	if err != nil {
 		if strings.Contains(err.Error(), "не найдена") {
@@ -118,17 +203,109 @@ func (h *Handler) GetNote(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(note)
 }
 
+// getNoteHTML отдает содержимое заметки, отрендеренное в HTML
+func (h *Handler) getNoteHTML(w http.ResponseWriter, r *http.Request, id int64) {
+	html, err := h.NoteService.RenderNoteHTML(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найдена") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else if strings.Contains(err.Error(), "неверный ID") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
+
+// wantsHTML определяет, запрошен ли HTML параметром format или заголовком Accept
+func wantsHTML(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+
+	// При равных весах выбирается тип, указанный в Accept раньше;
+	// */* без явного text/html оставляет JSON
+	accept := r.Header.Get("Accept")
+	htmlQ, htmlPos := acceptQuality(accept, "text/html")
+	if htmlQ == 0 {
+		return false
+	}
+	jsonQ, jsonPos := acceptQuality(accept, "application/json")
+	if htmlQ != jsonQ {
+		return htmlQ > jsonQ
+	}
+	return htmlPos < jsonPos
+}
+
+// acceptQuality возвращает вес типа mediaType в заголовке Accept и
+// позицию диапазона, который его задает. Точный тип важнее type/*,
+// а type/* важнее */*. Если тип не подходит ни под один диапазон,
+// вес равен 0, а позиция — числу элементов заголовка.
+func acceptQuality(accept, mediaType string) (float64, int) {
+	major, _, _ := strings.Cut(mediaType, "/")
+	items := strings.Split(accept, ",")
+	q, pos, specificity := 0.0, len(items), 0
+	for i, item := range items {
+		name, params, _ := strings.Cut(item, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		var s int
+		switch name {
+		case mediaType:
+			s = 3
+		case major + "/*":
+			s = 2
+		case "*/*":
+			s = 1
+		default:
+			continue
+		}
+		itemQ, ok := qualityValue(params)
+		if !ok || s <= specificity {
+			continue
+		}
+		q, pos, specificity = itemQ, i, s
+	}
+	return q, pos
+}
+
+// qualityValue находит q среди параметров элемента Accept;
+// без q вес равен 1. ok=false, если q не число от 0 до 1.
+func qualityValue(params string) (float64, bool) {
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || !(q >= 0 && q <= 1) {
+			return 0, false
+		}
+		return q, true
+	}
+	return 1, true
+}
+
 // UpdateNote godoc
 // @Summary Обновить существующую заметку
 // @Description Обновляет существующую заметку предоставленными данными (частичное обновление)
 // @Tags notes
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
 // @Param id path int true "ID заметки"
 // @Param input body core.NoteUpdateRequest true "Поля для обновления"
 // @Success 200 {object} core.Note
-// @Failure 400 {object} core.ErrorResponse
+// @Failure 400 {object} core.ValidationErrorResponse
 // @Failure 404 {object} core.ErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
 // @Failure 500 {object} core.ErrorResponse
 // @Router /api/v1/notes/{id} [put]
 func (h *Handler) UpdateNote(w http.ResponseWriter, r *http.Request) {
@@ -140,23 +317,30 @@ func (h *Handler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var updates core.NoteUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		http.Error(w, "Неверный ввод", http.StatusBadRequest)
+	if !decodeJSON(w, r, &updates) {
 		return
 	}
 
 	// Преобразовать DTO в структуру сервиса
 	updateReq := service.UpdateNoteRequest{
-		Title:   updates.Title,
-		Content: updates.Content,
+		Title:      updates.Title,
+		Content:    updates.Content,
+		Tags:       updates.Tags,
+		DueAt:      updates.DueAt,
+		RemindAt:   updates.RemindAt,
+		Recurrence: updates.Recurrence,
+	}
+	if updates.Type != nil {
+		noteType := core.NoteType(*updates.Type)
+		updateReq.Type = &noteType
 	}
 
 	err = h.NoteService.UpdateNote(r.Context(), id, updateReq)
 	if err != nil {
 		if strings.Contains(err.Error(), "не найдена") {
 			http.Error(w, err.Error(), http.StatusNotFound)
-		} else if strings.Contains(err.Error(), "не может быть пустым") {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else if isValidationError(err) {
+			writeBadRequest(w, err)
 		} else {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
@@ -180,6 +364,7 @@ func (h *Handler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 // @Tags notes
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
 // @Param id path int true "ID заметки"
 // @Success 204 "No Content"
 // @Failure 400 {object} core.ErrorResponse
@@ -206,3 +391,79 @@ func (h *Handler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// isValidationError определяет ошибки валидации бизнес-правил
+func isValidationError(err error) bool {
+	var fieldErrs validation.Errors
+	if errors.As(err, &fieldErrs) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, marker := range []string{
+		"не может быть пустым",
+		"не может превышать",
+		"неизвестный тип",
+		"допустимы только",
+		"превышено количество",
+		"неверный ID",
+		"неверный формат",
+		"неверное правило",
+		"требует",
+		"шаблон",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeBadRequest отвечает статусом 400; нарушения правил валидации полей
+// возвращаются списком в формате core.ValidationErrorResponse
+func writeBadRequest(w http.ResponseWriter, err error) {
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(core.ValidationErrorResponse{
+		Error:   "ошибка валидации",
+		Fields:  fieldErrs,
+		TraceID: w.Header().Get(tracing.TraceIDHeader),
+	})
+}
+
+// parseTimeQuery разбирает время из параметра запроса в формате RFC 3339
+// или дату ГГГГ-ММ-ДД (полночь UTC); пустой параметр дает nil
+func parseTimeQuery(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, value); err != nil {
+			return nil, fmt.Errorf("Неверное значение %s", name)
+		}
+	}
+	return &t, nil
+}
+
+// parseBoolQuery разбирает логический параметр запроса; пустой параметр дает nil
+func parseBoolQuery(r *http.Request, name string) (*bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("Неверное значение %s", name)
+	}
+	return &b, nil
+}
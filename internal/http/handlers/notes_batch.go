@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+// BatchNotes godoc
+// @Summary Пакетные операции над заметками
+// @Description Выполняет набор операций create/update/delete. При atomic=true применяются все операции или ни одной, иначе каждая выполняется независимо
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param input body core.NoteBatchRequest true "Операции пакета"
+// @Success 200 {object} core.NoteBatchResponse
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 422 {object} core.NoteBatchResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
+// @Failure 500 {object} core.ErrorResponse
+// @Router /api/v1/notes:batch [post]
+func (h *Handler) BatchNotes(w http.ResponseWriter, r *http.Request) {
+	var batchReq core.NoteBatchRequest
+	if !decodeJSON(w, r, &batchReq) {
+		return
+	}
+
+	// Преобразовать DTO в операции
+	ops := make([]core.BatchOp, len(batchReq.Operations))
+	for i, op := range batchReq.Operations {
+		ops[i] = core.BatchOp{
+			Type:    core.BatchOpType(strings.ToLower(op.Op)),
+			ID:      op.ID,
+			Title:   op.Title,
+			Content: op.Content,
+		}
+	}
+
+	results, err := h.NoteService.ApplyBatch(r.Context(), ops, batchReq.Atomic)
+	if err != nil {
+		if strings.Contains(err.Error(), "пакет") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := core.NoteBatchResponse{
+		Atomic:  batchReq.Atomic,
+		Applied: true,
+		Results: make([]core.NoteBatchItemResult, len(results)),
+	}
+	for i, res := range results {
+		item := core.NoteBatchItemResult{
+			Index:  res.Index,
+			Op:     string(res.Type),
+			ID:     res.ID,
+			Status: batchItemStatus(res),
+		}
+		if res.Err != nil {
+			item.Error = res.Err.Error()
+			var fieldErrs validation.Errors
+			if errors.As(res.Err, &fieldErrs) {
+				item.Fields = fieldErrs
+			}
+			resp.Applied = false
+		}
+		resp.Results[i] = item
+	}
+
+	status := http.StatusOK
+	if batchReq.Atomic && !resp.Applied {
+		// Ни одна операция не применена: пометить остальные как пропущенные
+		for i := range resp.Results {
+			if resp.Results[i].Error == "" {
+				resp.Results[i].Status = http.StatusFailedDependency
+			}
+		}
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// batchItemStatus сопоставляет результат операции с HTTP-статусом
+func batchItemStatus(res core.BatchResult) int {
+	if res.Err != nil {
+		if strings.Contains(res.Err.Error(), "не найдена") {
+			return http.StatusNotFound
+		}
+		return http.StatusBadRequest
+	}
+
+	switch res.Type {
+	case core.BatchCreate:
+		return http.StatusCreated
+	case core.BatchDelete:
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
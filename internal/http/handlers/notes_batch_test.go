@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+func TestBatchNotes(t *testing.T) {
+	const valid = `{"op":"create","title":"Новая"},
+		{"op":"update","id":1,"title":"Планы"},
+		{"op":"delete","id":2}`
+	const ops = valid + `, {"op":"update","id":999,"content":"нет такой"}`
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantApplied bool
+		wantItems   []int
+		wantIDs     []int64
+		wantTitle   string
+	}{
+		{
+			name:       "независимые операции",
+			body:       `{"atomic":false,"operations":[` + ops + `]}`,
+			wantStatus: http.StatusOK,
+			wantItems:  []int{http.StatusCreated, http.StatusOK, http.StatusNoContent, http.StatusNotFound},
+			wantIDs:    []int64{1, 3},
+			wantTitle:  "Планы",
+		},
+		{
+			name:       "атомарный пакет с ошибкой",
+			body:       `{"atomic":true,"operations":[` + ops + `]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantItems:  []int{http.StatusFailedDependency, http.StatusFailedDependency, http.StatusFailedDependency, http.StatusNotFound},
+			wantIDs:    []int64{1, 2},
+			wantTitle:  "План",
+		},
+		{
+			name:       "атомарный пакет с ошибкой валидации",
+			body:       `{"atomic":true,"operations":[{"op":"update","id":1,"title":"Планы"},{"op":"create","title":""}]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantItems:  []int{http.StatusFailedDependency, http.StatusBadRequest},
+			wantIDs:    []int64{1, 2},
+			wantTitle:  "План",
+		},
+		{
+			name:        "атомарный пакет без ошибок",
+			body:        `{"atomic":true,"operations":[` + valid + `]}`,
+			wantStatus:  http.StatusOK,
+			wantApplied: true,
+			wantItems:   []int{http.StatusCreated, http.StatusOK, http.StatusNoContent},
+			wantIDs:     []int64{1, 3},
+			wantTitle:   "Планы",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, notes := newTestHandler(t)
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/notes:batch", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.BatchNotes(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			var resp core.NoteBatchResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Applied != tt.wantApplied {
+				t.Errorf("applied = %v, want %v", resp.Applied, tt.wantApplied)
+			}
+			if len(resp.Results) != len(tt.wantItems) {
+				t.Fatalf("results = %+v, want %d", resp.Results, len(tt.wantItems))
+			}
+			for i, item := range resp.Results {
+				if item.Index != i || item.Status != tt.wantItems[i] {
+					t.Errorf("results[%d] = %+v, want status %d", i, item, tt.wantItems[i])
+				}
+			}
+
+			all, _ := notes.GetAll(context.Background())
+			var ids []int64
+			for _, n := range all {
+				ids = append(ids, n.ID)
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("заметки %v, want %v", ids, tt.wantIDs)
+			}
+			if got, err := notes.GetByID(context.Background(), 1); err != nil || got.Title != tt.wantTitle {
+				t.Errorf("заметка 1: %+v, %v, want заголовок %q", got, err, tt.wantTitle)
+			}
+		})
+	}
+}
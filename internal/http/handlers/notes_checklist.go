@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+// AddChecklistItem godoc
+// @Summary Добавить пункт списка
+// @Description Добавляет пункт в конец заметки-списка
+// @Tags checklist
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param input body core.ChecklistItemRequest true "Текст пункта"
+// @Success 201 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 409 {object} core.ErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
+// @Router /api/v1/notes/{id}/items [post]
+func (h *Handler) AddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	noteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var itemReq core.ChecklistItemRequest
+	if !decodeJSON(w, r, &itemReq) {
+		return
+	}
+
+	note, err := h.NoteService.AddChecklistItem(r.Context(), noteID, itemReq.Text)
+	writeChecklistResult(w, note, err, http.StatusCreated)
+}
+
+// ToggleChecklistItem godoc
+// @Summary Отметить пункт списка
+// @Description Переключает отметку выполнения пункта
+// @Tags checklist
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param itemId path int true "ID пункта"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 409 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/items/{itemId}/toggle [post]
+func (h *Handler) ToggleChecklistItem(w http.ResponseWriter, r *http.Request) {
+	noteID, itemID, ok := checklistItemParams(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.NoteService.ToggleChecklistItem(r.Context(), noteID, itemID)
+	writeChecklistResult(w, note, err, http.StatusOK)
+}
+
+// ReorderChecklistItems godoc
+// @Summary Изменить порядок пунктов
+// @Description Задает новый порядок пунктов; список должен содержать ID всех пунктов ровно один раз
+// @Tags checklist
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param input body core.ChecklistReorderRequest true "Новый порядок пунктов"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 409 {object} core.ErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
+// @Router /api/v1/notes/{id}/items/order [put]
+func (h *Handler) ReorderChecklistItems(w http.ResponseWriter, r *http.Request) {
+	noteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var orderReq core.ChecklistReorderRequest
+	if !decodeJSON(w, r, &orderReq) {
+		return
+	}
+
+	note, err := h.NoteService.ReorderChecklistItems(r.Context(), noteID, orderReq.ItemIDs)
+	writeChecklistResult(w, note, err, http.StatusOK)
+}
+
+// RemoveChecklistItem godoc
+// @Summary Удалить пункт списка
+// @Description Удаляет пункт из заметки-списка
+// @Tags checklist
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Param itemId path int true "ID пункта"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 409 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/items/{itemId} [delete]
+func (h *Handler) RemoveChecklistItem(w http.ResponseWriter, r *http.Request) {
+	noteID, itemID, ok := checklistItemParams(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.NoteService.RemoveChecklistItem(r.Context(), noteID, itemID)
+	writeChecklistResult(w, note, err, http.StatusOK)
+}
+
+func checklistItemParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	noteID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "itemId"), 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID пункта", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return noteID, itemID, true
+}
+
+func writeChecklistResult(w http.ResponseWriter, note *core.Note, err error, status int) {
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "не найден"):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case strings.Contains(err.Error(), "не является списком"):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case isValidationError(err), strings.Contains(err.Error(), "порядок"):
+			writeBadRequest(w, err)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(note)
+}
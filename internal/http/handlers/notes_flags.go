@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+// PinNote godoc
+// @Summary Закрепить заметку
+// @Description Закрепленные заметки всегда идут первыми в списке; закрепление возвращает заметку из архива
+// @Tags notes
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/pin [put]
+func (h *Handler) PinNote(w http.ResponseWriter, r *http.Request) {
+	h.setNoteFlag(w, r, core.FlagPinned, true)
+}
+
+// UnpinNote godoc
+// @Summary Открепить заметку
+// @Description Снимает закрепление заметки
+// @Tags notes
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/pin [delete]
+func (h *Handler) UnpinNote(w http.ResponseWriter, r *http.Request) {
+	h.setNoteFlag(w, r, core.FlagPinned, false)
+}
+
+// ArchiveNote godoc
+// @Summary Архивировать заметку
+// @Description Архивные заметки не показываются в списке по умолчанию; архивирование снимает закрепление
+// @Tags notes
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/archive [put]
+func (h *Handler) ArchiveNote(w http.ResponseWriter, r *http.Request) {
+	h.setNoteFlag(w, r, core.FlagArchived, true)
+}
+
+// UnarchiveNote godoc
+// @Summary Вернуть заметку из архива
+// @Description Возвращает заметку из архива
+// @Tags notes
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/archive [delete]
+func (h *Handler) UnarchiveNote(w http.ResponseWriter, r *http.Request) {
+	h.setNoteFlag(w, r, core.FlagArchived, false)
+}
+
+// FavoriteNote godoc
+// @Summary Добавить в избранное
+// @Description Отмечает заметку как избранную
+// @Tags notes
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/favorite [put]
+func (h *Handler) FavoriteNote(w http.ResponseWriter, r *http.Request) {
+	h.setNoteFlag(w, r, core.FlagFavorite, true)
+}
+
+// UnfavoriteNote godoc
+// @Summary Убрать из избранного
+// @Description Снимает отметку избранного
+// @Tags notes
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {object} core.Note
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/favorite [delete]
+func (h *Handler) UnfavoriteNote(w http.ResponseWriter, r *http.Request) {
+	h.setNoteFlag(w, r, core.FlagFavorite, false)
+}
+
+// setNoteFlag устанавливает или снимает состояние заметки из пути запроса
+func (h *Handler) setNoteFlag(w http.ResponseWriter, r *http.Request, flag core.NoteFlag, value bool) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.NoteService.SetNoteFlag(r.Context(), id, flag, value)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найдена") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else if strings.Contains(err.Error(), "неверный ID") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(note)
+}
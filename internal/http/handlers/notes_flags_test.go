@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+func TestNoteFlagRoutes(t *testing.T) {
+	h, _ := newTestHandler(t)
+	r := chi.NewRouter()
+	r.Get("/api/v1/notes", h.GetAllNotes)
+	r.Put("/api/v1/notes/{id}/pin", h.PinNote)
+	r.Delete("/api/v1/notes/{id}/pin", h.UnpinNote)
+	r.Put("/api/v1/notes/{id}/archive", h.ArchiveNote)
+	r.Delete("/api/v1/notes/{id}/archive", h.UnarchiveNote)
+	r.Put("/api/v1/notes/{id}/favorite", h.FavoriteNote)
+	r.Delete("/api/v1/notes/{id}/favorite", h.UnfavoriteNote)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	steps := []struct {
+		method, path string
+		wantStatus   int
+		want         [3]bool // закреплена, в архиве, избранная
+	}{
+		{http.MethodPut, "/api/v1/notes/2/pin", http.StatusOK, [3]bool{true, false, false}},
+		{http.MethodPut, "/api/v1/notes/2/favorite", http.StatusOK, [3]bool{true, false, true}},
+		{http.MethodPut, "/api/v1/notes/2/archive", http.StatusOK, [3]bool{false, true, true}},
+		{http.MethodDelete, "/api/v1/notes/2/favorite", http.StatusOK, [3]bool{false, true, false}},
+		{http.MethodDelete, "/api/v1/notes/2/archive", http.StatusOK, [3]bool{false, false, false}},
+		{http.MethodPut, "/api/v1/notes/1/pin", http.StatusOK, [3]bool{true, false, false}},
+		{http.MethodDelete, "/api/v1/notes/1/pin", http.StatusOK, [3]bool{false, false, false}},
+		{method: http.MethodPut, path: "/api/v1/notes/999/pin", wantStatus: http.StatusNotFound},
+		{method: http.MethodPut, path: "/api/v1/notes/abc/pin", wantStatus: http.StatusBadRequest},
+		{method: http.MethodPut, path: "/api/v1/notes/0/archive", wantStatus: http.StatusBadRequest},
+	}
+	for _, st := range steps {
+		w := do(st.method, st.path)
+		if w.Code != st.wantStatus {
+			t.Fatalf("%s %s = %d, want %d: %s", st.method, st.path, w.Code, st.wantStatus, w.Body)
+		}
+		if st.wantStatus != http.StatusOK {
+			continue
+		}
+		var note core.Note
+		if err := json.Unmarshal(w.Body.Bytes(), &note); err != nil {
+			t.Fatal(err)
+		}
+		if got := [3]bool{note.Pinned, note.Archived, note.Favorite}; got != st.want {
+			t.Errorf("%s %s: флаги %v, want %v", st.method, st.path, got, st.want)
+		}
+	}
+
+	// Заметка 2 в архиве и избранном, заметка 1 закреплена
+	for _, path := range []string{"/api/v1/notes/2/archive", "/api/v1/notes/2/favorite", "/api/v1/notes/1/pin"} {
+		if w := do(http.MethodPut, path); w.Code != http.StatusOK {
+			t.Fatalf("PUT %s = %d", path, w.Code)
+		}
+	}
+	queries := []struct {
+		query      string
+		wantStatus int
+		want       []int64
+	}{
+		{query: "", wantStatus: http.StatusOK, want: []int64{1}},
+		{query: "archived=all", wantStatus: http.StatusOK, want: []int64{1, 2}},
+		{query: "archived=true", wantStatus: http.StatusOK, want: []int64{2}},
+		{query: "archived=all&favorite=true", wantStatus: http.StatusOK, want: []int64{2}},
+		{query: "pinned=false", wantStatus: http.StatusOK, want: nil},
+		{query: "pinned=maybe", wantStatus: http.StatusBadRequest},
+		{query: "archived=yes", wantStatus: http.StatusBadRequest},
+	}
+	for _, q := range queries {
+		w := do(http.MethodGet, "/api/v1/notes?"+q.query)
+		if w.Code != q.wantStatus {
+			t.Errorf("GET ?%s = %d, want %d", q.query, w.Code, q.wantStatus)
+			continue
+		}
+		if q.wantStatus != http.StatusOK {
+			continue
+		}
+		var notes []core.Note
+		if err := json.Unmarshal(w.Body.Bytes(), &notes); err != nil {
+			t.Fatal(err)
+		}
+		var got []int64
+		for _, n := range notes {
+			got = append(got, n.ID)
+		}
+		if !slices.Equal(got, q.want) {
+			t.Errorf("GET ?%s = %v, want %v", q.query, got, q.want)
+		}
+	}
+}
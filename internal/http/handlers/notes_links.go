@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+// GetOutlinks godoc
+// @Summary Исходящие ссылки заметки
+// @Description Возвращает вики-ссылки ([[заголовок]] или [[#id]]) из содержимого заметки, включая битые
+// @Tags links
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {array} core.NoteLink
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/outlinks [get]
+func (h *Handler) GetOutlinks(w http.ResponseWriter, r *http.Request) {
+	h.writeLinks(w, r, h.NoteService.GetOutlinks)
+}
+
+// GetBacklinks godoc
+// @Summary Обратные ссылки на заметку
+// @Description Возвращает ссылки из других заметок, указывающие на данную
+// @Tags links
+// @Produce json
+// @Param id path int true "ID заметки"
+// @Success 200 {array} core.NoteLink
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}/backlinks [get]
+func (h *Handler) GetBacklinks(w http.ResponseWriter, r *http.Request) {
+	h.writeLinks(w, r, h.NoteService.GetBacklinks)
+}
+
+// GetBrokenLinks godoc
+// @Summary Битые ссылки
+// @Description Возвращает все вики-ссылки, цель которых не найдена
+// @Tags links
+// @Produce json
+// @Success 200 {array} core.NoteLink
+// @Failure 500 {object} core.ErrorResponse
+// @Router /api/v1/notes/links/broken [get]
+func (h *Handler) GetBrokenLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := h.NoteService.GetBrokenLinks(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+func (h *Handler) writeLinks(w http.ResponseWriter, r *http.Request, get func(ctx context.Context, id int64) ([]core.NoteLink, error)) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	links, err := get(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найдена") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else if strings.Contains(err.Error(), "неверный ID") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
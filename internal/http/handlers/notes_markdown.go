@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/markdown"
+)
+
+const (
+	// maxImportSize ограничивает размер загружаемых при импорте файлов
+	// и каждого распакованного файла ZIP-архива
+	maxImportSize = 32 << 20
+	// maxImportUnpacked ограничивает суммарный размер файлов, распакованных
+	// из всех архивов одного запроса
+	maxImportUnpacked = 128 << 20
+)
+
+// errImportTooLarge возвращается, когда распакованные файлы превышают
+// maxImportUnpacked
+var errImportTooLarge = errors.New("распакованные файлы импорта слишком большие")
+
+// GetNoteMarkdown godoc
+// @Summary Экспортировать заметку в Markdown
+// @Description Возвращает заметку в виде Markdown-файла с YAML front matter (id, title, tags, created_at, updated_at и остальные поля заметки)
+// @Tags notes
+// @Produce text/markdown
+// @Param id path int true "ID заметки"
+// @Success 200 {string} string "Markdown-файл"
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/notes/{id}.md [get]
+func (h *Handler) GetNoteMarkdown(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.NoteService.GetNote(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найдена") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	data, err := markdown.Encode(*note)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", markdown.FileName(*note)))
+	w.Write(data)
+}
+
+// ExportNotesMarkdown godoc
+// @Summary Экспортировать все заметки в Markdown
+// @Description Возвращает ZIP-архив, содержащий по одному Markdown-файлу на заметку
+// @Tags notes
+// @Produce application/zip
+// @Success 200 {file} file "ZIP-архив с Markdown-файлами"
+// @Failure 500 {object} core.ErrorResponse
+// @Router /api/v1/notes/export/markdown [get]
+func (h *Handler) ExportNotesMarkdown(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.NoteService.GetAllNotes(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Собрать архив целиком, чтобы при ошибке вернуть корректный статус
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, note := range notes {
+		data, err := markdown.Encode(note)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		modified := note.CreatedAt
+		if note.UpdatedAt != nil {
+			modified = *note.UpdatedAt
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     markdown.FileName(note),
+			Method:   zip.Deflate,
+			Modified: modified,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		f.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="notes-markdown.zip"`)
+	w.Write(buf.Bytes())
+}
+
+// ImportNotesMarkdown godoc
+// @Summary Импортировать заметки из Markdown
+// @Description Создает заметки из загруженных Markdown-файлов с YAML front matter (или ZIP-архивов с ними). Содержимое сохраняется как есть, ссылки [[#id]] между импортируемыми заметками переписываются на новые ID. Ошибки сообщаются по каждому файлу
+// @Tags notes
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file true "Markdown-файлы или ZIP-архив"
+// @Success 200 {object} core.NoteImportResponse
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 413 {object} core.ErrorResponse
+// @Router /api/v1/notes/import/markdown [post]
+func (h *Handler) ImportNotesMarkdown(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		http.Error(w, "Неверный ввод", http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		http.Error(w, "Не переданы файлы для импорта", http.StatusBadRequest)
+		return
+	}
+
+	// Файлы разбираются целиком до создания заметок, чтобы ссылки [[#id]]
+	// между ними переписывались на новые ID
+	var decoded []decodedNote
+	budget := int64(maxImportUnpacked)
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			decoded = append(decoded, decodedNote{file: fh.Filename, err: err})
+			continue
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			decoded = append(decoded, decodedNote{file: fh.Filename, err: err})
+			continue
+		}
+
+		if strings.EqualFold(path.Ext(fh.Filename), ".zip") {
+			notes, err := decodeMarkdownZip(fh.Filename, data, &budget)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			decoded = append(decoded, notes...)
+			continue
+		}
+		decoded = append(decoded, decodeMarkdownFile(fh.Filename, data))
+	}
+
+	resp, err := h.importDecoded(r, decoded)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, item := range resp.Results {
+		if item.Error != "" {
+			resp.Failed++
+		} else {
+			resp.Imported++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// decodedNote — разобранный файл импорта или ошибка его разбора
+type decodedNote struct {
+	file   string
+	note   core.Note
+	source int64
+	err    error
+}
+
+// importDecoded создает заметки из разобранных файлов одним импортом;
+// файлы с ошибками пропускаются и сообщаются в ответе
+func (h *Handler) importDecoded(r *http.Request, decoded []decodedNote) (core.NoteImportResponse, error) {
+	var notes []core.Note
+	var index []int
+	for i, d := range decoded {
+		if d.err == nil {
+			notes = append(notes, d.note)
+			index = append(index, i)
+		}
+	}
+
+	result, err := h.NoteService.ImportNotes(r.Context(), notes, service.ImportOptions{})
+	if err != nil {
+		return core.NoteImportResponse{}, err
+	}
+	for j, i := range index {
+		if result.Errors[j] != nil {
+			decoded[i].err = result.Errors[j]
+		}
+		decoded[i].note.ID = result.IDs[j]
+	}
+
+	resp := core.NoteImportResponse{Results: make([]core.NoteImportItem, len(decoded))}
+	for i, d := range decoded {
+		item := core.NoteImportItem{File: d.file, SourceID: d.source}
+		if d.err != nil {
+			item.Error = d.err.Error()
+		} else {
+			item.ID = d.note.ID
+		}
+		resp.Results[i] = item
+	}
+	return resp, nil
+}
+
+// decodeMarkdownZip разбирает все Markdown-файлы из ZIP-архива. Каждый
+// файл распаковывается не больше maxImportSize байт, а все вместе — не
+// больше остатка budget, который уменьшается на прочитанное. Заявленным
+// в архиве размерам не доверяют. При исчерпании budget возвращается
+// errImportTooLarge.
+func decodeMarkdownZip(name string, data []byte, budget *int64) ([]decodedNote, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return []decodedNote{{file: name, err: errors.New("неверный ZIP-архив")}}, nil
+	}
+
+	var results []decodedNote
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() || !strings.EqualFold(path.Ext(zf.Name), ".md") {
+			continue
+		}
+		fileName := name + "/" + zf.Name
+		rc, err := zf.Open()
+		if err != nil {
+			results = append(results, decodedNote{file: fileName, err: err})
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, min(maxImportSize, *budget)+1))
+		rc.Close()
+		*budget -= int64(len(content))
+		if *budget < 0 {
+			return nil, errImportTooLarge
+		}
+		if err == nil && len(content) > maxImportSize {
+			err = errors.New("файл слишком большой")
+		}
+		if err != nil {
+			results = append(results, decodedNote{file: fileName, err: err})
+			continue
+		}
+		results = append(results, decodeMarkdownFile(fileName, content))
+	}
+
+	return results, nil
+}
+
+// decodeMarkdownFile разбирает один Markdown-файл. Исходный ID сохраняется
+// в заметке: по нему переписываются ссылки, а новый ID назначается при импорте
+func decodeMarkdownFile(name string, data []byte) decodedNote {
+	note, err := markdown.Decode(data)
+	return decodedNote{file: name, note: note, err: err, source: note.ID}
+}
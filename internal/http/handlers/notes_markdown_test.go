@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func buildZip(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeMarkdownZip(t *testing.T) {
+	note := []byte("---\nid: 1\ntitle: Заметка\ntags: [работа]\n---\nтекст\n")
+	padded := append(append([]byte{}, note...), bytes.Repeat([]byte("x"), 1000)...)
+
+	tests := []struct {
+		name       string
+		files      map[string][]byte
+		budget     int64
+		wantNotes  int
+		wantErr    string
+		wantTooBig bool
+	}{
+		{name: "заметки", files: map[string][]byte{"a.md": note, "b.md": note, "readme.txt": padded}, budget: 1 << 20, wantNotes: 2},
+		{name: "файл больше предела", files: map[string][]byte{"big.md": make([]byte, maxImportSize+1)}, budget: maxImportUnpacked, wantNotes: 1, wantErr: "файл слишком большой"},
+		{name: "сумма файлов больше бюджета", files: map[string][]byte{"a.md": padded, "b.md": padded}, budget: 1500, wantTooBig: true},
+		{name: "один файл больше бюджета", files: map[string][]byte{"a.md": padded}, budget: 100, wantTooBig: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := tt.budget
+			decoded, err := decodeMarkdownZip("notes.zip", buildZip(t, tt.files), &budget)
+			if tt.wantTooBig {
+				if !errors.Is(err, errImportTooLarge) {
+					t.Fatalf("error = %v, want errImportTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(decoded) != tt.wantNotes {
+				t.Fatalf("разобрано %d файлов, want %d", len(decoded), tt.wantNotes)
+			}
+			for _, d := range decoded {
+				if tt.wantErr == "" && d.err != nil || tt.wantErr != "" && (d.err == nil || !strings.Contains(d.err.Error(), tt.wantErr)) {
+					t.Errorf("%s: error = %v, want %q", d.file, d.err, tt.wantErr)
+				}
+				if d.err == nil && (d.note.Title != "Заметка" || len(d.note.Tags) != 1) {
+					t.Errorf("%s: заметка = %+v", d.file, d.note)
+				}
+			}
+		})
+	}
+}
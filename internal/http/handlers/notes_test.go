@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+// newTestHandler создает обработчик на хранилищах в памяти с заметками
+// 1 «План» и 2 «Удалить»
+func newTestHandler(t *testing.T) (*Handler, *repo.NoteRepoMem) {
+	t.Helper()
+	notes := repo.NewNoteRepoMem()
+	validator := validation.New(validation.DefaultRules())
+	noteService := service.NewNoteService(notes, repo.NewLinkRepoMem(), repo.NewCommentRepoMem(), validator)
+	for _, title := range []string{"План", "Удалить"} {
+		if _, err := noteService.CreateNote(context.Background(), core.Note{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewHandler(noteService, service.NewTemplateService(repo.NewTemplateRepoMem(), validator)), notes
+}
+
+func TestWantsHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		want   bool
+	}{
+		{name: "без Accept", want: false},
+		{name: "только HTML", accept: "text/html", want: true},
+		{name: "только JSON", accept: "application/json", want: false},
+		{name: "любой тип", accept: "*/*", want: false},
+		{name: "браузер", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: true},
+		{name: "HTML раньше при равных весах", accept: "text/html, application/json", want: true},
+		{name: "JSON раньше при равных весах", accept: "application/json, text/html", want: false},
+		{name: "JSON с большим весом", accept: "text/html;q=0.5, application/json", want: false},
+		{name: "HTML с большим весом", accept: "application/json;q=0.5, text/html", want: true},
+		{name: "HTML запрещен", accept: "text/html;q=0, */*", want: false},
+		{name: "text/*", accept: "text/*, application/json;q=0.9", want: true},
+		{name: "точный тип важнее маски", accept: "text/*;q=1, text/html;q=0.1, application/json;q=0.5", want: false},
+		{name: "q среди других параметров", accept: "application/json;charset=utf-8;q=0.2, text/html", want: true},
+		{name: "неверный q пропускается", accept: "text/html;q=2, application/json;q=0.1", want: false},
+		{name: "регистр не важен", accept: "Text/HTML", want: true},
+		{name: "format=html важнее Accept", query: "format=html", accept: "application/json", want: true},
+		{name: "format=json важнее Accept", query: "format=json", accept: "text/html", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/notes/1?"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := wantsHTML(r); got != tt.want {
+				t.Errorf("wantsHTML(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+)
+
+// TemplateHandler обслуживает маршруты шаблонов заметок
+type TemplateHandler struct {
+	TemplateService service.TemplateService
+}
+
+func NewTemplateHandler(templateService service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{TemplateService: templateService}
+}
+
+// GetAllTemplates godoc
+// @Summary Получить все шаблоны
+// @Description Возвращает список всех шаблонов заметок
+// @Tags templates
+// @Produce json
+// @Success 200 {array} core.Template
+// @Failure 500 {object} core.ErrorResponse
+// @Router /api/v1/templates [get]
+func (h *TemplateHandler) GetAllTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.TemplateService.GetAllTemplates(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// CreateTemplate godoc
+// @Summary Создать шаблон
+// @Description Создает шаблон заметки. Синтаксис шаблона проверяется при сохранении; циклы и вложенные шаблоны не поддерживаются
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param input body core.TemplateCreateRequest true "Данные шаблона"
+// @Success 201 {object} core.Template
+// @Failure 400 {object} core.ValidationErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
+// @Failure 500 {object} core.ErrorResponse
+// @Router /api/v1/templates [post]
+func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var templateReq core.TemplateCreateRequest
+	if !decodeJSON(w, r, &templateReq) {
+		return
+	}
+
+	id, err := h.TemplateService.CreateTemplate(r.Context(), core.Template{
+		Name:    templateReq.Name,
+		Title:   templateReq.Title,
+		Content: templateReq.Content,
+	})
+	if err != nil {
+		if isValidationError(err) {
+			writeBadRequest(w, err)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	created, err := h.TemplateService.GetTemplate(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Ошибка при получении созданного шаблона", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetTemplate godoc
+// @Summary Получить шаблон по ID
+// @Description Возвращает шаблон заметки по его ID
+// @Tags templates
+// @Produce json
+// @Param id path int true "ID шаблона"
+// @Success 200 {object} core.Template
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/templates/{id} [get]
+func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	t, err := h.TemplateService.GetTemplate(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// UpdateTemplate godoc
+// @Summary Обновить шаблон
+// @Description Частично обновляет шаблон заметки
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param id path int true "ID шаблона"
+// @Param input body core.TemplateUpdateRequest true "Поля для обновления"
+// @Success 200 {object} core.Template
+// @Failure 400 {object} core.ValidationErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Failure 413 {object} core.DecodeErrorResponse
+// @Failure 415 {object} core.DecodeErrorResponse
+// @Failure 500 {object} core.ErrorResponse
+// @Router /api/v1/templates/{id} [put]
+func (h *TemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	var updates core.TemplateUpdateRequest
+	if !decodeJSON(w, r, &updates) {
+		return
+	}
+
+	err = h.TemplateService.UpdateTemplate(r.Context(), id, service.UpdateTemplateRequest{
+		Name:    updates.Name,
+		Title:   updates.Title,
+		Content: updates.Content,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else if isValidationError(err) {
+			writeBadRequest(w, err)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	updated, err := h.TemplateService.GetTemplate(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Ошибка при получении обновленного шаблона", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteTemplate godoc
+// @Summary Удалить шаблон
+// @Description Удаляет шаблон; заметки, созданные по нему, не затрагиваются
+// @Tags templates
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Param id path int true "ID шаблона"
+// @Success 204 "No Content"
+// @Failure 400 {object} core.ErrorResponse
+// @Failure 404 {object} core.ErrorResponse
+// @Router /api/v1/templates/{id} [delete]
+func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Неверный ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.TemplateService.DeleteTemplate(r.Context(), id); err != nil {
+		if strings.Contains(err.Error(), "не найден") {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+func TestCreateTemplateRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "верный шаблон", body: `{"name":"Встреча","title":"Встреча {{date}}","content":"{{.project}}"}`, wantStatus: http.StatusCreated},
+		{name: "без названия", body: `{"name":"","content":"x"}`, wantStatus: http.StatusBadRequest},
+		{name: "цикл", body: `{"name":"a","content":"{{range .x}}{{end}}"}`, wantStatus: http.StatusBadRequest},
+		{name: "printf", body: `{"name":"a","content":"{{printf \"%d\" 1}}"}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandler(t)
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/templates", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			NewTemplateHandler(h.TemplateService).CreateTemplate(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestCreateNoteFromTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		body        string
+		wantStatus  int
+		wantTitle   string
+		wantContent string
+	}{
+		{
+			name:        "переменные и заголовок по шаблону",
+			query:       "template=1",
+			body:        `{"variables":{"project":"API"}}`,
+			wantStatus:  http.StatusCreated,
+			wantTitle:   "Встреча API",
+			wantContent: "Проект: API, автор anonymous",
+		},
+		{
+			name:        "заголовок из запроса",
+			query:       "template=1",
+			body:        `{"title":"Планерка","variables":{"project":"API"}}`,
+			wantStatus:  http.StatusCreated,
+			wantTitle:   "Планерка",
+			wantContent: "Проект: API, автор anonymous",
+		},
+		{name: "нет шаблона", query: "template=999", body: `{}`, wantStatus: http.StatusNotFound},
+		{name: "неверный ID шаблона", query: "template=abc", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "слишком большой результат", query: "template=2", body: `{"variables":{"x":"` + strings.Repeat("x", 40<<10) + `"}}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandler(t)
+			ctx := context.Background()
+			for _, tmpl := range []core.Template{
+				{Name: "Встреча", Title: "Встреча {{.project}}", Content: "Проект: {{.project}}, автор {{user}}"},
+				{Name: "Удвоение", Content: "{{.x}}{{.x}}"},
+			} {
+				if _, err := h.TemplateService.CreateTemplate(ctx, tmpl); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/notes?"+tt.query, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.CreateNote(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			var note core.Note
+			if err := json.Unmarshal(w.Body.Bytes(), &note); err != nil {
+				t.Fatal(err)
+			}
+			if note.Title != tt.wantTitle || note.Content != tt.wantContent {
+				t.Errorf("заметка = %q / %q, want %q / %q", note.Title, note.Content, tt.wantTitle, tt.wantContent)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ybotet/pz12-notes-api/internal/health"
+)
+
+// livez отвечает, пока процесс способен обрабатывать запросы;
+// зависимости не проверяются, чтобы их сбой не приводил к перезапуску
+func livez(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// readyz отвечает 200, если все проверки прошли, и 503, если какая-то
+// провалилась или сервер останавливается. Результаты отдельных проверок
+// видны только вызывающим с токеном администратора.
+func readyz(registry *health.Registry, token func() string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := registry.Check(r.Context())
+
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+
+		if authorized(r, token()) {
+			writeHealth(w, status, report)
+		} else {
+			writeHealth(w, status, map[string]string{"status": report.Status})
+		}
+	}
+}
+
+func writeHealth(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
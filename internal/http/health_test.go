@@ -0,0 +1,74 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/health"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	failing := health.CheckerFunc(func(context.Context) error { return errors.New("нет соединения") })
+
+	tests := []struct {
+		name       string
+		path       string
+		check      health.Checker
+		shutdown   bool
+		token      string
+		wantStatus int
+		wantBody   string
+		wantChecks bool
+	}{
+		{name: "livez", path: "/livez", wantStatus: http.StatusOK, wantBody: health.StatusOK},
+		{name: "livez при сбое проверки", path: "/livez", check: failing, wantStatus: http.StatusOK, wantBody: health.StatusOK},
+		{name: "readyz", path: "/readyz", wantStatus: http.StatusOK, wantBody: health.StatusOK},
+		{name: "readyz при сбое проверки", path: "/readyz", check: failing, wantStatus: http.StatusServiceUnavailable, wantBody: health.StatusFail},
+		{name: "readyz при остановке", path: "/readyz", shutdown: true, wantStatus: http.StatusServiceUnavailable, wantBody: health.StatusShuttingDown},
+		{name: "health совпадает с readyz", path: "/health", check: failing, wantStatus: http.StatusServiceUnavailable, wantBody: health.StatusFail},
+		{name: "отчет с токеном", path: "/readyz", check: failing, token: "secret", wantStatus: http.StatusServiceUnavailable, wantBody: health.StatusFail, wantChecks: true},
+		{name: "неверный токен", path: "/readyz", check: failing, token: "wrong", wantStatus: http.StatusServiceUnavailable, wantBody: health.StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := health.NewRegistry(time.Second, 0)
+			if tt.check != nil {
+				registry.Register("db", tt.check)
+			}
+			if tt.shutdown {
+				registry.Shutdown()
+			}
+			r := newTestRouter(t, WithHealth(registry), WithAdminToken(func() string { return "secret" }))
+
+			var header []string
+			if tt.token != "" {
+				header = []string{"Authorization", "Bearer " + tt.token}
+			}
+			rec := serve(r, http.MethodGet, tt.path, "", header...)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+			var body struct {
+				Status string
+				Checks []health.Result
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("ответ %q: %v", rec.Body.String(), err)
+			}
+			if body.Status != tt.wantBody {
+				t.Errorf("status в ответе = %q, want %q", body.Status, tt.wantBody)
+			}
+			if tt.wantChecks != (len(body.Checks) > 0) {
+				t.Errorf("checks = %+v, подробный отчет только с токеном", body.Checks)
+			}
+		})
+	}
+}
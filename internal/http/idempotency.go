@@ -0,0 +1,363 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// IdempotencyHeader — заголовок, которым клиент помечает повторяемый запрос
+const IdempotencyHeader = "Idempotency-Key"
+
+var (
+	// ErrIdempotencyInProgress возвращается, пока первый запрос с ключом не завершён
+	ErrIdempotencyInProgress = errors.New("запрос с этим ключом уже выполняется")
+	// ErrIdempotencyMismatch возвращается, если ключ повторно использован с другим запросом
+	ErrIdempotencyMismatch = errors.New("ключ идемпотентности использован с другим запросом")
+)
+
+// StoredResponse — сохранённый ответ на первый запрос с ключом
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore определяет хранилище ключей идемпотентности
+type IdempotencyStore interface {
+	// Begin резервирует ключ за запросом с отпечатком fingerprint.
+	// Если запрос уже завершён, возвращает сохранённый ответ.
+	Begin(ctx context.Context, key, fingerprint string) (*StoredResponse, error)
+	// Complete сохраняет ответ для ключа
+	Complete(ctx context.Context, key string, resp StoredResponse) error
+	// Release снимает резервирование без сохранения ответа
+	Release(ctx context.Context, key string) error
+}
+
+type idempotencyEntry struct {
+	fingerprint string
+	resp        *StoredResponse
+	expires     time.Time
+}
+
+// IdempotencyStoreMem реализует IdempotencyStore в памяти
+type IdempotencyStoreMem struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	ttl       time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewIdempotencyStoreMem(ttl time.Duration) *IdempotencyStoreMem {
+	return &IdempotencyStoreMem{
+		entries:   make(map[string]*idempotencyEntry),
+		ttl:       ttl,
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// idempotencySweepInterval — как часто из хранилища удаляются истекшие ключи
+const idempotencySweepInterval = time.Minute
+
+func (s *IdempotencyStoreMem) Begin(ctx context.Context, key, fingerprint string) (*StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	entry, exists := s.entries[key]
+	if !exists || now.After(entry.expires) {
+		s.entries[key] = &idempotencyEntry{
+			fingerprint: fingerprint,
+			expires:     now.Add(s.ttl),
+		}
+		return nil, nil
+	}
+
+	if entry.fingerprint != fingerprint {
+		return nil, ErrIdempotencyMismatch
+	}
+	if entry.resp == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+
+	return entry.resp, nil
+}
+
+func (s *IdempotencyStoreMem) Complete(ctx context.Context, key string, resp StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[key]
+	if !exists {
+		return errors.New("ключ идемпотентности не найден")
+	}
+
+	entry.resp = &resp
+	entry.expires = s.now().Add(s.ttl)
+	return nil
+}
+
+func (s *IdempotencyStoreMem) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep удаляет истекшие ключи не чаще раза в idempotencySweepInterval,
+// чтобы Begin не просматривал все хранилище на каждом запросе. Истекший,
+// но еще не удаленный ключ Begin считает свободным.
+func (s *IdempotencyStoreMem) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < idempotencySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// idempotencyMemoryBody — до какого размера тело запроса с ключом
+// хранится в памяти; большие тела (вложения, импорт) пишутся во
+// временный файл
+const idempotencyMemoryBody = 1 << 20
+
+// Idempotency возвращает middleware, которое для изменяющих запросов
+// с заголовком Idempotency-Key выполняет обработчик только один раз,
+// а повторам отдаёт сохранённый ответ. Ключи действуют в пределах
+// клиента (см. clientKey). Параллельный повтор ждёт завершения первого
+// запроса не дольше wait, затем получает 409. Тело запроса с ключом
+// больше maxBody байт отклоняется с 413.
+func Idempotency(store IdempotencyStore, wait time.Duration, maxBody int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyHeader)
+			if key == "" || !isMutatingMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > 255 {
+				http.Error(w, "Слишком длинный ключ идемпотентности", http.StatusBadRequest)
+				return
+			}
+
+			// Тело читается заранее, чтобы по отпечатку отличить повтор от
+			// другого запроса с тем же ключом, и передается обработчику копией
+			body, bodyHash, err := bufferBody(r.Body, maxBody)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				switch {
+				case errors.As(err, &maxBytesErr), errors.Is(err, errBodyTooLarge):
+					http.Error(w, "Тело запроса слишком большое", http.StatusRequestEntityTooLarge)
+				case errors.Is(err, errBodyRead):
+					http.Error(w, "Неверный ввод", http.StatusBadRequest)
+				default:
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+			defer body.Close()
+			r.Body = body
+
+			key = clientKey(r) + " " + key
+			fingerprint := requestFingerprint(r, bodyHash)
+			stored, err := beginIdempotent(r.Context(), store, key, fingerprint, wait)
+			switch {
+			case errors.Is(err, ErrIdempotencyMismatch):
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			case errors.Is(err, ErrIdempotencyInProgress):
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if stored != nil {
+				replayResponse(w, stored)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				// Ошибки сервера не сохраняются, чтобы клиент мог повторить запрос
+				if p := recover(); p != nil {
+					store.Release(context.Background(), key)
+					panic(p)
+				}
+				if rec.status >= http.StatusInternalServerError {
+					store.Release(context.Background(), key)
+					return
+				}
+				store.Complete(context.Background(), key, StoredResponse{
+					Status: rec.status,
+					Header: rec.Header().Clone(),
+					Body:   rec.body.Bytes(),
+				})
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// beginIdempotent резервирует ключ, ожидая завершения параллельного запроса
+func beginIdempotent(ctx context.Context, store IdempotencyStore, key, fingerprint string, wait time.Duration) (*StoredResponse, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		stored, err := store.Begin(ctx, key, fingerprint)
+		if !errors.Is(err, ErrIdempotencyInProgress) || time.Now().After(deadline) {
+			return stored, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// replayResponse повторяет сохраненный ответ. Заголовки, уже заданные
+// внешними middleware для текущего запроса (CORS, trace ID, лимиты),
+// не заменяются сохраненными.
+func replayResponse(w http.ResponseWriter, stored *StoredResponse) {
+	for name, values := range stored.Header {
+		if _, set := w.Header()[name]; !set {
+			w.Header()[name] = values
+		}
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+func requestFingerprint(r *http.Request, bodyHash []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method)
+	io.WriteString(h, " ")
+	io.WriteString(h, r.URL.RequestURI())
+	io.WriteString(h, "\n")
+	h.Write(bodyHash)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var (
+	errBodyTooLarge = errors.New("тело запроса слишком большое")
+	errBodyRead     = errors.New("ошибка чтения тела запроса")
+)
+
+// bufferBody читает тело не больше limit байт, считая его SHA-256, и
+// возвращает копию для обработчика: в памяти, если тело не больше
+// idempotencyMemoryBody, иначе во временном файле, который удаляется
+// при закрытии копии
+func bufferBody(body io.Reader, limit int64) (io.ReadCloser, []byte, error) {
+	h := sha256.New()
+	body = io.TeeReader(bodyReader{io.LimitReader(body, limit+1)}, h)
+
+	var head bytes.Buffer
+	n, err := io.CopyN(&head, body, idempotencyMemoryBody+1)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	if n > limit {
+		return nil, nil, errBodyTooLarge
+	}
+	if n <= idempotencyMemoryBody {
+		return io.NopCloser(&head), h.Sum(nil), nil
+	}
+
+	f, err := os.CreateTemp("", "idempotency-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	tmp := &tempFileBody{File: f}
+	size, err := io.Copy(f, io.MultiReader(&head, body))
+	if err == nil && size > limit {
+		err = errBodyTooLarge
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		return nil, nil, err
+	}
+	return tmp, h.Sum(nil), nil
+}
+
+// bodyReader помечает ошибки чтения тела, чтобы отличить их
+// от ошибок записи временного файла
+type bodyReader struct {
+	r io.Reader
+}
+
+func (b bodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if err != nil && err != io.EOF && !errors.As(err, &maxBytesErr) {
+		err = fmt.Errorf("%w: %w", errBodyRead, err)
+	}
+	return n, err
+}
+
+// tempFileBody — тело запроса во временном файле
+type tempFileBody struct {
+	*os.File
+}
+
+func (b *tempFileBody) Close() error {
+	err := b.File.Close()
+	os.Remove(b.Name())
+	return err
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// responseRecorder пишет ответ клиенту и одновременно сохраняет его копию
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.status = status
+	rec.wroteHeader = true
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
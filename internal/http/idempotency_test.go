@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyStoreMem(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewIdempotencyStoreMem(time.Hour)
+	s.now = func() time.Time { return now }
+	s.lastSweep = now
+
+	if stored, err := s.Begin(ctx, "k", "a"); stored != nil || err != nil {
+		t.Fatalf("первый Begin: %v, %v", stored, err)
+	}
+	if _, err := s.Begin(ctx, "k", "a"); !errors.Is(err, ErrIdempotencyInProgress) {
+		t.Fatalf("Begin до Complete: %v", err)
+	}
+	if _, err := s.Begin(ctx, "k", "b"); !errors.Is(err, ErrIdempotencyMismatch) {
+		t.Fatalf("Begin с другим отпечатком: %v", err)
+	}
+
+	if err := s.Complete(ctx, "k", StoredResponse{Status: http.StatusCreated, Body: []byte("ok")}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := s.Begin(ctx, "k", "a"); err != nil || stored == nil || stored.Status != http.StatusCreated {
+		t.Fatalf("Begin после Complete: %+v, %v", stored, err)
+	}
+
+	if err := s.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := s.Begin(ctx, "k", "b"); stored != nil || err != nil {
+		t.Fatalf("Begin после Release: %v, %v", stored, err)
+	}
+
+	// Истекший ключ свободен еще до очистки, а очистка удаляет его из памяти
+	s.Complete(ctx, "k", StoredResponse{Status: http.StatusOK})
+	now = now.Add(2 * time.Hour)
+	if _, ok := s.entries["k"]; !ok {
+		t.Fatal("ключ удален раньше очистки")
+	}
+	s.Begin(ctx, "other", "x")
+	if _, ok := s.entries["k"]; ok {
+		t.Error("истекший ключ не удален очисткой")
+	}
+	if stored, err := s.Begin(ctx, "k", "c"); stored != nil || err != nil {
+		t.Fatalf("Begin истекшего ключа: %v, %v", stored, err)
+	}
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	users := func() map[string]string { return map[string]string{"alice": "ta", "bob": "tb"} }
+
+	type request struct {
+		method, path, body string
+		token, key, remote string
+	}
+	tests := []struct {
+		name       string
+		requests   []request
+		wantStatus []int
+		wantCalls  int
+		wantReplay []bool
+	}{
+		{
+			name: "повтор получает сохраненный ответ",
+			requests: []request{
+				{method: "POST", path: "/notes", body: `{"t":1}`, token: "ta", key: "k1"},
+				{method: "POST", path: "/notes", body: `{"t":1}`, token: "ta", key: "k1"},
+			},
+			wantStatus: []int{201, 201},
+			wantCalls:  1,
+			wantReplay: []bool{false, true},
+		},
+		{
+			name: "другое тело с тем же ключом",
+			requests: []request{
+				{method: "POST", path: "/notes", body: `{"t":1}`, token: "ta", key: "k1"},
+				{method: "POST", path: "/notes", body: `{"t":2}`, token: "ta", key: "k1"},
+			},
+			wantStatus: []int{201, 422},
+			wantCalls:  1,
+			wantReplay: []bool{false, false},
+		},
+		{
+			name: "ключи разных пользователей независимы",
+			requests: []request{
+				{method: "POST", path: "/notes", body: `{"t":1}`, token: "ta", key: "k1"},
+				{method: "POST", path: "/notes", body: `{"t":1}`, token: "tb", key: "k1"},
+			},
+			wantStatus: []int{201, 201},
+			wantCalls:  2,
+			wantReplay: []bool{false, false},
+		},
+		{
+			name: "анонимные клиенты различаются по адресу",
+			requests: []request{
+				{method: "POST", path: "/notes", body: `{}`, key: "k1", remote: "1.1.1.1:1"},
+				{method: "POST", path: "/notes", body: `{}`, key: "k1", remote: "2.2.2.2:1"},
+				{method: "POST", path: "/notes", body: `{}`, key: "k1", remote: "1.1.1.1:2"},
+			},
+			wantStatus: []int{201, 201, 201},
+			wantCalls:  2,
+			wantReplay: []bool{false, false, true},
+		},
+		{
+			name: "ошибка сервера не сохраняется",
+			requests: []request{
+				{method: "POST", path: "/fail", body: `{}`, token: "ta", key: "k1"},
+				{method: "POST", path: "/fail", body: `{}`, token: "ta", key: "k1"},
+			},
+			wantStatus: []int{500, 500},
+			wantCalls:  2,
+			wantReplay: []bool{false, false},
+		},
+		{
+			name: "без ключа и для GET не действует",
+			requests: []request{
+				{method: "POST", path: "/notes", body: `{}`, token: "ta"},
+				{method: "POST", path: "/notes", body: `{}`, token: "ta"},
+				{method: "GET", path: "/notes", token: "ta", key: "k1"},
+				{method: "GET", path: "/notes", token: "ta", key: "k1"},
+			},
+			wantStatus: []int{201, 201, 200, 200},
+			wantCalls:  4,
+			wantReplay: []bool{false, false, false, false},
+		},
+		{
+			name: "большое тело передается обработчику целиком",
+			requests: []request{
+				{method: "POST", path: "/notes", body: strings.Repeat("x", idempotencyMemoryBody+10), token: "ta", key: "k1"},
+				{method: "POST", path: "/notes", body: strings.Repeat("x", idempotencyMemoryBody+10), token: "ta", key: "k1"},
+			},
+			wantStatus: []int{201, 201},
+			wantCalls:  1,
+			wantReplay: []bool{false, true},
+		},
+		{
+			name: "тело больше предела",
+			requests: []request{
+				{method: "POST", path: "/notes", body: strings.Repeat("x", 2*idempotencyMemoryBody+1), token: "ta", key: "k1"},
+				{method: "POST", path: "/notes", body: strings.Repeat("x", 2*idempotencyMemoryBody+1), token: "ta"},
+			},
+			wantStatus: []int{413, 201},
+			wantCalls:  1,
+			wantReplay: []bool{false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("чтение тела: %v", err)
+				}
+				switch {
+				case r.URL.Path == "/fail":
+					w.WriteHeader(http.StatusInternalServerError)
+				case r.Method == http.MethodGet:
+					w.WriteHeader(http.StatusOK)
+				default:
+					w.WriteHeader(http.StatusCreated)
+				}
+				fmt.Fprintf(w, "%d", len(body))
+			})
+			h := Identify(users, false)(Idempotency(NewIdempotencyStoreMem(time.Hour), 0, 2*idempotencyMemoryBody)(next))
+
+			for i, req := range tt.requests {
+				r := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body))
+				if req.token != "" {
+					r.Header.Set("Authorization", "Bearer "+req.token)
+				}
+				if req.key != "" {
+					r.Header.Set(IdempotencyHeader, req.key)
+				}
+				if req.remote != "" {
+					r.RemoteAddr = req.remote
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, r)
+
+				if rec.Code != tt.wantStatus[i] {
+					t.Errorf("запрос %d: статус %d, want %d", i, rec.Code, tt.wantStatus[i])
+				}
+				if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.wantReplay[i] {
+					t.Errorf("запрос %d: повтор = %v, want %v", i, replayed, tt.wantReplay[i])
+				}
+				if rec.Code < 400 && rec.Body.String() != fmt.Sprint(len(req.body)) {
+					t.Errorf("запрос %d: обработчик получил %s байт, want %d", i, rec.Body.String(), len(req.body))
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("обработчик вызван %d раз, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+)
+
+// RequestLogger помещает в контекст запроса логгер с request_id
+// и пользователем и по завершении записывает одну строку о запросе:
+// метод, путь, шаблон маршрута, статус, размер ответа и длительность.
+// Должен выполняться после RequestID и Identify.
+func RequestLogger(base *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			l := base.With(
+				slog.String("request_id", middleware.GetReqID(ctx)),
+				slog.String("user", core.UserFromContext(ctx)),
+			)
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(logging.WithLogger(ctx, l)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := ""
+			if rctx := chi.RouteContext(ctx); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			l.LogAttrs(ctx, level, "запрос",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", route),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote", r.RemoteAddr),
+			)
+		})
+	}
+}
+
+// Recoverer перехватывает панику обработчика, записывает ее в журнал
+// со стеком и отвечает 500
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			logging.FromContext(r.Context()).ErrorContext(r.Context(), "паника при обработке запроса",
+				slog.Any("panic", rec),
+				slog.String("stack", string(debug.Stack())),
+			)
+			if r.Header.Get("Connection") != "Upgrade" {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
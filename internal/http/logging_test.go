@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+)
+
+// logLines разбирает журнал в формате JSON построчно
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("запись %q: %v", line, err)
+		}
+		lines = append(lines, v)
+	}
+	return lines
+}
+
+func TestRequestLogger(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		header     []string
+		wantRoute  string
+		wantStatus float64
+		wantUser   string
+	}{
+		{name: "маршрут с параметром", method: http.MethodGet, path: "/api/v1/notes/1", wantRoute: "/api/v1/notes/{id}", wantStatus: 200, wantUser: "anonymous"},
+		{name: "пользователь по токену", method: http.MethodGet, path: "/api/v1/notes/1", header: []string{"Authorization", "Bearer a-token"}, wantRoute: "/api/v1/notes/{id}", wantStatus: 200, wantUser: "alice"},
+		{name: "заметка не найдена", method: http.MethodGet, path: "/api/v1/notes/999", wantRoute: "/api/v1/notes/{id}", wantStatus: 404, wantUser: "anonymous"},
+		{name: "неизвестный маршрут", method: http.MethodGet, path: "/nope", wantRoute: "", wantStatus: 404, wantUser: "anonymous"},
+		{name: "создание", method: http.MethodPost, path: "/api/v1/notes", body: `{"title":"Новая"}`, wantRoute: "/api/v1/notes", wantStatus: 201, wantUser: "anonymous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			users := func() map[string]string { return map[string]string{"alice": "a-token"} }
+			r := newTestRouter(t, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))), WithUsers(users, false))
+			serve(r, http.MethodPost, "/api/v1/notes", `{"title":"План"}`)
+			buf.Reset()
+
+			header := append([]string{middleware.RequestIDHeader, "req-42"}, tt.header...)
+			rec := serve(r, tt.method, tt.path, tt.body, header...)
+
+			lines := logLines(t, &buf)
+			if len(lines) != 1 {
+				t.Fatalf("записей %d, want 1: %q", len(lines), buf.String())
+			}
+			got := lines[0]
+			want := map[string]any{
+				"level":      "INFO",
+				"msg":        "запрос",
+				"method":     tt.method,
+				"path":       tt.path,
+				"route":      tt.wantRoute,
+				"status":     tt.wantStatus,
+				"bytes":      float64(rec.Body.Len()),
+				"request_id": "req-42",
+				"user":       tt.wantUser,
+				"remote":     "10.0.0.1:5555",
+			}
+			for k, v := range want {
+				if got[k] != v {
+					t.Errorf("%s = %v, want %v", k, got[k], v)
+				}
+			}
+			if _, ok := got["duration"].(float64); !ok {
+				t.Errorf("duration = %v", got["duration"])
+			}
+		})
+	}
+}
+
+func TestRecovererLogsPanic(t *testing.T) {
+	var buf bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, Identify(func() map[string]string { return nil }, false), RequestLogger(slog.New(slog.NewJSONHandler(&buf, nil))), Recoverer)
+	r.Get("/log", func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).InfoContext(r.Context(), "из обработчика")
+	})
+	r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("бум")
+	})
+
+	// Логгер из контекста уже содержит request_id и пользователя
+	serve(r, http.MethodGet, "/log", "", middleware.RequestIDHeader, "req-1")
+	lines := logLines(t, &buf)
+	if len(lines) != 2 || lines[0]["msg"] != "из обработчика" || lines[0]["request_id"] != "req-1" || lines[0]["user"] != "anonymous" {
+		t.Errorf("журнал обработчика = %v", lines)
+	}
+
+	buf.Reset()
+	rec := serve(r, http.MethodGet, "/panic", "", middleware.RequestIDHeader, "req-2")
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	lines = logLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("записей %d, want 2: %q", len(lines), buf.String())
+	}
+	panicLine, requestLine := lines[0], lines[1]
+	if panicLine["level"] != "ERROR" || panicLine["panic"] != "бум" || panicLine["request_id"] != "req-2" {
+		t.Errorf("запись о панике = %v", panicLine)
+	}
+	if stack, _ := panicLine["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("stack = %q", stack)
+	}
+	if requestLine["level"] != "ERROR" || requestLine["status"] != float64(500) {
+		t.Errorf("запись о запросе = %v", requestLine)
+	}
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+	"github.com/ybotet/pz12-notes-api/internal/ratelimit"
+)
+
+// RateLimit ограничивает частоту запросов к маршрутам /api/ по лимитам
+// rules. Корзины ведутся отдельно для каждого клиента (см. clientKey)
+// и правила; маршрут определяется по шаблону mux до вызова обработчиков.
+// Ответ содержит заголовки RateLimit-*, отклоненный запрос получает 429
+// с Retry-After. Если хранилище недоступно, запрос пропускается.
+func RateLimit(mux *chi.Mux, store ratelimit.Store, rules func() *ratelimit.Rules) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern := findRoute(mux, r)
+			if !strings.HasPrefix(pattern, "/api/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			name, limit := rules().Match(r.Method, pattern)
+			if limit.Unlimited() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := store.Take(r.Context(), name+"|"+clientKey(r), limit)
+			if err != nil {
+				logging.FromContext(r.Context()).WarnContext(r.Context(), "ограничение частоты не проверено", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", limit.Policy())
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			if !res.Allowed {
+				h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+				http.Error(w, "Слишком много запросов, повторите позже", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// findRoute возвращает шаблон маршрута запроса в том же виде,
+// что chi.Context.RoutePattern после маршрутизации
+func findRoute(mux *chi.Mux, r *http.Request) string {
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	pattern := mux.Find(chi.NewRouteContext(), r.Method, path)
+	if pattern != "/" {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/ratelimit"
+)
+
+func TestClientKeyIgnoresUnverifiedIdentity(t *testing.T) {
+	users := func() map[string]string { return map[string]string{"alice": "secret"} }
+
+	tests := []struct {
+		name        string
+		header      http.Header
+		trustHeader bool
+		want        string
+	}{
+		{name: "без идентификации", want: "ip:10.0.0.1"},
+		{name: "известный токен", header: http.Header{"Authorization": {"Bearer secret"}}, want: "user:alice"},
+		{name: "случайный токен", header: http.Header{"Authorization": {"Bearer random-1"}}, want: "ip:10.0.0.1"},
+		{name: "X-User-ID без доверия", header: http.Header{"X-User-Id": {"mallory"}}, want: "ip:10.0.0.1"},
+		{name: "X-User-ID за шлюзом", header: http.Header{"X-User-Id": {"bob"}}, trustHeader: true, want: "user:bob"},
+		{name: "токен важнее заголовка", header: http.Header{"Authorization": {"Bearer secret"}, "X-User-Id": {"bob"}}, trustHeader: true, want: "user:alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := Identify(users, tt.trustHeader)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientKey(r)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/notes", nil)
+			r.RemoteAddr = "10.0.0.1:5555"
+			for k, v := range tt.header {
+				r.Header[k] = v
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("clientKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	rules, err := ratelimit.ParseRules("100/m", "POST /api/v1/notes=2/m, /api/v1/admin/*=1/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := chi.NewRouter()
+	r.Use(RateLimit(r, ratelimit.NewMemoryStore(), func() *ratelimit.Rules { return rules }))
+	r.Post("/api/v1/notes", ok)
+	r.Get("/api/v1/notes", ok)
+	r.Route("/api/v1/admin", func(r chi.Router) {
+		r.Get("/export", ok)
+		r.Post("/import", ok)
+	})
+	r.Get("/livez", ok)
+
+	do := func(method, path, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := do("POST", "/api/v1/notes", "1.1.1.1:1"); rec.Code != http.StatusOK {
+			t.Fatalf("POST %d: %d", i, rec.Code)
+		}
+	}
+	rec := do("POST", "/api/v1/notes", "1.1.1.1:2")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("третий POST: %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" || rec.Header().Get("RateLimit-Remaining") != "0" || rec.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Errorf("заголовки 429: %v", rec.Header())
+	}
+
+	// Другой маршрут — корзина по умолчанию, другой адрес — своя корзина
+	if rec := do("GET", "/api/v1/notes", "1.1.1.1:3"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "100" {
+		t.Errorf("GET: %d %v", rec.Code, rec.Header())
+	}
+	if rec := do("POST", "/api/v1/notes", "2.2.2.2:1"); rec.Code != http.StatusOK {
+		t.Errorf("POST с другого адреса: %d", rec.Code)
+	}
+
+	// Маршруты под /api/v1/admin/* делят одну корзину
+	if rec := do("GET", "/api/v1/admin/export", "1.1.1.1:4"); rec.Code != http.StatusOK {
+		t.Fatalf("export: %d", rec.Code)
+	}
+	if rec := do("POST", "/api/v1/admin/import", "1.1.1.1:5"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("import после export: %d, want 429", rec.Code)
+	}
+
+	// Маршруты вне /api/ не ограничиваются
+	for i := 0; i < 5; i++ {
+		if rec := do("GET", "/livez", "1.1.1.1:6"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("livez: %d %v", rec.Code, rec.Header())
+		}
+	}
+}
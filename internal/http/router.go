@@ -1,36 +1,305 @@
 package http
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/ybotet/pz12-notes-api/internal/health"
 	"github.com/ybotet/pz12-notes-api/internal/http/handlers"
+	"github.com/ybotet/pz12-notes-api/internal/metrics"
+	"github.com/ybotet/pz12-notes-api/internal/ratelimit"
+	"github.com/ybotet/pz12-notes-api/internal/tracing"
 )
 
-func NewRouter(h *handlers.Handler) *chi.Mux {
+// Handlers — обработчики, из которых собираются маршруты API
+type Handlers struct {
+	Notes       *handlers.Handler
+	Admin       *handlers.AdminHandler
+	Attachments *handlers.AttachmentHandler
+	Templates   *handlers.TemplateHandler
+	Comments    *handlers.CommentHandler
+	LogLevel    *handlers.LogLevelHandler
+}
+
+// routerOptions — настройки роутера, задаваемые опциями NewRouter
+type routerOptions struct {
+	middlewares      []func(http.Handler) http.Handler
+	idempotencyStore IdempotencyStore
+	idempotencyWait  time.Duration
+	idempotencyBody  int64
+	adminToken       func() string
+	userTokens       func() map[string]string
+	trustUserHeader  bool
+	docsDir          string
+	health           *health.Registry
+	metrics          *metrics.Metrics
+	tracing          bool
+	logger           *slog.Logger
+	rateLimitStore   ratelimit.Store
+	rateLimits       func() *ratelimit.Rules
+	maxJSONBody      int64
+	cors             *CORSOptions
+	compression      bool
+	compressMinSize  int
+}
+
+// Option настраивает роутер
+type Option func(*routerOptions)
+
+// WithMiddleware добавляет middleware, которые выполняются после
+// стандартных (RequestID, пользователь, журнал, восстановление после паники)
+func WithMiddleware(mw ...func(http.Handler) http.Handler) Option {
+	return func(o *routerOptions) {
+		o.middlewares = append(o.middlewares, mw...)
+	}
+}
+
+// WithIdempotency задает хранилище ключей идемпотентности, время
+// ожидания параллельного запроса с тем же ключом и наибольший размер
+// тела запроса с ключом
+func WithIdempotency(store IdempotencyStore, wait time.Duration, maxBody int64) Option {
+	return func(o *routerOptions) {
+		o.idempotencyStore = store
+		o.idempotencyWait = wait
+		o.idempotencyBody = maxBody
+	}
+}
+
+// WithAdminToken подключает административные маршруты под защитой токена;
+// без этой опции они не подключаются
+func WithAdminToken(token func() string) Option {
+	return func(o *routerOptions) {
+		o.adminToken = token
+	}
+}
+
+// WithUsers задает токены пользователей (пользователь → токен), по которым
+// запросы аутентифицируются; trustHeader разрешает брать пользователя из
+// X-User-ID, если сервер работает за шлюзом, выставляющим этот заголовок
+func WithUsers(tokens func() map[string]string, trustHeader bool) Option {
+	return func(o *routerOptions) {
+		o.userTokens = tokens
+		o.trustUserHeader = trustHeader
+	}
+}
+
+// WithHealth задает реестр проверок для /readyz
+func WithHealth(registry *health.Registry) Option {
+	return func(o *routerOptions) {
+		o.health = registry
+	}
+}
+
+// WithMetrics подключает сбор метрик HTTP-запросов и маршрут /metrics
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *routerOptions) {
+		o.metrics = m
+	}
+}
+
+// WithTracing открывает спан OpenTelemetry на каждый запрос
+func WithTracing() Option {
+	return func(o *routerOptions) {
+		o.tracing = true
+	}
+}
+
+// WithLogger задает логгер журнала запросов
+func WithLogger(l *slog.Logger) Option {
+	return func(o *routerOptions) {
+		o.logger = l
+	}
+}
+
+// WithRateLimit ограничивает частоту запросов к API; лимиты
+// запрашиваются при каждом запросе, чтобы их можно было сменить
+// без перезапуска
+func WithRateLimit(store ratelimit.Store, rules func() *ratelimit.Rules) Option {
+	return func(o *routerOptions) {
+		o.rateLimitStore = store
+		o.rateLimits = rules
+	}
+}
+
+// WithMaxJSONBody задает максимальный размер JSON-тела запроса в байтах
+func WithMaxJSONBody(limit int64) Option {
+	return func(o *routerOptions) {
+		o.maxJSONBody = limit
+	}
+}
+
+// WithCORS разрешает запросы к API из браузера с других источников
+func WithCORS(opts CORSOptions) Option {
+	return func(o *routerOptions) {
+		o.cors = &opts
+	}
+}
+
+// WithCompression сжимает ответы от minSize байт и распаковывает
+// сжатые тела запросов
+func WithCompression(minSize int) Option {
+	return func(o *routerOptions) {
+		o.compression = true
+		o.compressMinSize = minSize
+	}
+}
+
+// WithDocs подключает Swagger UI и ReDoc из каталога dir
+func WithDocs(dir string) Option {
+	return func(o *routerOptions) {
+		o.docsDir = dir
+	}
+}
+
+// NewRouter собирает все маршруты сервера. Без опций используется
+// хранилище идемпотентности в памяти и пустой реестр проверок,
+// все запросы анонимны, административные маршруты не подключены,
+// частота запросов не ограничена,
+// запросы с других источников (CORS) не разрешены, ответы не сжимаются,
+// документация не подключена.
+func NewRouter(h Handlers, opts ...Option) *chi.Mux {
+	o := routerOptions{
+		idempotencyWait: 5 * time.Second,
+		idempotencyBody: 64 << 20,
+		userTokens:      func() map[string]string { return nil },
+		logger:          slog.Default(),
+		maxJSONBody:     1 << 20,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.idempotencyStore == nil {
+		o.idempotencyStore = NewIdempotencyStoreMem(24 * time.Hour)
+	}
+	if o.rateLimits != nil && o.rateLimitStore == nil {
+		o.rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	if o.health == nil {
+		o.health = health.NewRegistry(2*time.Second, time.Second)
+	}
+
 	r := chi.NewRouter()
 
 	// Middlewares
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(middleware.RequestID)
+	r.Use(Identify(o.userTokens, o.trustUserHeader))
+	if o.compression {
+		// Снаружи трассировки, чтобы дописанный к ошибке trace ID
+		// сжимался вместе с телом ответа
+		r.Use(Compress(o.compressMinSize))
+	}
+	if o.tracing {
+		// Снаружи Recoverer, чтобы ответ 500 после паники содержал trace ID
+		r.Use(tracing.Middleware)
+	}
+	r.Use(RequestLogger(o.logger))
+	if o.metrics != nil {
+		// До Recoverer, чтобы запросы с паникой учитывались со статусом 500
+		r.Use(o.metrics.Middleware)
+	}
+	r.Use(Recoverer)
+	if o.cors != nil {
+		// До ограничения частоты: предварительные запросы не расходуют
+		// лимит, а ответ 429 доступен скрипту
+		r.Use(CORS(*o.cors))
+	}
+	if o.rateLimits != nil {
+		// До идемпотентности и обработчиков, чтобы отклоненный запрос
+		// не читал тело и не занимал ключ
+		r.Use(RateLimit(r, o.rateLimitStore, o.rateLimits))
+	}
+	r.Use(o.middlewares...)
+
+	// Ограничение размера до идемпотентности, которая читает тело целиком,
+	// и после распаковки, чтобы оно относилось к распакованным данным
+	if o.compression {
+		r.Use(DecompressRequest)
+	}
+	r.Use(MaxJSONBody(o.maxJSONBody))
+
+	// Повторы изменяющих запросов с Idempotency-Key
+	r.Use(Idempotency(o.idempotencyStore, o.idempotencyWait, o.idempotencyBody))
 
 	// Rutas de la API
 	r.Route("/api/v1/notes", func(r chi.Router) {
-		r.Get("/", h.GetAllNotes)
-		r.Post("/", h.CreateNote)
+		r.Get("/", h.Notes.GetAllNotes)
+		r.Post("/", h.Notes.CreateNote)
+		r.Get("/export/markdown", h.Notes.ExportNotesMarkdown)
+		r.Post("/import/markdown", h.Notes.ImportNotesMarkdown)
+		r.Get("/{id}.md", h.Notes.GetNoteMarkdown)
+		r.Get("/links/broken", h.Notes.GetBrokenLinks)
 		r.Route("/{id}", func(r chi.Router) {
-			r.Get("/", h.GetNote)
-			r.Put("/", h.UpdateNote)
-			r.Delete("/", h.DeleteNote)
+			r.Get("/", h.Notes.GetNote)
+			r.Put("/", h.Notes.UpdateNote)
+			r.Delete("/", h.Notes.DeleteNote)
+			r.Get("/outlinks", h.Notes.GetOutlinks)
+			r.Get("/backlinks", h.Notes.GetBacklinks)
+			r.Put("/pin", h.Notes.PinNote)
+			r.Delete("/pin", h.Notes.UnpinNote)
+			r.Put("/archive", h.Notes.ArchiveNote)
+			r.Delete("/archive", h.Notes.UnarchiveNote)
+			r.Put("/favorite", h.Notes.FavoriteNote)
+			r.Delete("/favorite", h.Notes.UnfavoriteNote)
+			r.Post("/items", h.Notes.AddChecklistItem)
+			r.Put("/items/order", h.Notes.ReorderChecklistItems)
+			r.Post("/items/{itemId}/toggle", h.Notes.ToggleChecklistItem)
+			r.Delete("/items/{itemId}", h.Notes.RemoveChecklistItem)
+			r.Get("/comments", h.Comments.ListComments)
+			r.Post("/comments", h.Comments.AddComment)
+			r.Put("/comments/{commentId}", h.Comments.UpdateComment)
+			r.Delete("/comments/{commentId}", h.Comments.DeleteComment)
+			r.Get("/attachments", h.Attachments.ListAttachments)
+			r.Post("/attachments", h.Attachments.UploadAttachment)
 		})
 	})
+	r.Post("/api/v1/notes:batch", h.Notes.BatchNotes)
 
-	// Ruta de salud
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+	// Вложения
+	r.Route("/api/v1/attachments/{id}", func(r chi.Router) {
+		r.Get("/", h.Attachments.DownloadAttachment)
+		r.Delete("/", h.Attachments.DeleteAttachment)
+		r.Get("/thumb", h.Attachments.GetThumbnail)
+	})
+
+	// Шаблоны заметок
+	r.Route("/api/v1/templates", func(r chi.Router) {
+		r.Get("/", h.Templates.GetAllTemplates)
+		r.Post("/", h.Templates.CreateTemplate)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", h.Templates.GetTemplate)
+			r.Put("/", h.Templates.UpdateTemplate)
+			r.Delete("/", h.Templates.DeleteTemplate)
+		})
 	})
 
+	// Административные маршруты подключаются только с токеном
+	adminToken := func() string { return "" }
+	if o.adminToken != nil {
+		adminToken = o.adminToken
+		r.Route("/api/v1/admin", func(r chi.Router) {
+			r.Use(RequireToken(o.adminToken))
+			r.Get("/export", h.Admin.ExportBackup)
+			r.Post("/import", h.Admin.ImportBackup)
+			r.Get("/log-level", h.LogLevel.GetLogLevel)
+			r.Put("/log-level", h.LogLevel.SetLogLevel)
+		})
+	}
+
+	// Состояние сервера; /health оставлен для совместимости и совпадает с /readyz.
+	// Подробный отчет доступен только с токеном административных маршрутов.
+	r.Get("/livez", livez)
+	r.Get("/readyz", readyz(o.health, adminToken))
+	r.Get("/health", readyz(o.health, adminToken))
+	if o.metrics != nil {
+		r.Method(http.MethodGet, "/metrics", o.metrics.Handler())
+	}
+
+	if o.docsDir != "" {
+		mountDocs(r, o.docsDir)
+	}
+
 	return r
 }
@@ -0,0 +1,248 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/backup"
+	"github.com/ybotet/pz12-notes-api/internal/blob"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/http/handlers"
+	"github.com/ybotet/pz12-notes-api/internal/imaging"
+	"github.com/ybotet/pz12-notes-api/internal/ratelimit"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+// newTestRouter собирает роутер, как main, на хранилищах в памяти
+func newTestRouter(t *testing.T, opts ...Option) *chi.Mux {
+	t.Helper()
+	notes := repo.NewNoteRepoMem()
+	comments := repo.NewCommentRepoMem()
+	attachments := repo.NewAttachmentRepoMem()
+	templates := repo.NewTemplateRepoMem()
+	blobs := blob.NewMemStore()
+	validator := validation.New(validation.DefaultRules())
+
+	noteService := service.NewNoteService(notes, repo.NewLinkRepoMem(), comments, validator)
+	templateService := service.NewTemplateService(templates, validator)
+	attachmentService := service.NewAttachmentService(notes, attachments, blobs, 0, imaging.NewPool(0, 0))
+	noteService.OnDelete(attachmentService.DeleteNoteAttachments)
+
+	opts = append([]Option{WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))}, opts...)
+	return NewRouter(Handlers{
+		Notes: handlers.NewHandler(noteService, templateService),
+		Admin: handlers.NewAdminHandler(backup.Store{
+			Notes:       notes,
+			Comments:    comments,
+			Attachments: attachments,
+			Templates:   templates,
+			Blobs:       blobs,
+		}, noteService),
+		Attachments: handlers.NewAttachmentHandler(attachmentService, 1<<20),
+		Templates:   handlers.NewTemplateHandler(templateService),
+		Comments:    handlers.NewCommentHandler(service.NewCommentService(notes, comments, validator)),
+		LogLevel:    handlers.NewLogLevelHandler(new(slog.LevelVar)),
+	}, opts...)
+}
+
+// serve выполняет запрос; header задается парами имя, значение
+func serve(r http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr = "10.0.0.1:5555"
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeID(t *testing.T, rec *httptest.ResponseRecorder) int64 {
+	t.Helper()
+	var v struct{ ID int64 }
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("ответ %q: %v", rec.Body.String(), err)
+	}
+	return v.ID
+}
+
+func TestRouterAdminRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		header []string
+		want   int
+	}{
+		{name: "без WithAdminToken", want: http.StatusNotFound},
+		{name: "без WithAdminToken с токеном", header: []string{"Authorization", "Bearer t"}, want: http.StatusNotFound},
+		{name: "без токена", opts: []Option{WithAdminToken(func() string { return "t" })}, want: http.StatusUnauthorized},
+		{name: "чужой токен", opts: []Option{WithAdminToken(func() string { return "t" })}, header: []string{"Authorization", "Bearer x"}, want: http.StatusUnauthorized},
+		{name: "верный токен", opts: []Option{WithAdminToken(func() string { return "t" })}, header: []string{"Authorization", "Bearer t"}, want: http.StatusOK},
+		{name: "пустой токен не открывает доступ", opts: []Option{WithAdminToken(func() string { return "" })}, header: []string{"Authorization", "Bearer "}, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(t, tt.opts...)
+			for _, path := range []string{"/api/v1/admin/log-level", "/api/v1/admin/export"} {
+				if rec := serve(r, http.MethodGet, path, "", tt.header...); rec.Code != tt.want {
+					t.Errorf("GET %s = %d, want %d", path, rec.Code, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRouterAuthentication(t *testing.T) {
+	users := func() map[string]string { return map[string]string{"alice": "a-token", "bob": "b-token"} }
+
+	tests := []struct {
+		name        string
+		trustHeader bool
+		header      []string
+		wantAuthor  string
+	}{
+		{name: "аноним", wantAuthor: core.AnonymousUser},
+		{name: "токен пользователя", header: []string{"Authorization", "Bearer a-token"}, wantAuthor: "alice"},
+		{name: "неизвестный токен", header: []string{"Authorization", "Bearer other"}, wantAuthor: core.AnonymousUser},
+		{name: "X-User-ID без доверия", header: []string{UserHeader, "bob"}, wantAuthor: core.AnonymousUser},
+		{name: "X-User-ID за шлюзом", trustHeader: true, header: []string{UserHeader, "bob"}, wantAuthor: "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(t, WithUsers(users, tt.trustHeader))
+			note := decodeID(t, serve(r, http.MethodPost, "/api/v1/notes", `{"title":"Заметка"}`))
+
+			rec := serve(r, http.MethodPost, "/api/v1/notes/"+strconv.FormatInt(note, 10)+"/comments", `{"body":"текст"}`, tt.header...)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("POST comments = %d: %s", rec.Code, rec.Body)
+			}
+			var c core.Comment
+			if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+				t.Fatal(err)
+			}
+			if c.AuthorID != tt.wantAuthor {
+				t.Errorf("AuthorID = %q, want %q", c.AuthorID, tt.wantAuthor)
+			}
+		})
+	}
+
+	t.Run("изменять комментарий может только автор", func(t *testing.T) {
+		r := newTestRouter(t, WithUsers(users, false))
+		note := decodeID(t, serve(r, http.MethodPost, "/api/v1/notes", `{"title":"Заметка"}`))
+		path := "/api/v1/notes/" + strconv.FormatInt(note, 10) + "/comments"
+		comment := decodeID(t, serve(r, http.MethodPost, path, `{"body":"текст"}`, "Authorization", "Bearer a-token"))
+		path += "/" + strconv.FormatInt(comment, 10)
+
+		if rec := serve(r, http.MethodPut, path, `{"body":"чужая правка"}`, "Authorization", "Bearer b-token"); rec.Code != http.StatusForbidden {
+			t.Errorf("PUT от bob = %d, want %d", rec.Code, http.StatusForbidden)
+		}
+		if rec := serve(r, http.MethodPut, path, `{"body":"правка"}`); rec.Code != http.StatusForbidden {
+			t.Errorf("PUT от анонима = %d, want %d", rec.Code, http.StatusForbidden)
+		}
+		if rec := serve(r, http.MethodPut, path, `{"body":"правка"}`, "Authorization", "Bearer a-token"); rec.Code != http.StatusOK {
+			t.Errorf("PUT от alice = %d, want %d", rec.Code, http.StatusOK)
+		}
+	})
+}
+
+func TestRouterCORSBeforeRateLimit(t *testing.T) {
+	rules, err := ratelimit.ParseRules("1/m", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newTestRouter(t,
+		WithCORS(CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, AllowedMethods: []string{"GET", "POST"}}),
+		WithRateLimit(ratelimit.NewMemoryStore(), func() *ratelimit.Rules { return rules }),
+	)
+	origin := []string{"Origin", "https://app.example.com"}
+
+	// Предварительные запросы обрабатываются до ограничения частоты
+	// и до маршрутизации: у маршрутов chi нет обработчиков OPTIONS
+	for i := 0; i < 3; i++ {
+		rec := serve(r, http.MethodOptions, "/api/v1/notes", "", append(origin, "Access-Control-Request-Method", "POST")...)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("preflight %d = %d, want %d", i, rec.Code, http.StatusNoContent)
+		}
+	}
+
+	if rec := serve(r, http.MethodGet, "/api/v1/notes", "", origin...); rec.Code != http.StatusOK {
+		t.Fatalf("первый GET = %d, want %d", rec.Code, http.StatusOK)
+	}
+	rec := serve(r, http.MethodGet, "/api/v1/notes", "", origin...)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("второй GET = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin в ответе 429 = %q", got)
+	}
+}
+
+func TestRouterIdempotency(t *testing.T) {
+	users := func() map[string]string { return map[string]string{"alice": "a-token", "bob": "b-token"} }
+	rules, err := ratelimit.ParseRules("100/m", "POST /api/v1/notes=3/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewIdempotencyStoreMem(time.Hour)
+	r := newTestRouter(t,
+		WithUsers(users, false),
+		WithIdempotency(store, time.Second, 1<<20),
+		WithRateLimit(ratelimit.NewMemoryStore(), func() *ratelimit.Rules { return rules }),
+	)
+	create := func(token, key string) *httptest.ResponseRecorder {
+		return serve(r, http.MethodPost, "/api/v1/notes", `{"title":"Заметка"}`,
+			"Authorization", "Bearer "+token, IdempotencyHeader, key)
+	}
+
+	// Ключи действуют в пределах пользователя, определенного по токену
+	first := create("a-token", "k")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("первый POST = %d: %s", first.Code, first.Body)
+	}
+	replay := create("a-token", "k")
+	if replay.Code != http.StatusCreated || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("повтор = %d, Idempotent-Replayed %q", replay.Code, replay.Header().Get("Idempotent-Replayed"))
+	}
+	if decodeID(t, replay) != decodeID(t, first) {
+		t.Errorf("повтор создал другую заметку: %s", replay.Body)
+	}
+	other := create("b-token", "k")
+	if other.Code != http.StatusCreated || other.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("тот же ключ другого пользователя = %d, Idempotent-Replayed %q", other.Code, other.Header().Get("Idempotent-Replayed"))
+	}
+	if decodeID(t, other) == decodeID(t, first) {
+		t.Error("другой пользователь получил чужой сохраненный ответ")
+	}
+
+	// Лимит проверяется до идемпотентности: отклоненный запрос
+	// не занимает ключ и может быть повторен позже
+	if rec := create("a-token", "late"); rec.Code != http.StatusCreated {
+		t.Fatalf("третий POST alice = %d", rec.Code)
+	}
+	if rec := create("a-token", "rejected"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("четвертый POST alice = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	store.mu.Lock()
+	_, taken := store.entries["user:alice rejected"]
+	_, stored := store.entries["user:alice late"]
+	store.mu.Unlock()
+	if taken {
+		t.Error("запрос, отклоненный лимитом, занял ключ идемпотентности")
+	}
+	if !stored {
+		t.Error("ключ выполненного запроса не сохранен под пользователем")
+	}
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+// UserHeader — заголовок с идентификатором пользователя
+const UserHeader = "X-User-ID"
+
+// Identify помещает в контекст запроса пользователя, предъявившего в
+// Authorization: Bearer свой токен из users (пользователь → токен).
+// Заголовок X-User-ID учитывается, только если trustHeader: его должен
+// выставлять доверенный шлюз, иначе клиент может представиться кем угодно.
+// Остальные запросы анонимны, неизвестный токен ошибкой не считается:
+// им может быть, например, токен административных маршрутов.
+func Identify(users func() map[string]string, trustHeader bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := userByToken(r, users())
+			if user == "" && trustHeader {
+				user = strings.TrimSpace(r.Header.Get(UserHeader))
+			}
+			if user == "" || len(user) > 128 {
+				user = core.AnonymousUser
+			}
+			next.ServeHTTP(w, r.WithContext(core.WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// userByToken возвращает владельца токена из Authorization или пустую
+// строку; токены сравниваются за постоянное время
+func userByToken(r *http.Request, users map[string]string) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	if !ok || token == "" {
+		return ""
+	}
+	found := ""
+	for user, want := range users {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1 {
+			found = user
+		}
+	}
+	return found
+}
+
+// clientKey определяет клиента для лимитов и ключей идемпотентности:
+// аутентифицированного пользователя (см. Identify), иначе IP-адрес
+// соединения. Заголовки, которые клиент может менять произвольно,
+// ключом не служат.
+func clientKey(r *http.Request) string {
+	if user := core.UserFromContext(r.Context()); user != core.AnonymousUser {
+		return "user:" + user
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
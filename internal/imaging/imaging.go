@@ -0,0 +1,140 @@
+// Package imaging извлекает метаданные изображений, удаляет из них EXIF
+// и строит уменьшенные копии средствами стандартной библиотеки.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// MaxPixels ограничивает размер декодируемых изображений
+const MaxPixels = 50_000_000
+
+// Info содержит основные сведения об изображении
+type Info struct {
+	Format      string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Supported сообщает, обрабатывается ли изображение с таким MIME-типом
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Inspect читает заголовок изображения и возвращает формат и размеры
+func Inspect(data []byte) (Info, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, err
+	}
+
+	return Info{
+		Format:      format,
+		ContentType: "image/" + format,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}, nil
+}
+
+// Thumbnail уменьшает изображение до ширины width с сохранением пропорций
+// и кодирует результат: JPEG остается JPEG, остальные форматы — PNG.
+// Изображения уже меньше width не увеличиваются.
+func Thumbnail(data []byte, width int) ([]byte, string, error) {
+	info, err := Inspect(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.Width*info.Height > MaxPixels {
+		return nil, "", errors.New("изображение слишком большое")
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	dst := resize(src, width)
+
+	var buf bytes.Buffer
+	if info.Format == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&buf, dst)
+	return buf.Bytes(), "image/png", err
+}
+
+// resize уменьшает изображение усреднением пикселей исходной области
+func resize(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if width <= 0 || width >= sw {
+		return src
+	}
+	height := (sh*width + sw/2) / sw
+	if height < 1 {
+		height = 1
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				off := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(rgba.Pix[off])
+					g += uint32(rgba.Pix[off+1])
+					bl += uint32(rgba.Pix[off+2])
+					a += uint32(rgba.Pix[off+3])
+					off += 4
+					n++
+				}
+			}
+
+			off := dst.PixOffset(x, y)
+			dst.Pix[off] = uint8(r / n)
+			dst.Pix[off+1] = uint8(g / n)
+			dst.Pix[off+2] = uint8(bl / n)
+			dst.Pix[off+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// ReadAll читает изображение не больше limit байт
+func ReadAll(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.New("изображение слишком большое")
+	}
+	return data, nil
+}
@@ -0,0 +1,92 @@
+package imaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPoolBusy возвращается, если очередь задач заполнена
+var ErrPoolBusy = errors.New("очередь обработки изображений заполнена")
+
+// ErrPoolClosed возвращается при отправке задачи в остановленный пул
+var ErrPoolClosed = errors.New("пул обработки изображений остановлен")
+
+// Pool выполняет задачи фиксированным числом горутин с ограниченной очередью
+type Pool struct {
+	mu     sync.RWMutex
+	jobs   chan func()
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewPool запускает workers обработчиков с очередью на queue задач
+func NewPool(workers, queue int) *Pool {
+	p := &Pool{jobs: make(chan func(), queue)}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// Submit ставит задачу в очередь, не блокируясь
+func (p *Pool) Submit(job func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	select {
+	case p.jobs <- job:
+		return nil
+	default:
+		return ErrPoolBusy
+	}
+}
+
+// Close прекращает прием задач и дожидается выполнения поставленных
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// Shutdown прекращает прием задач и дожидается выполнения поставленных,
+// но не дольше, чем живет ctx
+func (p *Pool) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckHealth сообщает об ошибке, если пул остановлен
+func (p *Pool) CheckHealth(ctx context.Context) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Префиксы сегментов APP1 JPEG с метаданными: EXIF и XMP (основной
+// и расширенный) могут содержать координаты съемки
+var jpegMetadataPrefixes = [][]byte{
+	[]byte("Exif\x00\x00"),
+	[]byte("http://ns.adobe.com/xap/1.0/\x00"),
+	[]byte("http://ns.adobe.com/xmp/extension/\x00"),
+}
+
+// Ключевые слова текстовых фрагментов PNG с EXIF и XMP; «Raw profile
+// type» так сохраняют EXIF ImageMagick и exiftool
+var pngMetadataKeywords = []string{
+	"XML:com.adobe.xmp",
+	"Raw profile type exif",
+	"Raw profile type APP1",
+	"Raw profile type xmp",
+}
+
+// StripEXIF удаляет из изображения метаданные EXIF и XMP, включая
+// геолокацию, без перекодирования: из JPEG — сегменты APP1, из PNG —
+// фрагмент eXIf и текстовые фрагменты с EXIF и XMP. Данные других
+// форматов возвращаются как есть.
+func StripEXIF(data []byte) ([]byte, error) {
+	switch {
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xD8:
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNG(data)
+	}
+	return data, nil
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	out.Write(data[:2])
+	pos := 2
+	stripped := false
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, errors.New("неверная структура JPEG")
+		}
+		marker := data[pos+1]
+
+		// Начало данных изображения: дальше сегментов метаданных нет
+		if marker == 0xDA {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errors.New("неверная структура JPEG")
+		}
+
+		segment := data[pos:end]
+		if marker == 0xE1 && hasAnyPrefix(segment[4:], jpegMetadataPrefixes) {
+			stripped = true
+		} else {
+			out.Write(segment)
+		}
+		pos = end
+	}
+
+	if !stripped {
+		return data, nil
+	}
+	out.Write(data[pos:])
+	return out.Bytes(), nil
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	pos := len(pngSignature)
+	stripped := false
+	for pos < len(data) {
+		// Фрагмент: длина, тип, данные, CRC
+		if pos+12 > len(data) {
+			return nil, errors.New("неверная структура PNG")
+		}
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) || end < pos {
+			return nil, errors.New("неверная структура PNG")
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		body := data[pos+8 : pos+8+length]
+		if crc32.ChecksumIEEE(data[pos+4:pos+8+length]) != binary.BigEndian.Uint32(data[pos+8+length:end]) {
+			return nil, errors.New("неверная контрольная сумма фрагмента PNG")
+		}
+
+		if pngMetadataChunk(chunkType, body) {
+			stripped = true
+		} else {
+			out.Write(data[pos:end])
+		}
+		pos = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	if !stripped {
+		return data, nil
+	}
+	return out.Bytes(), nil
+}
+
+// pngMetadataChunk сообщает, содержит ли фрагмент PNG метаданные EXIF или XMP
+func pngMetadataChunk(chunkType string, body []byte) bool {
+	switch chunkType {
+	case "eXIf":
+		return true
+	case "tEXt", "zTXt", "iTXt":
+		// Данные текстового фрагмента начинаются с ключевого слова и нулевого байта
+		keyword, _, ok := bytes.Cut(body, []byte{0})
+		if !ok {
+			return false
+		}
+		for _, k := range pngMetadataKeywords {
+			if string(keyword) == k {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(b []byte, prefixes [][]byte) bool {
+	for _, p := range prefixes {
+		if bytes.HasPrefix(b, p) {
+			return true
+		}
+	}
+	return false
+}
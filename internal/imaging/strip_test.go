@@ -0,0 +1,153 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+)
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for x := 0; x < 8; x++ {
+		img.Set(x, 1, color.RGBA{R: 255, A: 255})
+	}
+	return img
+}
+
+// jpegWithSegments вставляет сегменты APP1 сразу после SOI
+func jpegWithSegments(t *testing.T, payloads ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	out := append([]byte{}, data[:2]...)
+	for _, p := range payloads {
+		out = append(out, 0xFF, 0xE1)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(p)+2))
+		out = append(out, p...)
+	}
+	return append(out, data[2:]...)
+}
+
+// pngWithChunks вставляет фрагменты перед IDAT
+func pngWithChunks(t *testing.T, chunks ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	idat := bytes.Index(data, []byte("IDAT")) - 4
+
+	out := append([]byte{}, data[:idat]...)
+	for _, c := range chunks {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(c[1])))
+		start := len(out)
+		out = append(out, c[0]...)
+		out = append(out, c[1]...)
+		out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[start:]))
+	}
+	return append(out, data[idat:]...)
+}
+
+func TestStripEXIF(t *testing.T) {
+	const gps = "GPSLatitude=55.75"
+
+	tests := []struct {
+		name      string
+		data      []byte
+		stripped  bool
+		keep      string
+		wantError bool
+	}{
+		{
+			name:     "JPEG с EXIF",
+			data:     jpegWithSegments(t, "Exif\x00\x00"+gps),
+			stripped: true,
+		},
+		{
+			name:     "JPEG с XMP",
+			data:     jpegWithSegments(t, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>"+gps),
+			stripped: true,
+		},
+		{
+			name:     "JPEG с расширенным XMP и посторонним APP1",
+			data:     jpegWithSegments(t, "http://ns.adobe.com/xmp/extension/\x00"+gps, "Other\x00keep-me"),
+			stripped: true,
+			keep:     "keep-me",
+		},
+		{
+			name: "JPEG без метаданных",
+			data: jpegWithSegments(t),
+		},
+		{
+			name:     "PNG с eXIf",
+			data:     pngWithChunks(t, [2]string{"eXIf", "MM\x00*" + gps}),
+			stripped: true,
+		},
+		{
+			name: "PNG с XMP и raw-профилем EXIF",
+			data: pngWithChunks(t,
+				[2]string{"iTXt", "XML:com.adobe.xmp\x00\x00\x00\x00\x00" + gps},
+				[2]string{"zTXt", "Raw profile type exif\x00\x00" + gps},
+				[2]string{"tEXt", "Comment\x00keep-me"},
+			),
+			stripped: true,
+			keep:     "keep-me",
+		},
+		{
+			name: "PNG без метаданных",
+			data: pngWithChunks(t, [2]string{"tEXt", "Comment\x00keep-me"}),
+			keep: "keep-me",
+		},
+		{
+			name: "не изображение",
+			data: []byte("GPSLatitude=55.75 в тексте"),
+		},
+		{
+			name:      "обрезанный JPEG",
+			data:      jpegWithSegments(t, "Exif\x00\x00"+gps)[:10],
+			wantError: true,
+		},
+		{
+			name:      "PNG с неверной контрольной суммой",
+			data:      func() []byte { d := pngWithChunks(t, [2]string{"eXIf", gps}); d[len(pngSignature)+20]++; return d }(),
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StripEXIF(tt.data)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("StripEXIF error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+
+			if tt.stripped == bytes.Equal(got, tt.data) {
+				t.Errorf("данные изменены = %v, want %v", !bytes.Equal(got, tt.data), tt.stripped)
+			}
+			if bytes.Contains(got, []byte(gps)) && tt.stripped {
+				t.Error("метаданные не удалены")
+			}
+			if tt.keep != "" && !bytes.Contains(got, []byte(tt.keep)) {
+				t.Errorf("удален фрагмент без метаданных %q", tt.keep)
+			}
+			if Supported(http.DetectContentType(got)) {
+				if _, _, err := image.Decode(bytes.NewReader(got)); err != nil {
+					t.Errorf("изображение не декодируется после очистки: %v", err)
+				}
+			}
+		})
+	}
+}
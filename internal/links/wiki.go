@@ -0,0 +1,142 @@
+// Package links разбирает вики-ссылки вида [[заголовок]] и [[#id]] в тексте заметок.
+package links
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// wikiLinkRe находит ссылки [[цель]] и [[цель|подпись]]
+var wikiLinkRe = regexp.MustCompile(`\[\[([^\[\]\n]+?)\]\]`)
+
+// Ref — одна вики-ссылка в тексте
+type Ref struct {
+	// Target — текст ссылки без подписи: заголовок или #id
+	Target string
+	// Title — заголовок целевой заметки, если ссылка по заголовку
+	Title string
+	// ID — идентификатор целевой заметки, если ссылка вида [[#id]]
+	ID    int64
+	Label string
+}
+
+// IsID сообщает, указывает ли ссылка на заметку по ID
+func (r Ref) IsID() bool {
+	return r.ID > 0
+}
+
+// Key возвращает ключ цели ссылки, одинаковый для всех ее записей:
+// #id для ссылки по ID, иначе заголовок без учета регистра и крайних пробелов
+func (r Ref) Key() string {
+	if r.IsID() {
+		return "#" + strconv.FormatInt(r.ID, 10)
+	}
+	return normalize(r.Title)
+}
+
+// Parse возвращает ссылки из текста без повторов, в порядке появления
+func Parse(content string) []Ref {
+	var refs []Ref
+	seen := make(map[string]bool)
+	for _, m := range wikiLinkRe.FindAllStringSubmatch(content, -1) {
+		ref, ok := ParseRef(m[1])
+		if !ok {
+			continue
+		}
+		key := normalize(ref.Target)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// RewriteTitle заменяет ссылки по заголовку oldTitle на ссылки на заметку
+// id с заголовком newTitle, сохраняя подписи. Если newTitle нельзя записать
+// в ссылке (см. Linkable), ссылка ведет на #id, а прежний текст ссылки
+// становится ее подписью.
+func RewriteTitle(content, oldTitle, newTitle string, id int64) string {
+	return wikiLinkRe.ReplaceAllStringFunc(content, func(match string) string {
+		ref, ok := ParseRef(match[2 : len(match)-2])
+		if !ok || ref.IsID() || !SameTitle(ref.Title, oldTitle) {
+			return match
+		}
+		target, label := newTitle, ref.Label
+		if !Linkable(newTitle) {
+			target = "#" + strconv.FormatInt(id, 10)
+			if label == "" {
+				label = ref.Title
+			}
+		}
+		if label != "" {
+			return "[[" + target + "|" + label + "]]"
+		}
+		return "[[" + target + "]]"
+	})
+}
+
+// Linkable сообщает, можно ли сослаться на заметку по заголовку title:
+// квадратные скобки, | и перевод строки разорвали бы ссылку, а заголовок
+// вида #id читался бы как ссылка по ID
+func Linkable(title string) bool {
+	if strings.ContainsAny(title, "[]|\n") || strings.TrimSpace(title) == "" {
+		return false
+	}
+	ref, ok := ParseRef(title)
+	return ok && !ref.IsID()
+}
+
+// RewriteIDs заменяет ссылки [[#id]] по соответствию ids (старый ID →
+// новый), сохраняя подписи; ссылки на ID вне ids не меняются
+func RewriteIDs(content string, ids map[int64]int64) string {
+	return wikiLinkRe.ReplaceAllStringFunc(content, func(match string) string {
+		ref, ok := ParseRef(match[2 : len(match)-2])
+		if !ok || !ref.IsID() {
+			return match
+		}
+		id, ok := ids[ref.ID]
+		if !ok {
+			return match
+		}
+		target := "#" + strconv.FormatInt(id, 10)
+		if ref.Label != "" {
+			return "[[" + target + "|" + ref.Label + "]]"
+		}
+		return "[[" + target + "]]"
+	})
+}
+
+// SameTitle сравнивает заголовки без учета регистра и крайних пробелов
+func SameTitle(a, b string) bool {
+	return normalize(a) == normalize(b)
+}
+
+// ParseRef разбирает содержимое ссылки между [[ и ]]
+func ParseRef(inner string) (Ref, bool) {
+	target, label, _ := strings.Cut(inner, "|")
+	ref := Ref{
+		Target: strings.TrimSpace(target),
+		Label:  strings.TrimSpace(label),
+	}
+	if ref.Target == "" {
+		return Ref{}, false
+	}
+
+	if rest, ok := strings.CutPrefix(ref.Target, "#"); ok {
+		id, err := strconv.ParseInt(rest, 10, 64)
+		if err == nil && id > 0 {
+			ref.ID = id
+			return ref, true
+		}
+	}
+
+	ref.Title = ref.Target
+	return ref, true
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,82 @@
+package links
+
+import "testing"
+
+func TestRewriteIDs(t *testing.T) {
+	ids := map[int64]int64{1: 10, 2: 20}
+	tests := []struct {
+		in, want string
+	}{
+		{in: "[[#1]]", want: "[[#10]]"},
+		{in: "см. [[#2|вторую]] и [[#1]]", want: "см. [[#20|вторую]] и [[#10]]"},
+		{in: "[[ #1 ]]", want: "[[#10]]"},
+		{in: "[[#3]] вне импорта", want: "[[#3]] вне импорта"},
+		{in: "[[Заголовок]] и [[#x]]", want: "[[Заголовок]] и [[#x]]"},
+		{in: "#1 без скобок", want: "#1 без скобок"},
+	}
+	for _, tt := range tests {
+		if got := RewriteIDs(tt.in, ids); got != tt.want {
+			t.Errorf("RewriteIDs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRewriteTitle(t *testing.T) {
+	tests := []struct {
+		name, content, newTitle, want string
+	}{
+		{name: "простая ссылка", content: "см. [[План]]", newTitle: "Планы", want: "см. [[Планы]]"},
+		{name: "регистр и подпись", content: "[[ план |тут]]", newTitle: "Планы", want: "[[Планы|тут]]"},
+		{name: "другие ссылки не меняются", content: "[[Другое]] [[#3]]", newTitle: "Планы", want: "[[Другое]] [[#3]]"},
+		{name: "заголовок с ]]", content: "см. [[План]]", newTitle: "a]]b", want: "см. [[#7|План]]"},
+		{name: "заголовок с | и подписью", content: "[[План|тут]]", newTitle: "a|b", want: "[[#7|тут]]"},
+		{name: "заголовок вида #id", content: "[[План]]", newTitle: "#12", want: "[[#7|План]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RewriteTitle(tt.content, "План", tt.newTitle, 7)
+			if got != tt.want {
+				t.Errorf("RewriteTitle = %q, want %q", got, tt.want)
+			}
+			// Переписанные ссылки должны разбираться
+			for _, ref := range Parse(got) {
+				if !ref.IsID() && ref.Title != tt.newTitle && !SameTitle(ref.Title, "Другое") {
+					t.Errorf("ссылка %+v не ведет на новый заголовок", ref)
+				}
+			}
+		})
+	}
+}
+
+func TestLinkable(t *testing.T) {
+	tests := map[string]bool{
+		"План":       true,
+		"[WIP] План": false,
+		"a]]b":       false,
+		"a|b":        false,
+		"#12":        false,
+		"#план":      true,
+		"  ":         false,
+	}
+	for title, want := range tests {
+		if got := Linkable(title); got != want {
+			t.Errorf("Linkable(%q) = %v, want %v", title, got, want)
+		}
+	}
+}
+
+func TestRefKey(t *testing.T) {
+	tests := []struct {
+		inner, want string
+	}{
+		{inner: "#5", want: "#5"},
+		{inner: "#05|подпись", want: "#5"},
+		{inner: " План ", want: "план"},
+	}
+	for _, tt := range tests {
+		ref, _ := ParseRef(tt.inner)
+		if got := ref.Key(); got != tt.want {
+			t.Errorf("ParseRef(%q).Key() = %q, want %q", tt.inner, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package logging настраивает структурированный журнал на log/slog
+// и передает логгер через контекст запроса в сервисы и хранилища.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Форматы журнала
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New создает логгер в формате json или text с изменяемым уровнем level
+func New(w io.Writer, format string, level *slog.LevelVar) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch format {
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("неизвестный формат журнала %q", format)
+	}
+	return slog.New(traceHandler{h}), nil
+}
+
+// ParseLevel разбирает уровень журнала: debug, info, warn или error
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("неизвестный уровень журнала %q", s)
+	}
+	return level, nil
+}
+
+type loggerKey struct{}
+
+// WithLogger сохраняет логгер в контексте
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext возвращает логгер из контекста, а если его нет — slog.Default().
+// Логгер запроса уже содержит request_id и пользователя.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// traceHandler добавляет к записям с контекстом идентификаторы
+// трассировки и спана OpenTelemetry
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
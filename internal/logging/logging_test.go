@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{name: "json", format: FormatJSON, want: `"msg":"событие"`},
+		{name: "text", format: FormatText, want: "msg=событие"},
+		{name: "неизвестный формат", format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := New(&buf, tt.format, new(slog.LevelVar))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "неизвестный формат") {
+					t.Errorf("New(%q) error = %v", tt.format, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Info("событие")
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("запись = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLevel(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	l, err := New(&buf, FormatText, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("скрыто")
+	if buf.Len() != 0 {
+		t.Errorf("запись ниже уровня: %q", buf.String())
+	}
+	// Уровень меняется без пересоздания логгера
+	level.Set(slog.LevelDebug)
+	l.Debug("видно")
+	if !strings.Contains(buf.String(), "видно") {
+		t.Errorf("запись после смены уровня = %q", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{in: "debug", want: slog.LevelDebug},
+		{in: " INFO ", want: slog.LevelInfo},
+		{in: "warn", want: slog.LevelWarn},
+		{in: "error", want: slog.LevelError},
+		{in: "verbose", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != slog.Default() {
+		t.Error("FromContext() без логгера не вернул slog.Default()")
+	}
+	l := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if got := FromContext(WithLogger(context.Background(), l)); got != l {
+		t.Error("FromContext() не вернул сохраненный логгер")
+	}
+}
+
+func TestTraceIDs(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, FormatJSON, new(slog.LevelVar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 1},
+		SpanID:     trace.SpanID{0x00, 0xf0, 2},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	// Идентификаторы сохраняются и у производного логгера
+	l.With("request_id", "r1").InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "в спане")
+	l.InfoContext(context.Background(), "вне спана")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("записей %d, want 2: %q", len(lines), buf.String())
+	}
+	var inSpan, outside map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &inSpan); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &outside); err != nil {
+		t.Fatal(err)
+	}
+	if inSpan["trace_id"] != sc.TraceID().String() || inSpan["span_id"] != sc.SpanID().String() || inSpan["request_id"] != "r1" {
+		t.Errorf("запись в спане = %v", inSpan)
+	}
+	if _, ok := outside["trace_id"]; ok {
+		t.Errorf("запись вне спана = %v, trace_id лишний", outside)
+	}
+}
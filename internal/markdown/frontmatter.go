@@ -0,0 +1,176 @@
+// Package markdown преобразует заметки в Markdown-файлы с YAML front matter и обратно.
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"gopkg.in/yaml.v2"
+)
+
+const delimiter = "---"
+
+// frontMatter описывает метаданные заметки в заголовке файла
+type frontMatter struct {
+	ID         int64    `yaml:"id"`
+	Title      string   `yaml:"title"`
+	Tags       []string `yaml:"tags,omitempty"`
+	CreatedAt  string   `yaml:"created_at"`
+	UpdatedAt  string   `yaml:"updated_at,omitempty"`
+	DueAt      string   `yaml:"due_at,omitempty"`
+	RemindAt   string   `yaml:"remind_at,omitempty"`
+	Recurrence string   `yaml:"recurrence,omitempty"`
+	Pinned     bool     `yaml:"pinned,omitempty"`
+	Archived   bool     `yaml:"archived,omitempty"`
+	Favorite   bool     `yaml:"favorite,omitempty"`
+	// Type и Items заполняются только для заметок-списков
+	Type  string            `yaml:"type,omitempty"`
+	Items []frontMatterItem `yaml:"items,omitempty"`
+}
+
+// frontMatterItem описывает пункт заметки-списка
+type frontMatterItem struct {
+	ID      int64  `yaml:"id"`
+	Text    string `yaml:"text"`
+	Checked bool   `yaml:"checked"`
+	Order   int    `yaml:"order"`
+}
+
+// Encode сериализует заметку в Markdown с YAML front matter.
+// Содержимое записывается без изменений, за ним следует перевод строки.
+func Encode(note core.Note) ([]byte, error) {
+	fm := frontMatter{
+		ID:        note.ID,
+		Title:     note.Title,
+		Tags:      note.Tags,
+		CreatedAt: note.CreatedAt.Format(time.RFC3339Nano),
+	}
+	if note.UpdatedAt != nil {
+		fm.UpdatedAt = note.UpdatedAt.Format(time.RFC3339Nano)
+	}
+	if note.DueAt != nil {
+		fm.DueAt = note.DueAt.Format(time.RFC3339Nano)
+	}
+	if note.RemindAt != nil {
+		fm.RemindAt = note.RemindAt.Format(time.RFC3339Nano)
+	}
+	fm.Recurrence = note.Recurrence
+	fm.Pinned = note.Pinned
+	fm.Archived = note.Archived
+	fm.Favorite = note.Favorite
+	if note.IsChecklist() {
+		fm.Type = string(note.Type)
+		for _, item := range note.Items {
+			fm.Items = append(fm.Items, frontMatterItem{
+				ID:      item.ID,
+				Text:    item.Text,
+				Checked: item.Checked,
+				Order:   item.Order,
+			})
+		}
+	}
+
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(delimiter + "\n")
+	buf.Write(header)
+	buf.WriteString(delimiter + "\n")
+	buf.WriteString(note.Content)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// Decode разбирает Markdown-файл, созданный Encode, обратно в заметку
+func Decode(data []byte) (core.Note, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, delimiter+"\n") {
+		return core.Note{}, errors.New("отсутствует front matter")
+	}
+
+	rest := text[len(delimiter)+1:]
+	end := strings.Index(rest, "\n"+delimiter+"\n")
+	var header, body string
+	switch {
+	case strings.HasPrefix(rest, delimiter+"\n"):
+		body = rest[len(delimiter)+1:]
+	case end >= 0:
+		header = rest[:end+1]
+		body = rest[end+len(delimiter)+2:]
+	case strings.HasSuffix(rest, "\n"+delimiter):
+		header = rest[:len(rest)-len(delimiter)]
+	default:
+		return core.Note{}, errors.New("front matter не закрыт")
+	}
+
+	var fm frontMatter
+	if err := yaml.UnmarshalStrict([]byte(header), &fm); err != nil {
+		return core.Note{}, fmt.Errorf("неверный front matter: %w", err)
+	}
+
+	note := core.Note{
+		ID:      fm.ID,
+		Title:   fm.Title,
+		Tags:    fm.Tags,
+		Content: strings.TrimSuffix(body, "\n"),
+		Type:    core.NoteType(fm.Type),
+	}
+	for _, item := range fm.Items {
+		note.Items = append(note.Items, core.ChecklistItem{
+			ID:      item.ID,
+			Text:    item.Text,
+			Checked: item.Checked,
+			Order:   item.Order,
+		})
+	}
+
+	if fm.CreatedAt != "" {
+		createdAt, err := time.Parse(time.RFC3339Nano, fm.CreatedAt)
+		if err != nil {
+			return core.Note{}, fmt.Errorf("неверное значение created_at: %w", err)
+		}
+		note.CreatedAt = createdAt
+	}
+
+	if fm.UpdatedAt != "" {
+		updatedAt, err := time.Parse(time.RFC3339Nano, fm.UpdatedAt)
+		if err != nil {
+			return core.Note{}, fmt.Errorf("неверное значение updated_at: %w", err)
+		}
+		note.UpdatedAt = &updatedAt
+	}
+
+	if fm.DueAt != "" {
+		dueAt, err := time.Parse(time.RFC3339Nano, fm.DueAt)
+		if err != nil {
+			return core.Note{}, fmt.Errorf("неверное значение due_at: %w", err)
+		}
+		note.DueAt = &dueAt
+	}
+
+	if fm.RemindAt != "" {
+		remindAt, err := time.Parse(time.RFC3339Nano, fm.RemindAt)
+		if err != nil {
+			return core.Note{}, fmt.Errorf("неверное значение remind_at: %w", err)
+		}
+		note.RemindAt = &remindAt
+	}
+	note.Recurrence = fm.Recurrence
+	note.Pinned = fm.Pinned
+	note.Archived = fm.Archived
+	note.Favorite = fm.Favorite
+
+	return note, nil
+}
+
+// FileName возвращает имя файла для экспорта заметки
+func FileName(note core.Note) string {
+	return fmt.Sprintf("note-%d.md", note.ID)
+}
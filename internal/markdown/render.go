@@ -0,0 +1,126 @@
+package markdown
+
+import (
+	"bytes"
+	"container/list"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// CacheSize — сколько заметок хранится в кэше рендеринга
+const CacheSize = 1000
+
+// cachedHTML — результат рендеринга конкретной версии заметки
+type cachedHTML struct {
+	id      int64
+	version time.Time
+	html    string
+}
+
+// Renderer преобразует содержимое заметок (CommonMark + GFM) в безопасный HTML
+// и кэширует результат последней версии заметки. В кэше не больше
+// limit заметок, при переполнении вытесняется та, к которой дольше
+// всего не обращались.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+
+	mu    sync.Mutex
+	limit int
+	order *list.List // от недавно использованных к давним
+	cache map[int64]*list.Element
+}
+
+func NewRenderer() *Renderer {
+	// Сырой HTML в исходном тексте не выводится goldmark, а итог
+	// дополнительно очищается политикой для пользовательского контента
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+
+	return &Renderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+		),
+		policy: policy,
+		limit:  CacheSize,
+		order:  list.New(),
+		cache:  make(map[int64]*list.Element),
+	}
+}
+
+// Render возвращает HTML для содержимого заметки, используя кэш,
+// если эта версия заметки уже была отрендерена
+func (r *Renderer) Render(note core.Note) (string, error) {
+	version := noteVersion(note)
+
+	if html, ok := r.cached(note.ID, version); ok {
+		return html, nil
+	}
+
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(note.Content), &buf); err != nil {
+		return "", err
+	}
+	html := r.policy.Sanitize(buf.String())
+
+	r.store(cachedHTML{id: note.ID, version: version, html: html})
+	return html, nil
+}
+
+// cached возвращает HTML из кэша, если он отрендерен для этой версии
+func (r *Renderer) cached(id int64, version time.Time) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.cache[id]
+	if !ok || !el.Value.(*cachedHTML).version.Equal(version) {
+		return "", false
+	}
+	r.order.MoveToFront(el)
+	return el.Value.(*cachedHTML).html, true
+}
+
+// store сохраняет результат рендеринга вместо прежней версии заметки
+// и вытесняет давно не использованные заметки сверх limit
+func (r *Renderer) store(entry cachedHTML) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.cache[entry.id]; ok {
+		el.Value = &entry
+		r.order.MoveToFront(el)
+		return
+	}
+	r.cache[entry.id] = r.order.PushFront(&entry)
+	for r.order.Len() > r.limit {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.cache, oldest.Value.(*cachedHTML).id)
+	}
+}
+
+// Invalidate удаляет из кэша результат рендеринга заметки
+func (r *Renderer) Invalidate(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.cache[id]; ok {
+		r.order.Remove(el)
+		delete(r.cache, id)
+	}
+}
+
+// noteVersion определяет версию заметки по времени последнего изменения
+func noteVersion(note core.Note) time.Time {
+	if note.UpdatedAt != nil {
+		return *note.UpdatedAt
+	}
+	return note.CreatedAt
+}
@@ -0,0 +1,77 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+func render(t *testing.T, r *Renderer, note core.Note) string {
+	t.Helper()
+	html, err := r.Render(note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return html
+}
+
+func TestRenderSanitizes(t *testing.T) {
+	html := render(t, NewRenderer(), core.Note{ID: 1, Content: "# Заголовок\n\n<script>alert(1)</script>\n\n- [x] готово"})
+	if !strings.Contains(html, "<h1") || !strings.Contains(html, `type="checkbox"`) {
+		t.Errorf("Render() = %q, нет заголовка или флажка", html)
+	}
+	if strings.Contains(html, "<script") {
+		t.Errorf("Render() = %q, сырой HTML не удален", html)
+	}
+}
+
+func TestRenderCacheVersions(t *testing.T) {
+	r := NewRenderer()
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	note := core.Note{ID: 1, Content: "первая", CreatedAt: created}
+	render(t, r, note)
+
+	// Та же версия берется из кэша, даже если содержимое передано другое
+	note.Content = "вторая"
+	if got := render(t, r, note); !strings.Contains(got, "первая") {
+		t.Errorf("Render() той же версии = %q, want из кэша", got)
+	}
+
+	// Новая версия заменяет прежнюю, а не добавляется к ней
+	updated := created.Add(time.Minute)
+	note.UpdatedAt = &updated
+	if got := render(t, r, note); !strings.Contains(got, "вторая") {
+		t.Errorf("Render() новой версии = %q", got)
+	}
+	if len(r.cache) != 1 || r.order.Len() != 1 {
+		t.Errorf("в кэше %d записей, в очереди %d, want 1", len(r.cache), r.order.Len())
+	}
+
+	r.Invalidate(1)
+	if len(r.cache) != 0 || r.order.Len() != 0 {
+		t.Errorf("после Invalidate в кэше %d записей, в очереди %d", len(r.cache), r.order.Len())
+	}
+	r.Invalidate(1)
+}
+
+func TestRenderCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	r := NewRenderer()
+	r.limit = 2
+	note := func(id int64) core.Note { return core.Note{ID: id, Content: "заметка"} }
+
+	render(t, r, note(1))
+	render(t, r, note(2))
+	render(t, r, note(1)) // 1 использована недавно, вытесняется 2
+	render(t, r, note(3))
+
+	if len(r.cache) != 2 || r.order.Len() != 2 {
+		t.Fatalf("в кэше %d записей, в очереди %d, want 2", len(r.cache), r.order.Len())
+	}
+	for id, want := range map[int64]bool{1: true, 2: false, 3: true} {
+		if _, ok := r.cache[id]; ok != want {
+			t.Errorf("заметка %d в кэше: %v, want %v", id, ok, want)
+		}
+	}
+}
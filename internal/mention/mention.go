@@ -0,0 +1,44 @@
+// Package mention находит упоминания пользователей вида @имя в тексте.
+package mention
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionRe находит @имя в начале текста или после символа, который не может
+// быть частью имени, чтобы не срабатывать на адресах почты
+var mentionRe = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@-])@([\p{L}\p{N}_][\p{L}\p{N}_.-]*)`)
+
+// Parse возвращает упомянутых пользователей без повторов, в порядке появления
+func Parse(text string) []string {
+	var users []string
+	seen := make(map[string]bool)
+	for _, m := range mentionRe.FindAllStringSubmatch(text, -1) {
+		// Точка или дефис в конце — знак препинания, а не часть имени
+		user := strings.TrimRight(m[1], ".-")
+		key := strings.ToLower(user)
+		if user == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		users = append(users, user)
+	}
+	return users
+}
+
+// Added возвращает упоминания из current, которых не было в previous
+func Added(previous, current []string) []string {
+	old := make(map[string]bool, len(previous))
+	for _, user := range previous {
+		old[strings.ToLower(user)] = true
+	}
+
+	var added []string
+	for _, user := range current {
+		if !old[strings.ToLower(user)] {
+			added = append(added, user)
+		}
+	}
+	return added
+}
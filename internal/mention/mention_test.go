@@ -0,0 +1,56 @@
+package mention
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "без упоминаний", text: "просто текст", want: nil},
+		{name: "в начале текста", text: "@alice посмотри", want: []string{"alice"}},
+		{name: "несколько", text: "@alice и @bob, гляньте", want: []string{"alice", "bob"}},
+		{name: "подряд через запятую", text: "@alice,@bob", want: []string{"alice", "bob"}},
+		{name: "кириллица", text: "спасибо, @Мария!", want: []string{"Мария"}},
+		{name: "точка в конце предложения", text: "спроси @bob.", want: []string{"bob"}},
+		{name: "точка внутри имени", text: "@john.smith ok", want: []string{"john.smith"}},
+		{name: "дефис в конце", text: "@bob- привет", want: []string{"bob"}},
+		{name: "адрес почты", text: "пиши на bob@example.com", want: nil},
+		{name: "двойная собака", text: "@@alice", want: nil},
+		{name: "повтор без учета регистра", text: "@Alice и @alice", want: []string{"Alice"}},
+		{name: "в скобках", text: "(@alice)", want: []string{"alice"}},
+		{name: "одна собака", text: "@ alice", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdded(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current []string
+		want              []string
+	}{
+		{name: "новые", previous: nil, current: []string{"alice"}, want: []string{"alice"}},
+		{name: "без изменений", previous: []string{"alice"}, current: []string{"alice"}, want: nil},
+		{name: "другой регистр", previous: []string{"Alice"}, current: []string{"alice"}, want: nil},
+		{name: "добавлен один", previous: []string{"alice"}, current: []string{"alice", "bob"}, want: []string{"bob"}},
+		{name: "удален", previous: []string{"alice", "bob"}, current: []string{"bob"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Added(tt.previous, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Added(%q, %q) = %q, want %q", tt.previous, tt.current, got, tt.want)
+			}
+		})
+	}
+}
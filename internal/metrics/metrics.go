@@ -0,0 +1,131 @@
+// Package metrics собирает метрики сервера в формате Prometheus:
+// HTTP-запросы, вызовы сервиса заметок, размер хранилища и состояние
+// среды выполнения Go. Метрики отдаются обработчиком Handler, поэтому
+// для проверки достаточно запросить /metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace — общий префикс имен метрик
+const namespace = "notes"
+
+// unmatchedRoute — метка запросов, не совпавших ни с одним маршрутом;
+// исходный путь не используется, чтобы не плодить временные ряды
+const unmatchedRoute = "unmatched"
+
+// Metrics хранит реестр и метрики сервера
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	serviceDuration *prometheus.HistogramVec
+	serviceErrors   *prometheus.CounterVec
+}
+
+// New создает реестр с метриками сервера и среды выполнения Go
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Число обработанных HTTP-запросов.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Время обработки HTTP-запросов.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		serviceDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "note_service",
+			Name:      "call_duration_seconds",
+			Help:      "Время выполнения методов сервиса заметок.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		serviceErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "note_service",
+			Name:      "errors_total",
+			Help:      "Число вызовов методов сервиса заметок, завершившихся ошибкой.",
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.serviceDuration,
+		m.serviceErrors,
+	)
+	return m
+}
+
+// Handler отдает метрики в текстовом формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterGauge добавляет метрику, значение которой вычисляется при
+// каждом сборе, например размер хранилища
+func (m *Metrics) RegisterGauge(subsystem, name, help string, value func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, value))
+}
+
+// Middleware считает HTTP-запросы и время их обработки. Маршрут берется
+// из шаблона chi (/api/v1/notes/{id}), а не из пути запроса.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(status)}
+		m.httpRequests.With(labels).Inc()
+		m.httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// track начинает замер вызова метода сервиса; возвращенная функция
+// вызывается через defer с указателем на именованную ошибку:
+//
+//	defer s.m.track("GetNote")(&err)
+func (m *Metrics) track(method string) func(err *error) {
+	start := time.Now()
+	return func(err *error) {
+		m.serviceDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if *err != nil {
+			m.serviceErrors.WithLabelValues(method).Inc()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/http/handlers"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+// scrape запрашивает метрики так же, как Prometheus
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", rec.Code)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestMiddlewareAndServiceMetrics(t *testing.T) {
+	m := New()
+	notes := repo.NewNoteRepoMem()
+	validator := validation.New(validation.DefaultRules())
+	noteService := InstrumentNoteService(service.NewNoteService(notes, repo.NewLinkRepoMem(), repo.NewCommentRepoMem(), validator), m)
+	m.RegisterGauge("repository", "notes", "Число заметок в хранилище.", func() float64 { return float64(notes.Len()) })
+	if _, err := noteService.CreateNote(context.Background(), core.Note{Title: "Первая"}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := handlers.NewHandler(noteService, service.NewTemplateService(repo.NewTemplateRepoMem(), validator))
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Post("/api/v1/notes", h.CreateNote)
+	r.Get("/api/v1/notes/{id}", h.GetNote)
+
+	requests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/api/v1/notes/1", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/notes/999", "", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/notes", `{"title":"Вторая"}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/notes", `{"title":""}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/v1/missing/1", "", http.StatusNotFound},
+	}
+	for _, req := range requests {
+		hr := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body))
+		hr.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, hr)
+		if rec.Code != req.want {
+			t.Fatalf("%s %s = %d, want %d", req.method, req.path, rec.Code, req.want)
+		}
+	}
+
+	got := scrape(t, m)
+	for _, want := range []string{
+		// Маршрут — шаблон chi, статус — код ответа
+		`notes_http_requests_total{method="GET",route="/api/v1/notes/{id}",status="200"} 1`,
+		`notes_http_requests_total{method="GET",route="/api/v1/notes/{id}",status="404"} 1`,
+		`notes_http_requests_total{method="POST",route="/api/v1/notes",status="201"} 1`,
+		`notes_http_requests_total{method="POST",route="/api/v1/notes",status="400"} 1`,
+		`notes_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`notes_http_request_duration_seconds_count{method="GET",route="/api/v1/notes/{id}",status="200"} 1`,
+		// Вызовы сервиса считаются вместе с вызовом из теста;
+		// после создания обработчик читает заметку для ответа
+		`notes_note_service_call_duration_seconds_count{method="CreateNote"} 3`,
+		`notes_note_service_call_duration_seconds_count{method="GetNote"} 3`,
+		`notes_note_service_errors_total{method="CreateNote"} 1`,
+		`notes_note_service_errors_total{method="GetNote"} 1`,
+		`notes_repository_notes 2`,
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("в /metrics нет %s", want)
+		}
+	}
+	for _, path := range []string{"/api/v1/notes/1", "/api/v1/notes/999", "/api/v1/missing/1"} {
+		if strings.Contains(got, `route="`+path+`"`) {
+			t.Errorf("путь запроса %s попал в метку route", path)
+		}
+	}
+	if !strings.Contains(got, "go_goroutines ") {
+		t.Error("в /metrics нет метрик среды выполнения Go")
+	}
+}
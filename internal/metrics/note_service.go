@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+)
+
+// noteService оборачивает service.NoteService и измеряет его методы
+type noteService struct {
+	next service.NoteService
+	m    *Metrics
+}
+
+// InstrumentNoteService возвращает сервис заметок, который записывает
+// длительность и ошибки каждого вызова next
+func InstrumentNoteService(next service.NoteService, m *Metrics) service.NoteService {
+	return &noteService{next: next, m: m}
+}
+
+func (s *noteService) CreateNote(ctx context.Context, note core.Note) (id int64, err error) {
+	defer s.m.track("CreateNote")(&err)
+	return s.next.CreateNote(ctx, note)
+}
+
+func (s *noteService) GetNote(ctx context.Context, id int64) (note *core.Note, err error) {
+	defer s.m.track("GetNote")(&err)
+	return s.next.GetNote(ctx, id)
+}
+
+func (s *noteService) GetAllNotes(ctx context.Context) (notes []core.Note, err error) {
+	defer s.m.track("GetAllNotes")(&err)
+	return s.next.GetAllNotes(ctx)
+}
+
+func (s *noteService) ListNotes(ctx context.Context, filter core.NoteFilter) (notes []core.Note, err error) {
+	defer s.m.track("ListNotes")(&err)
+	return s.next.ListNotes(ctx, filter)
+}
+
+func (s *noteService) UpdateNote(ctx context.Context, id int64, updates service.UpdateNoteRequest) (err error) {
+	defer s.m.track("UpdateNote")(&err)
+	return s.next.UpdateNote(ctx, id, updates)
+}
+
+func (s *noteService) DeleteNote(ctx context.Context, id int64) (err error) {
+	defer s.m.track("DeleteNote")(&err)
+	return s.next.DeleteNote(ctx, id)
+}
+
+func (s *noteService) ApplyBatch(ctx context.Context, ops []core.BatchOp, atomic bool) (results []core.BatchResult, err error) {
+	defer s.m.track("ApplyBatch")(&err)
+	return s.next.ApplyBatch(ctx, ops, atomic)
+}
+
+func (s *noteService) RenderNoteHTML(ctx context.Context, id int64) (html string, err error) {
+	defer s.m.track("RenderNoteHTML")(&err)
+	return s.next.RenderNoteHTML(ctx, id)
+}
+
+func (s *noteService) SetNoteFlag(ctx context.Context, id int64, flag core.NoteFlag, value bool) (note *core.Note, err error) {
+	defer s.m.track("SetNoteFlag")(&err)
+	return s.next.SetNoteFlag(ctx, id, flag, value)
+}
+
+func (s *noteService) GetOutlinks(ctx context.Context, id int64) (links []core.NoteLink, err error) {
+	defer s.m.track("GetOutlinks")(&err)
+	return s.next.GetOutlinks(ctx, id)
+}
+
+func (s *noteService) GetBacklinks(ctx context.Context, id int64) (links []core.NoteLink, err error) {
+	defer s.m.track("GetBacklinks")(&err)
+	return s.next.GetBacklinks(ctx, id)
+}
+
+func (s *noteService) GetBrokenLinks(ctx context.Context) (links []core.NoteLink, err error) {
+	defer s.m.track("GetBrokenLinks")(&err)
+	return s.next.GetBrokenLinks(ctx)
+}
+
+func (s *noteService) RebuildLinks(ctx context.Context) (err error) {
+	defer s.m.track("RebuildLinks")(&err)
+	return s.next.RebuildLinks(ctx)
+}
+
+func (s *noteService) ImportNotes(ctx context.Context, notes []core.Note, opts service.ImportOptions) (result *service.ImportResult, err error) {
+	defer s.m.track("ImportNotes")(&err)
+	return s.next.ImportNotes(ctx, notes, opts)
+}
+
+func (s *noteService) AddChecklistItem(ctx context.Context, noteID int64, text string) (note *core.Note, err error) {
+	defer s.m.track("AddChecklistItem")(&err)
+	return s.next.AddChecklistItem(ctx, noteID, text)
+}
+
+func (s *noteService) ToggleChecklistItem(ctx context.Context, noteID, itemID int64) (note *core.Note, err error) {
+	defer s.m.track("ToggleChecklistItem")(&err)
+	return s.next.ToggleChecklistItem(ctx, noteID, itemID)
+}
+
+func (s *noteService) ReorderChecklistItems(ctx context.Context, noteID int64, itemIDs []int64) (note *core.Note, err error) {
+	defer s.m.track("ReorderChecklistItems")(&err)
+	return s.next.ReorderChecklistItems(ctx, noteID, itemIDs)
+}
+
+func (s *noteService) RemoveChecklistItem(ctx context.Context, noteID, itemID int64) (note *core.Note, err error) {
+	defer s.m.track("RemoveChecklistItem")(&err)
+	return s.next.RemoveChecklistItem(ctx, noteID, itemID)
+}
+
+func (s *noteService) CompleteReminder(ctx context.Context, id int64, at time.Time) (err error) {
+	defer s.m.track("CompleteReminder")(&err)
+	return s.next.CompleteReminder(ctx, id, at)
+}
+
+func (s *noteService) OnSave(hook service.NoteSaveHook) {
+	s.next.OnSave(hook)
+}
+
+func (s *noteService) OnDelete(hook service.NoteDeleteHook) {
+	s.next.OnDelete(hook)
+}
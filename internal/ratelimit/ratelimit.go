@@ -0,0 +1,146 @@
+// Package ratelimit описывает лимиты частоты запросов и хранилище
+// их состояния по алгоритму token bucket.
+//
+// Лимит записывается как «запросов/период», например 60/m или 10/30s:
+// корзина вмещает указанное число запросов и полностью наполняется
+// за период. Лимиты маршрутов задаются списком через запятую:
+//
+//	POST /api/v1/notes=60/m, /api/v1/admin/*=10/m
+//
+// Шаблон маршрута совпадает с шаблоном chi (как в журнале и метриках);
+// шаблон с /* на конце охватывает все маршруты под этим префиксом и дает
+// им одну общую корзину. Метод можно опустить; лимит 0 снимает ограничение.
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit — число запросов за период; нулевой лимит не ограничивает запросы
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Unlimited сообщает, что лимит не ограничивает запросы
+func (l Limit) Unlimited() bool {
+	return l.Requests == 0
+}
+
+// Policy возвращает лимит в формате заголовка RateLimit-Policy: 60;w=60
+func (l Limit) Policy() string {
+	return fmt.Sprintf("%d;w=%d", l.Requests, int(l.Period/time.Second))
+}
+
+var periodUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseLimit разбирает лимит вида 60/m, 10/30s или 0
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" {
+		return Limit{}, nil
+	}
+
+	count, period, ok := strings.Cut(s, "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("неверный лимит %q: ожидается запросов/период, например 60/m", s)
+	}
+	d, ok := periodUnits[period]
+	if !ok {
+		d, err = time.ParseDuration(period)
+		if err != nil || d < time.Second {
+			return Limit{}, fmt.Errorf("неверный период лимита %q: ожидается s, m, h или длительность от 1s", s)
+		}
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Rule — лимит маршрута; пустой Method подходит для любого метода
+type Rule struct {
+	Method  string
+	Pattern string
+	Limit   Limit
+}
+
+// Name возвращает имя правила, по которому разделяются корзины клиентов
+func (r Rule) Name() string {
+	if r.Method == "" {
+		return r.Pattern
+	}
+	return r.Method + " " + r.Pattern
+}
+
+// Rules — лимит по умолчанию и лимиты отдельных маршрутов
+type Rules struct {
+	Default Limit
+	Routes  []Rule
+}
+
+// ParseRules разбирает лимит по умолчанию и список лимитов маршрутов
+func ParseRules(def, routes string) (*Rules, error) {
+	limit, err := ParseLimit(def)
+	if err != nil {
+		return nil, err
+	}
+	rules := &Rules{Default: limit}
+
+	for _, item := range strings.Split(routes, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		route, spec, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("неверное правило %q: ожидается [МЕТОД] шаблон=запросов/период", item)
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		rule := Rule{Limit: limit}
+		fields := strings.Fields(route)
+		switch len(fields) {
+		case 1:
+			rule.Pattern = fields[0]
+		case 2:
+			rule.Method, rule.Pattern = strings.ToUpper(fields[0]), fields[1]
+		default:
+			return nil, fmt.Errorf("неверное правило %q: ожидается [МЕТОД] шаблон=запросов/период", item)
+		}
+		if !strings.HasPrefix(rule.Pattern, "/") {
+			return nil, fmt.Errorf("неверное правило %q: шаблон маршрута должен начинаться с /", item)
+		}
+		rules.Routes = append(rules.Routes, rule)
+	}
+	return rules, nil
+}
+
+// Match возвращает имя корзины и лимит для запроса method к маршруту
+// pattern: первое подходящее правило, иначе лимит по умолчанию
+func (r *Rules) Match(method, pattern string) (string, Limit) {
+	for _, rule := range r.Routes {
+		if rule.matches(method, pattern) {
+			return rule.Name(), rule.Limit
+		}
+	}
+	return "default", r.Default
+}
+
+func (r Rule) matches(method, pattern string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Pattern, "/*"); ok {
+		return strings.HasPrefix(pattern, prefix+"/")
+	}
+	return r.Pattern == pattern
+}
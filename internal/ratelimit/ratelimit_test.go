@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "60/m", want: Limit{Requests: 60, Period: time.Minute}},
+		{in: " 10/s ", want: Limit{Requests: 10, Period: time.Second}},
+		{in: "5/h", want: Limit{Requests: 5, Period: time.Hour}},
+		{in: "10/30s", want: Limit{Requests: 10, Period: 30 * time.Second}},
+		{in: "0", want: Limit{}},
+		{in: "", wantErr: true},
+		{in: "60", wantErr: true},
+		{in: "-1/m", wantErr: true},
+		{in: "0/m", wantErr: true},
+		{in: "x/m", wantErr: true},
+		{in: "10/x", wantErr: true},
+		{in: "10/500ms", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimit(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("600/m", " post /api/v1/notes=60/m, /api/v1/admin/*=10/m ,, GET /api/v1/notes/{id}=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.Default != (Limit{Requests: 600, Period: time.Minute}) {
+		t.Errorf("Default = %+v", rules.Default)
+	}
+	want := []Rule{
+		{Method: "POST", Pattern: "/api/v1/notes", Limit: Limit{Requests: 60, Period: time.Minute}},
+		{Pattern: "/api/v1/admin/*", Limit: Limit{Requests: 10, Period: time.Minute}},
+		{Method: "GET", Pattern: "/api/v1/notes/{id}"},
+	}
+	if len(rules.Routes) != len(want) {
+		t.Fatalf("Routes = %+v, want %+v", rules.Routes, want)
+	}
+	for i := range want {
+		if rules.Routes[i] != want[i] {
+			t.Errorf("Routes[%d] = %+v, want %+v", i, rules.Routes[i], want[i])
+		}
+	}
+
+	for _, routes := range []string{
+		"/api/v1/notes",
+		"notes=1/m",
+		"POST GET /api/v1/notes=1/m",
+		"/api/v1/notes=1/x",
+		"=1/m",
+	} {
+		if _, err := ParseRules("0", routes); err == nil {
+			t.Errorf("ParseRules(%q): ожидалась ошибка", routes)
+		}
+	}
+	if _, err := ParseRules("bad", ""); err == nil {
+		t.Error("ParseRules с неверным лимитом по умолчанию: ожидалась ошибка")
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	rules, err := ParseRules("600/m", "POST /api/v1/notes=60/m, /api/v1/admin/*=10/m, /api/v1/notes/{id}=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method, pattern string
+		wantName        string
+		wantRequests    int
+	}{
+		{"POST", "/api/v1/notes", "POST /api/v1/notes", 60},
+		{"GET", "/api/v1/notes", "default", 600},
+		{"GET", "/api/v1/admin/export", "/api/v1/admin/*", 10},
+		{"PUT", "/api/v1/admin/log-level", "/api/v1/admin/*", 10},
+		{"GET", "/api/v1/admin", "default", 600},
+		{"GET", "/api/v1/administrator", "default", 600},
+		{"DELETE", "/api/v1/notes/{id}", "/api/v1/notes/{id}", 0},
+		{"GET", "/api/v1/notes/{id}/comments", "default", 600},
+	}
+	for _, tt := range tests {
+		name, limit := rules.Match(tt.method, tt.pattern)
+		if name != tt.wantName || limit.Requests != tt.wantRequests {
+			t.Errorf("Match(%s %s) = %q, %d; want %q, %d", tt.method, tt.pattern, name, limit.Requests, tt.wantName, tt.wantRequests)
+		}
+	}
+}
+
+func TestLimitPolicy(t *testing.T) {
+	if got := (Limit{Requests: 60, Period: time.Minute}).Policy(); got != "60;w=60" {
+		t.Errorf("Policy() = %q", got)
+	}
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock — управляемое время для MemoryStore
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.now = clock.now
+	s.lastSweep = clock.t
+	return s, clock
+}
+
+func take(t *testing.T, s *MemoryStore, key string, limit Limit) Result {
+	t.Helper()
+	res, err := s.Take(context.Background(), key, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestMemoryStoreBurstAndRefill(t *testing.T) {
+	s, clock := newTestStore()
+	limit := Limit{Requests: 3, Period: 3 * time.Second} // 1 запрос в секунду
+
+	for i := 2; i >= 0; i-- {
+		res := take(t, s, "k", limit)
+		if !res.Allowed || res.Remaining != i || res.Limit != 3 {
+			t.Fatalf("запрос %d: %+v", 3-i, res)
+		}
+	}
+
+	res := take(t, s, "k", limit)
+	if res.Allowed {
+		t.Fatal("четвертый запрос подряд должен быть отклонен")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", res.RetryAfter)
+	}
+	if res.Reset != 3*time.Second {
+		t.Errorf("Reset = %v, want 3s", res.Reset)
+	}
+
+	// Через полсекунды токен еще не накоплен
+	clock.advance(500 * time.Millisecond)
+	if res := take(t, s, "k", limit); res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("через 0.5s: %+v", res)
+	}
+
+	// Через секунду после опустошения доступен ровно один запрос
+	clock.advance(500 * time.Millisecond)
+	if res := take(t, s, "k", limit); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("через 1s: %+v", res)
+	}
+	if res := take(t, s, "k", limit); res.Allowed {
+		t.Fatal("второй запрос после пополнения должен быть отклонен")
+	}
+
+	// Корзина не наполняется сверх емкости
+	clock.advance(time.Hour)
+	if res := take(t, s, "k", limit); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("после долгого простоя: %+v", res)
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	s, _ := newTestStore()
+	limit := Limit{Requests: 1, Period: time.Minute}
+
+	if !take(t, s, "a", limit).Allowed {
+		t.Fatal("первый запрос a отклонен")
+	}
+	if take(t, s, "a", limit).Allowed {
+		t.Fatal("второй запрос a должен быть отклонен")
+	}
+	if !take(t, s, "b", limit).Allowed {
+		t.Fatal("корзина b не должна зависеть от a")
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	s, clock := newTestStore()
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+
+	take(t, s, "idle", limit)
+	clock.advance(2 * sweepInterval)
+	take(t, s, "active", limit)
+
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("наполнившаяся корзина не удалена")
+	}
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("активная корзина удалена")
+	}
+}
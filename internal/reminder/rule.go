@@ -0,0 +1,231 @@
+// Package reminder разбирает правила повторения напоминаний и планирует
+// их срабатывание.
+package reminder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Freq определяет единицу периода повторения
+type Freq string
+
+const (
+	Hourly  Freq = "HOURLY"
+	Daily   Freq = "DAILY"
+	Weekly  Freq = "WEEKLY"
+	Monthly Freq = "MONTHLY"
+	Yearly  Freq = "YEARLY"
+)
+
+// untilLayout — формат UNTIL из RFC 5545 (время в UTC)
+const untilLayout = "20060102T150405Z"
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule — упрощенное правило повторения в духе RRULE из RFC 5545,
+// например FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=10.
+// Поддерживаются FREQ, INTERVAL, COUNT, UNTIL и BYDAY (только для WEEKLY).
+type Rule struct {
+	Freq     Freq
+	Interval int
+	// Count — сколько повторений осталось, включая текущее; 0 — без ограничения
+	Count int
+	Until *time.Time
+	ByDay []time.Weekday
+}
+
+// ParseRule разбирает правило повторения. Префикс "RRULE:" допускается.
+func ParseRule(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return Rule{}, errors.New("правило повторения не может быть пустым")
+	}
+
+	rule := Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return Rule{}, fmt.Errorf("неверная часть правила %q", part)
+		}
+		if seen[key] {
+			return Rule{}, fmt.Errorf("повторяющийся параметр %s", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch f := Freq(value); f {
+			case Hourly, Daily, Weekly, Monthly, Yearly:
+				rule.Freq = f
+			default:
+				return Rule{}, fmt.Errorf("неподдерживаемая частота %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Rule{}, errors.New("INTERVAL должен быть положительным числом")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Rule{}, errors.New("COUNT должен быть положительным числом")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := time.Parse(untilLayout, value)
+			if err != nil {
+				if until, err = time.Parse(time.RFC3339, value); err != nil {
+					return Rule{}, errors.New("UNTIL должен быть в формате 20060102T150405Z")
+				}
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				wd, ok := weekdays[strings.TrimSpace(day)]
+				if !ok {
+					return Rule{}, fmt.Errorf("неизвестный день недели %s", day)
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		default:
+			return Rule{}, fmt.Errorf("неподдерживаемый параметр %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, errors.New("не указан параметр FREQ")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return Rule{}, errors.New("COUNT и UNTIL не могут использоваться вместе")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return Rule{}, errors.New("BYDAY поддерживается только для FREQ=WEEKLY")
+	}
+
+	return rule, nil
+}
+
+// String возвращает правило в каноническом виде
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, wd := range r.ByDay {
+			for name, d := range weekdays {
+				if d == wd {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next возвращает первое повторение после from, которое наступает позже after,
+// и правило с уменьшенным остатком COUNT. Пропущенные повторения (например,
+// пока сервер был остановлен) не возвращаются. ok=false, если повторений
+// больше нет.
+func (r Rule) Next(from, after time.Time) (time.Time, Rule, bool) {
+	next := from
+	for {
+		if r.Count == 1 {
+			return time.Time{}, r, false
+		}
+		next = r.step(next)
+		if next.IsZero() || (r.Until != nil && next.After(*r.Until)) {
+			return time.Time{}, r, false
+		}
+		if r.Count > 0 {
+			r.Count--
+		}
+		if next.After(after) {
+			return next, r, true
+		}
+	}
+}
+
+// step возвращает следующее повторение сразу после t
+func (r Rule) step(t time.Time) time.Time {
+	switch r.Freq {
+	case Hourly:
+		return t.Add(time.Duration(r.Interval) * time.Hour)
+	case Daily:
+		return t.AddDate(0, 0, r.Interval)
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return t.AddDate(0, 0, 7*r.Interval)
+		}
+		return r.stepByDay(t)
+	case Monthly:
+		return addMonths(t, r.Interval, 0)
+	case Yearly:
+		return addMonths(t, 0, r.Interval)
+	}
+	return time.Time{}
+}
+
+// stepByDay ищет следующий подходящий день в текущей неделе, а затем
+// в неделе через Interval недель (неделя начинается с понедельника)
+func (r Rule) stepByDay(t time.Time) time.Time {
+	weekStart := t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	for d := t.AddDate(0, 0, 1); d.Before(weekStart.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+		if r.matchesDay(d) {
+			return d
+		}
+	}
+	next := weekStart.AddDate(0, 0, 7*r.Interval)
+	for i := 0; i < 7; i++ {
+		if d := next.AddDate(0, 0, i); r.matchesDay(d) {
+			return d
+		}
+	}
+	return time.Time{}
+}
+
+func (r Rule) matchesDay(t time.Time) bool {
+	for _, wd := range r.ByDay {
+		if t.Weekday() == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// addMonths сдвигает дату на целое число месяцев или лет, пропуская
+// месяцы, в которых нет такого дня (31-е число, 29 февраля)
+func addMonths(t time.Time, months, years int) time.Time {
+	for k := 1; k <= 48; k++ {
+		next := time.Date(t.Year()+years*k, t.Month()+time.Month(months*k), t.Day(),
+			t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		if next.Day() == t.Day() {
+			return next
+		}
+	}
+	return time.Time{}
+}
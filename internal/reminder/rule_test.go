@@ -0,0 +1,158 @@
+package reminder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr string
+	}{
+		{name: "префикс и регистр", rule: "RRULE:freq=daily;interval=2", want: "FREQ=DAILY;INTERVAL=2"},
+		{name: "дни недели", rule: "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=10", want: "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=10"},
+		{name: "UNTIL", rule: "FREQ=MONTHLY;UNTIL=20261231T000000Z", want: "FREQ=MONTHLY;UNTIL=20261231T000000Z"},
+		{name: "пустое", rule: " ", wantErr: "не может быть пустым"},
+		{name: "без FREQ", rule: "COUNT=3", wantErr: "FREQ"},
+		{name: "неизвестная частота", rule: "FREQ=SECONDLY", wantErr: "неподдерживаемая частота"},
+		{name: "нулевой COUNT", rule: "FREQ=DAILY;COUNT=0", wantErr: "COUNT"},
+		{name: "COUNT и UNTIL", rule: "FREQ=DAILY;COUNT=2;UNTIL=20261231T000000Z", wantErr: "COUNT и UNTIL"},
+		{name: "BYDAY не для WEEKLY", rule: "FREQ=DAILY;BYDAY=MO", wantErr: "BYDAY"},
+		{name: "неизвестный день", rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: "неизвестный день"},
+		{name: "повтор параметра", rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: "повторяющийся параметр"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseRule(%q) error = %v, want %q", tt.rule, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", tt.rule, err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("ParseRule(%q).String() = %q, want %q", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleNext(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name      string
+		rule      string
+		from      time.Time
+		after     time.Time
+		want      time.Time
+		wantCount int
+		wantOK    bool
+	}{
+		{
+			name: "ежедневно", rule: "FREQ=DAILY",
+			from: date(2026, 3, 1), after: date(2026, 3, 1),
+			want: date(2026, 3, 2), wantOK: true,
+		},
+		{
+			name: "пропущенные повторения не возвращаются", rule: "FREQ=DAILY;INTERVAL=2",
+			from: date(2026, 3, 1), after: date(2026, 3, 6),
+			want: date(2026, 3, 7), wantOK: true,
+		},
+		{
+			name: "COUNT уменьшается", rule: "FREQ=DAILY;COUNT=3",
+			from: date(2026, 3, 1), after: date(2026, 3, 1),
+			want: date(2026, 3, 2), wantCount: 2, wantOK: true,
+		},
+		{
+			name: "COUNT учитывает пропущенные", rule: "FREQ=DAILY;COUNT=3",
+			from: date(2026, 3, 1), after: date(2026, 3, 2),
+			want: date(2026, 3, 3), wantCount: 1, wantOK: true,
+		},
+		{
+			name: "COUNT исчерпан", rule: "FREQ=DAILY;COUNT=1",
+			from: date(2026, 3, 1), after: date(2026, 3, 1),
+		},
+		{
+			name: "COUNT исчерпан пропущенными", rule: "FREQ=DAILY;COUNT=3",
+			from: date(2026, 3, 1), after: date(2026, 3, 10),
+		},
+		{
+			name: "после UNTIL", rule: "FREQ=WEEKLY;UNTIL=20260305T000000Z",
+			from: date(2026, 3, 1), after: date(2026, 3, 1),
+		},
+		{
+			name: "BYDAY в той же неделе", rule: "FREQ=WEEKLY;BYDAY=MO,TH",
+			from: date(2026, 3, 2), after: date(2026, 3, 2),
+			want: date(2026, 3, 5), wantOK: true,
+		},
+		{
+			name: "BYDAY на следующей неделе", rule: "FREQ=WEEKLY;BYDAY=MO,TH",
+			from: date(2026, 3, 5), after: date(2026, 3, 5),
+			want: date(2026, 3, 9), wantOK: true,
+		},
+		{
+			name: "BYDAY с интервалом", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			from: date(2026, 3, 5), after: date(2026, 3, 5),
+			want: date(2026, 3, 16), wantOK: true,
+		},
+		{
+			name: "BYDAY с воскресеньем в конце недели", rule: "FREQ=WEEKLY;BYDAY=SA,SU",
+			from: date(2026, 3, 7), after: date(2026, 3, 7),
+			want: date(2026, 3, 8), wantOK: true,
+		},
+		{
+			name: "BYDAY и COUNT", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=3",
+			from: date(2026, 3, 2), after: date(2026, 3, 4),
+			want: date(2026, 3, 6), wantCount: 1, wantOK: true,
+		},
+		{
+			name: "31-е пропускает короткие месяцы", rule: "FREQ=MONTHLY",
+			from: date(2026, 1, 31), after: date(2026, 1, 31),
+			want: date(2026, 3, 31), wantOK: true,
+		},
+		{
+			name: "31-е пропускает апрель", rule: "FREQ=MONTHLY",
+			from: date(2026, 3, 31), after: date(2026, 3, 31),
+			want: date(2026, 5, 31), wantOK: true,
+		},
+		{
+			name: "30-е пропускает февраль", rule: "FREQ=MONTHLY",
+			from: date(2026, 1, 30), after: date(2026, 1, 30),
+			want: date(2026, 3, 30), wantOK: true,
+		},
+		{
+			name: "пропущенный месяц не расходует COUNT", rule: "FREQ=MONTHLY;COUNT=2",
+			from: date(2026, 1, 31), after: date(2026, 1, 31),
+			want: date(2026, 3, 31), wantCount: 1, wantOK: true,
+		},
+		{
+			name: "29 февраля раз в четыре года", rule: "FREQ=YEARLY",
+			from: date(2024, 2, 29), after: date(2024, 2, 29),
+			want: date(2028, 2, 29), wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, next, ok := rule.Next(tt.from, tt.after)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Fatalf("Next() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if ok && next.Count != tt.wantCount {
+				t.Errorf("Next() Count = %d, want %d", next.Count, tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package reminder
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/logging"
+)
+
+// Event — срабатывание напоминания по заметке
+type Event struct {
+	NoteID int64
+	Title  string
+	// At — запланированное время напоминания
+	At    time.Time
+	DueAt *time.Time
+}
+
+// Handler обрабатывает сработавшее напоминание. Обработчик должен снять
+// или перенести напоминание в заметке, иначе при следующей загрузке из
+// хранилища оно сработает повторно.
+type Handler func(ctx context.Context, ev Event) error
+
+// Loader возвращает все заметки для восстановления расписания
+type Loader func(ctx context.Context) ([]core.Note, error)
+
+// maxRetryDelay ограничивает паузу перед повтором напоминания,
+// обработчик которого раз за разом завершается ошибкой
+const maxRetryDelay = 24 * time.Hour
+
+// Scheduler хранит очередь ожидающих напоминаний и вызывает Handler, когда
+// наступает их время. Расписание восстанавливается из хранилища при запуске
+// и периодически сверяется с ним, поэтому переживает перезапуск сервера и
+// изменения в обход сервиса (например, восстановление из резервной копии).
+type Scheduler struct {
+	load    Loader
+	handler Handler
+	resync  time.Duration
+
+	mu      sync.Mutex
+	pending map[int64]scheduled
+	queue   eventQueue
+	wake    chan struct{}
+	// version растет при каждом вызове Schedule и Cancel; changed хранит
+	// версию последнего изменения заметки, чтобы Reload не затер более
+	// свежее состояние снимком, прочитанным до этого изменения
+	version  uint64
+	changed  map[int64]uint64
+	failures map[int64]failure
+
+	// lastLoop — время последнего прохода цикла Run в наносекундах Unix,
+	// 0 — Run не выполняется
+	lastLoop atomic.Int64
+}
+
+// NewScheduler создает планировщик, который сверяет расписание с хранилищем
+// каждые resync
+func NewScheduler(load Loader, handler Handler, resync time.Duration) *Scheduler {
+	return &Scheduler{
+		load:     load,
+		handler:  handler,
+		resync:   resync,
+		pending:  make(map[int64]scheduled),
+		changed:  make(map[int64]uint64),
+		failures: make(map[int64]failure),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Schedule ставит, переносит или снимает напоминание по текущему
+// состоянию заметки
+func (s *Scheduler) Schedule(ctx context.Context, note core.Note) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.touch(note.ID)
+	s.schedule(note)
+	return nil
+}
+
+// Cancel снимает напоминание удаленной заметки
+func (s *Scheduler) Cancel(ctx context.Context, noteID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.touch(noteID)
+	delete(s.pending, noteID)
+	delete(s.failures, noteID)
+	return nil
+}
+
+// Reload заменяет расписание напоминаниями из хранилища. Заметки,
+// измененные через Schedule или Cancel во время загрузки, сохраняют
+// текущее расписание: снимок хранилища для них уже устарел.
+func (s *Scheduler) Reload(ctx context.Context) error {
+	s.mu.Lock()
+	start := s.version
+	s.mu.Unlock()
+
+	notes, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.pending
+	s.pending = make(map[int64]scheduled, len(notes))
+	s.queue = nil
+	for id, version := range s.changed {
+		if version <= start {
+			delete(s.changed, id)
+			continue
+		}
+		if p, ok := current[id]; ok {
+			s.pending[id] = p
+			heap.Push(&s.queue, queueEntry{noteID: id, at: p.fireAt})
+		}
+	}
+	for _, note := range notes {
+		if _, fresh := s.changed[note.ID]; !fresh {
+			s.schedule(note)
+		}
+	}
+	// Заметки, удаленные в обход сервиса, больше не повторяются
+	for id := range s.failures {
+		if _, ok := s.pending[id]; !ok {
+			delete(s.failures, id)
+		}
+	}
+	return nil
+}
+
+// Run загружает расписание и обрабатывает напоминания до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		logging.FromContext(ctx).Error("ошибка загрузки напоминаний", slog.Any("error", err))
+	}
+
+	resync := time.NewTicker(s.resync)
+	defer resync.Stop()
+	defer s.lastLoop.Store(0)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		s.lastLoop.Store(time.Now().UnixNano())
+		events, version := s.due(time.Now())
+		for _, ev := range events {
+			err := s.handler(ctx, ev)
+			if err == nil {
+				s.succeeded(ev)
+				continue
+			}
+			attrs := []any{slog.Int64("note_id", ev.NoteID), slog.Any("error", err)}
+			if retryAt, ok := s.failed(ev, version, time.Now()); ok {
+				attrs = append(attrs, slog.Time("retry_at", retryAt))
+			}
+			logging.FromContext(ctx).Error("ошибка обработки напоминания", attrs...)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(s.untilNext(time.Now()))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-s.wake:
+		case <-resync.C:
+			if err := s.Reload(ctx); err != nil {
+				logging.FromContext(ctx).Error("ошибка загрузки напоминаний", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// CheckHealth сообщает об ошибке, если Run не запущен или его цикл
+// не проходил дольше двух периодов сверки
+func (s *Scheduler) CheckHealth(ctx context.Context) error {
+	last := s.lastLoop.Load()
+	if last == 0 {
+		return errors.New("планировщик напоминаний не запущен")
+	}
+	if since := time.Since(time.Unix(0, last)); since > 2*s.resync {
+		return fmt.Errorf("планировщик напоминаний не отвечает %s", since.Round(time.Second))
+	}
+	return nil
+}
+
+// Pending возвращает число ожидающих напоминаний
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.pending)
+}
+
+// touch отмечает изменение заметки в обход снимка хранилища. Вызывается под s.mu.
+func (s *Scheduler) touch(noteID int64) {
+	s.version++
+	s.changed[noteID] = s.version
+}
+
+// schedule обновляет напоминание заметки. Напоминание, обработчик которого
+// завершился ошибкой, ставится не раньше назначенного повтора. Вызывается
+// под s.mu.
+func (s *Scheduler) schedule(note core.Note) {
+	if note.RemindAt == nil {
+		delete(s.pending, note.ID)
+		delete(s.failures, note.ID)
+		return
+	}
+
+	ev := Event{NoteID: note.ID, Title: note.Title, At: *note.RemindAt, DueAt: note.DueAt}
+	fireAt := ev.At
+	if f, ok := s.failures[note.ID]; ok {
+		if !f.at.Equal(ev.At) {
+			// Напоминание перенесли — прежние ошибки к нему не относятся
+			delete(s.failures, note.ID)
+		} else if f.retryAt.After(fireAt) {
+			fireAt = f.retryAt
+		}
+	}
+	s.enqueue(scheduled{Event: ev, fireAt: fireAt})
+}
+
+// enqueue ставит напоминание в очередь, если время срабатывания изменилось.
+// Вызывается под s.mu.
+func (s *Scheduler) enqueue(p scheduled) {
+	old, ok := s.pending[p.NoteID]
+	s.pending[p.NoteID] = p
+	if ok && old.fireAt.Equal(p.fireAt) {
+		return
+	}
+	heap.Push(&s.queue, queueEntry{noteID: p.NoteID, at: p.fireAt})
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// succeeded сбрасывает счетчик ошибок обработанного напоминания
+func (s *Scheduler) succeeded(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.failures[ev.NoteID]; ok && f.at.Equal(ev.At) {
+		delete(s.failures, ev.NoteID)
+	}
+}
+
+// failed назначает повтор напоминания с экспоненциально растущей паузой,
+// начиная с периода сверки, и возвращает время повтора. version — версия
+// расписания на момент извлечения напоминания из очереди. ok=false, если
+// пока выполнялся обработчик, напоминание перенесли или сняли.
+func (s *Scheduler) failed(ev Event, version uint64, now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pending[ev.NoteID]
+	if ok && !p.At.Equal(ev.At) || !ok && s.changed[ev.NoteID] > version {
+		return time.Time{}, false
+	}
+
+	f := s.failures[ev.NoteID]
+	if !f.at.Equal(ev.At) {
+		f = failure{at: ev.At}
+	}
+	f.attempts++
+	f.retryAt = now.Add(retryDelay(s.resync, f.attempts))
+	s.failures[ev.NoteID] = f
+	s.enqueue(scheduled{Event: ev, fireAt: f.retryAt})
+	return f.retryAt, true
+}
+
+// retryDelay возвращает паузу перед попыткой attempts+1
+func retryDelay(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// due извлекает из очереди напоминания, время которых наступило,
+// и возвращает их вместе с текущей версией расписания
+func (s *Scheduler) due(now time.Time) ([]Event, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []Event
+	for len(s.queue) > 0 && !s.queue[0].at.After(now) {
+		entry := heap.Pop(&s.queue).(queueEntry)
+		// Запись устарела, если напоминание с тех пор перенесли или сняли
+		p, ok := s.pending[entry.noteID]
+		if !ok || !p.fireAt.Equal(entry.at) {
+			continue
+		}
+		delete(s.pending, entry.noteID)
+		events = append(events, p.Event)
+	}
+	return events, s.version
+}
+
+// untilNext возвращает время ожидания до ближайшего напоминания
+func (s *Scheduler) untilNext(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return s.resync
+	}
+	if d := s.queue[0].at.Sub(now); d < s.resync {
+		return d
+	}
+	return s.resync
+}
+
+// scheduled — ожидающее напоминание; fireAt позже Event.At, если
+// обработчик уже завершался ошибкой и срабатывание отложено
+type scheduled struct {
+	Event
+	fireAt time.Time
+}
+
+// failure — неудачные попытки обработать напоминание на время at
+type failure struct {
+	at       time.Time
+	attempts int
+	retryAt  time.Time
+}
+
+type queueEntry struct {
+	noteID int64
+	at     time.Time
+}
+
+// eventQueue — куча записей по возрастанию времени
+type eventQueue []queueEntry
+
+func (q eventQueue) Len() int           { return len(q) }
+func (q eventQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+func (q eventQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x any) { *q = append(*q, x.(queueEntry)) }
+
+func (q *eventQueue) Pop() any {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
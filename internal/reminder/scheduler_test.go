@@ -0,0 +1,135 @@
+package reminder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+func reminderNote(id int64, at time.Time) core.Note {
+	return core.Note{ID: id, Title: "Заметка", RemindAt: &at}
+}
+
+func TestReloadKeepsFresherSchedule(t *testing.T) {
+	ctx := context.Background()
+	stale := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	fresh := stale.Add(time.Hour)
+
+	var s *Scheduler
+	load := func(ctx context.Context) ([]core.Note, error) {
+		// Снимок прочитан до того, как заметки 1 и 2 изменились через сервис
+		snapshot := []core.Note{reminderNote(1, stale), reminderNote(2, stale), reminderNote(3, stale)}
+		if err := s.Schedule(ctx, reminderNote(1, fresh)); err != nil {
+			return nil, err
+		}
+		if err := s.Cancel(ctx, 2); err != nil {
+			return nil, err
+		}
+		return snapshot, nil
+	}
+	s = NewScheduler(load, func(context.Context, Event) error { return nil }, time.Minute)
+
+	if err := s.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := s.pending[1]; !ok || !p.At.Equal(fresh) {
+		t.Errorf("заметка 1: pending = %v, %v, want %v", p.At, ok, fresh)
+	}
+	if _, ok := s.pending[2]; ok {
+		t.Error("заметка 2: снятое напоминание восстановлено из снимка")
+	}
+	if p, ok := s.pending[3]; !ok || !p.At.Equal(stale) {
+		t.Errorf("заметка 3: pending = %v, %v, want %v", p.At, ok, stale)
+	}
+
+	// Следующая сверка снова доверяет хранилищу
+	s.load = func(context.Context) ([]core.Note, error) {
+		return []core.Note{reminderNote(1, stale)}, nil
+	}
+	if err := s.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if p := s.pending[1]; !p.At.Equal(stale) || len(s.pending) != 1 {
+		t.Errorf("после второй сверки pending = %v", s.pending)
+	}
+}
+
+func TestFailedHandlerBacksOff(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	now := at.Add(time.Second)
+	snapshot := []core.Note{reminderNote(1, at)}
+	s := NewScheduler(func(context.Context) ([]core.Note, error) { return snapshot, nil },
+		func(context.Context, Event) error { return nil }, time.Minute)
+
+	if err := s.Reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for attempt, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		events, version := s.due(now)
+		if len(events) != 1 {
+			t.Fatalf("попытка %d: due() = %v, want 1 event", attempt+1, events)
+		}
+		if retryAt, ok := s.failed(events[0], version, now); !ok || !retryAt.Equal(now.Add(want)) {
+			t.Errorf("попытка %d: retryAt = %v, want %v", attempt+1, retryAt, now.Add(want))
+		}
+
+		// Сверка с хранилищем, где напоминание еще не снято, не ускоряет повтор
+		if err := s.Reload(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if events, _ := s.due(now); len(events) != 0 {
+			t.Fatalf("попытка %d: напоминание сработало до повтора", attempt+1)
+		}
+		now = now.Add(want)
+	}
+
+	// Перенесенное напоминание срабатывает в новое время без паузы
+	moved := now.Add(time.Second)
+	if err := s.Schedule(ctx, reminderNote(1, moved)); err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := s.due(moved); len(events) != 1 {
+		t.Errorf("перенесенное напоминание: due() = %v, want 1 event", events)
+	}
+}
+
+func TestFailedHandlerAfterCancel(t *testing.T) {
+	at := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewScheduler(nil, nil, time.Minute)
+	if err := s.Schedule(context.Background(), reminderNote(1, at)); err != nil {
+		t.Fatal(err)
+	}
+	events, version := s.due(at)
+	// Заметку удалили, пока обработчик выполнялся
+	if err := s.Cancel(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.failed(events[0], version, at); ok {
+		t.Error("failed() назначил повтор снятого напоминания")
+	}
+	if len(s.pending) != 0 || len(s.failures) != 0 {
+		t.Errorf("pending = %v, failures = %v, want empty", s.pending, s.failures)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 5, want: 16 * time.Minute},
+		{attempts: 11, want: 1024 * time.Minute},
+		{attempts: 12, want: maxRetryDelay},
+		{attempts: 1000, want: maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(time.Minute, tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(1m, %d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
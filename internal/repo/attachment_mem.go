@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+// AttachmentRepository определяет интерфейс хранения метаданных вложений
+type AttachmentRepository interface {
+	Create(ctx context.Context, a core.Attachment) (int64, error)
+	GetByID(ctx context.Context, id int64) (*core.Attachment, error)
+	GetAll(ctx context.Context) ([]core.Attachment, error)
+	ListByNote(ctx context.Context, noteID int64) ([]core.Attachment, error)
+	ListByOwner(ctx context.Context, ownerID string) ([]core.Attachment, error)
+	Update(ctx context.Context, a core.Attachment) error
+	Delete(ctx context.Context, id int64) error
+	// Import добавляет вложения из резервной копии с новыми ID, сохраняя
+	// время создания. Возвращает новые ID в порядке attachments.
+	Import(ctx context.Context, attachments []core.Attachment) ([]int64, error)
+}
+
+// AttachmentRepoMem реализует AttachmentRepository
+type AttachmentRepoMem struct {
+	mu          sync.RWMutex
+	attachments map[int64]*core.Attachment
+	next        int64
+}
+
+func NewAttachmentRepoMem() *AttachmentRepoMem {
+	return &AttachmentRepoMem{
+		attachments: make(map[int64]*core.Attachment),
+		next:        1,
+	}
+}
+
+func (r *AttachmentRepoMem) Create(ctx context.Context, a core.Attachment) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a = copyAttachment(&a)
+	a.ID = r.next
+	a.CreatedAt = time.Now()
+	r.attachments[a.ID] = &a
+	r.next++
+
+	return a.ID, nil
+}
+
+func (r *AttachmentRepoMem) GetByID(ctx context.Context, id int64) (*core.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, exists := r.attachments[id]
+	if !exists {
+		return nil, errors.New("вложение не найдено")
+	}
+
+	aCopy := copyAttachment(a)
+	return &aCopy, nil
+}
+
+func (r *AttachmentRepoMem) GetAll(ctx context.Context) ([]core.Attachment, error) {
+	return r.filter(func(*core.Attachment) bool { return true }), nil
+}
+
+func (r *AttachmentRepoMem) ListByNote(ctx context.Context, noteID int64) ([]core.Attachment, error) {
+	return r.filter(func(a *core.Attachment) bool { return a.NoteID == noteID }), nil
+}
+
+func (r *AttachmentRepoMem) ListByOwner(ctx context.Context, ownerID string) ([]core.Attachment, error) {
+	return r.filter(func(a *core.Attachment) bool { return a.OwnerID == ownerID }), nil
+}
+
+func (r *AttachmentRepoMem) Update(ctx context.Context, a core.Attachment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.attachments[a.ID]; !exists {
+		return errors.New("вложение не найдено")
+	}
+
+	a = copyAttachment(&a)
+	r.attachments[a.ID] = &a
+	return nil
+}
+
+func (r *AttachmentRepoMem) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.attachments[id]; !exists {
+		return errors.New("вложение не найдено")
+	}
+
+	delete(r.attachments, id)
+	return nil
+}
+
+func (r *AttachmentRepoMem) Import(ctx context.Context, attachments []core.Attachment) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	created := make([]int64, len(attachments))
+	for i, a := range attachments {
+		a = copyAttachment(&a)
+		a.ID = r.next
+		if a.CreatedAt.IsZero() {
+			a.CreatedAt = now
+		}
+		r.attachments[a.ID] = &a
+		created[i] = a.ID
+		r.next++
+	}
+
+	return created, nil
+}
+
+func (r *AttachmentRepoMem) filter(match func(*core.Attachment) bool) []core.Attachment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []core.Attachment{}
+	for _, a := range r.attachments {
+		if match(a) {
+			result = append(result, copyAttachment(a))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result
+}
+
+// copyAttachment копирует вложение вместе с картой миниатюр
+func copyAttachment(a *core.Attachment) core.Attachment {
+	aCopy := *a
+	if a.Thumbnails != nil {
+		aCopy.Thumbnails = make(map[int]string, len(a.Thumbnails))
+		for w, hash := range a.Thumbnails {
+			aCopy.Thumbnails[w] = hash
+		}
+	}
+	return aCopy
+}
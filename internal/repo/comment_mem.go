@@ -0,0 +1,224 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+// CommentRepository определяет интерфейс хранения комментариев к заметкам
+type CommentRepository interface {
+	Create(ctx context.Context, c core.Comment) (int64, error)
+	GetByID(ctx context.Context, id int64) (*core.Comment, error)
+	// ListByNote возвращает комментарии заметки в порядке создания
+	ListByNote(ctx context.Context, noteID int64) ([]core.Comment, error)
+	Update(ctx context.Context, c core.Comment) error
+	Delete(ctx context.Context, id int64) error
+	DeleteByNote(ctx context.Context, noteID int64) error
+	// CountByNote возвращает число неудаленных комментариев по заметкам
+	CountByNote(ctx context.Context) (map[int64]int, error)
+	// CountForNote возвращает число неудаленных комментариев заметки
+	CountForNote(ctx context.Context, noteID int64) (int, error)
+	// Import добавляет комментарии из резервной копии с новыми ID, сохраняя
+	// время создания и изменения. ParentID переназначается на новые ID,
+	// поэтому родитель должен идти в comments раньше ответа. Возвращает
+	// новые ID в порядке comments.
+	Import(ctx context.Context, comments []core.Comment) ([]int64, error)
+}
+
+// CommentRepoMem реализует CommentRepository
+type CommentRepoMem struct {
+	mu       sync.RWMutex
+	comments map[int64]*core.Comment
+	// counts — число неудаленных комментариев по заметкам, чтобы чтение
+	// заметки не перебирало все комментарии
+	counts map[int64]int
+	next   int64
+}
+
+func NewCommentRepoMem() *CommentRepoMem {
+	return &CommentRepoMem{
+		comments: make(map[int64]*core.Comment),
+		counts:   make(map[int64]int),
+		next:     1,
+	}
+}
+
+func (r *CommentRepoMem) Create(ctx context.Context, c core.Comment) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c = copyComment(&c)
+	c.ID = r.next
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = nil
+	r.comments[c.ID] = &c
+	r.count(&c, 1)
+	r.next++
+
+	return c.ID, nil
+}
+
+func (r *CommentRepoMem) GetByID(ctx context.Context, id int64) (*core.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.comments[id]
+	if !exists {
+		return nil, errors.New("комментарий не найден")
+	}
+
+	cCopy := copyComment(c)
+	return &cCopy, nil
+}
+
+func (r *CommentRepoMem) ListByNote(ctx context.Context, noteID int64) ([]core.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var comments []core.Comment
+	for _, c := range r.comments {
+		if c.NoteID == noteID {
+			comments = append(comments, copyComment(c))
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].ID < comments[j].ID
+	})
+
+	return comments, nil
+}
+
+func (r *CommentRepoMem) Update(ctx context.Context, c core.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.comments[c.ID]
+	if !exists {
+		return errors.New("комментарий не найден")
+	}
+
+	c = copyComment(&c)
+	c.NoteID = existing.NoteID
+	c.ParentID = existing.ParentID
+	c.AuthorID = existing.AuthorID
+	c.CreatedAt = existing.CreatedAt
+	now := time.Now()
+	c.UpdatedAt = &now
+	r.count(existing, -1)
+	r.comments[c.ID] = &c
+	r.count(&c, 1)
+
+	return nil
+}
+
+func (r *CommentRepoMem) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.comments[id]
+	if !exists {
+		return errors.New("комментарий не найден")
+	}
+
+	r.count(existing, -1)
+	delete(r.comments, id)
+	return nil
+}
+
+func (r *CommentRepoMem) DeleteByNote(ctx context.Context, noteID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, c := range r.comments {
+		if c.NoteID == noteID {
+			delete(r.comments, id)
+		}
+	}
+	delete(r.counts, noteID)
+	return nil
+}
+
+func (r *CommentRepoMem) CountByNote(ctx context.Context) (map[int64]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[int64]int, len(r.counts))
+	for noteID, n := range r.counts {
+		counts[noteID] = n
+	}
+	return counts, nil
+}
+
+func (r *CommentRepoMem) CountForNote(ctx context.Context, noteID int64) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.counts[noteID], nil
+}
+
+// count учитывает неудаленный комментарий в числе комментариев заметки:
+// delta=1 при добавлении, -1 при удалении
+func (r *CommentRepoMem) count(c *core.Comment, delta int) {
+	if c.Deleted {
+		return
+	}
+	r.counts[c.NoteID] += delta
+	if r.counts[c.NoteID] <= 0 {
+		delete(r.counts, c.NoteID)
+	}
+}
+
+func (r *CommentRepoMem) Import(ctx context.Context, comments []core.Comment) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Проверить ссылки на родителей до изменения хранилища
+	ids := make(map[int64]int64, len(comments))
+	created := make([]int64, len(comments))
+	for i, c := range comments {
+		if c.ParentID != nil {
+			if _, ok := ids[*c.ParentID]; !ok {
+				return nil, fmt.Errorf("комментарий %d: родительский комментарий %d не найден", c.ID, *c.ParentID)
+			}
+		}
+		created[i] = r.next + int64(i)
+		ids[c.ID] = created[i]
+	}
+
+	now := time.Now()
+	for i, c := range comments {
+		c = copyComment(&c)
+		c.ID = created[i]
+		if c.ParentID != nil {
+			parentID := ids[*c.ParentID]
+			c.ParentID = &parentID
+		}
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt = now
+		}
+		r.comments[c.ID] = &c
+		r.count(&c, 1)
+	}
+	r.next += int64(len(comments))
+
+	return created, nil
+}
+
+// copyComment возвращает копию комментария, не разделяющую срезы и указатели
+// с хранилищем
+func copyComment(c *core.Comment) core.Comment {
+	cCopy := *c
+	if c.ParentID != nil {
+		parentID := *c.ParentID
+		cCopy.ParentID = &parentID
+	}
+	cCopy.Mentions = append([]string(nil), c.Mentions...)
+	cCopy.Replies = nil
+	return cCopy
+}
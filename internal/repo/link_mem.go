@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/links"
+)
+
+// LinkRepository определяет интерфейс хранения графа ссылок между заметками
+type LinkRepository interface {
+	// ReplaceOutlinks заменяет все исходящие ссылки заметки
+	ReplaceOutlinks(ctx context.Context, sourceID int64, links []core.NoteLink) error
+	GetOutlinks(ctx context.Context, sourceID int64) ([]core.NoteLink, error)
+	GetBacklinks(ctx context.Context, targetID int64) ([]core.NoteLink, error)
+	GetBroken(ctx context.Context) ([]core.NoteLink, error)
+	// GetBrokenSources возвращает заметки с битыми ссылками на цели с
+	// ключами keys (см. links.Ref.Key)
+	GetBrokenSources(ctx context.Context, keys ...string) ([]int64, error)
+	DeleteSource(ctx context.Context, sourceID int64) error
+}
+
+// LinkRepoMem реализует LinkRepository
+type LinkRepoMem struct {
+	mu  sync.RWMutex
+	out map[int64][]core.NoteLink
+	// broken — заметки с битыми ссылками по ключу цели, чтобы при
+	// сохранении заметки не просматривать все битые ссылки
+	broken map[string]map[int64]bool
+}
+
+func NewLinkRepoMem() *LinkRepoMem {
+	return &LinkRepoMem{
+		out:    make(map[int64][]core.NoteLink),
+		broken: make(map[string]map[int64]bool),
+	}
+}
+
+func (r *LinkRepoMem) ReplaceOutlinks(ctx context.Context, sourceID int64, links []core.NoteLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.unindexBroken(sourceID)
+	if len(links) == 0 {
+		delete(r.out, sourceID)
+		return nil
+	}
+
+	r.out[sourceID] = append([]core.NoteLink(nil), links...)
+	for _, link := range links {
+		if !link.Broken {
+			continue
+		}
+		key := linkKey(link)
+		if r.broken[key] == nil {
+			r.broken[key] = make(map[int64]bool)
+		}
+		r.broken[key][sourceID] = true
+	}
+	return nil
+}
+
+func (r *LinkRepoMem) GetOutlinks(ctx context.Context, sourceID int64) ([]core.NoteLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]core.NoteLink{}, r.out[sourceID]...), nil
+}
+
+func (r *LinkRepoMem) GetBacklinks(ctx context.Context, targetID int64) ([]core.NoteLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	backlinks := []core.NoteLink{}
+	for _, links := range r.out {
+		for _, link := range links {
+			if !link.Broken && link.TargetID == targetID {
+				backlinks = append(backlinks, link)
+			}
+		}
+	}
+
+	sortLinks(backlinks)
+	return backlinks, nil
+}
+
+func (r *LinkRepoMem) GetBroken(ctx context.Context) ([]core.NoteLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	broken := []core.NoteLink{}
+	for _, links := range r.out {
+		for _, link := range links {
+			if link.Broken {
+				broken = append(broken, link)
+			}
+		}
+	}
+
+	sortLinks(broken)
+	return broken, nil
+}
+
+func (r *LinkRepoMem) GetBrokenSources(ctx context.Context, keys ...string) ([]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sources []int64
+	seen := make(map[int64]bool)
+	for _, key := range keys {
+		for id := range r.broken[key] {
+			if !seen[id] {
+				seen[id] = true
+				sources = append(sources, id)
+			}
+		}
+	}
+
+	sort.Slice(sources, func(i, j int) bool { return sources[i] < sources[j] })
+	return sources, nil
+}
+
+func (r *LinkRepoMem) DeleteSource(ctx context.Context, sourceID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.unindexBroken(sourceID)
+	delete(r.out, sourceID)
+	return nil
+}
+
+// unindexBroken удаляет битые ссылки заметки из индекса; вызывается под r.mu
+func (r *LinkRepoMem) unindexBroken(sourceID int64) {
+	for _, link := range r.out[sourceID] {
+		if !link.Broken {
+			continue
+		}
+		key := linkKey(link)
+		delete(r.broken[key], sourceID)
+		if len(r.broken[key]) == 0 {
+			delete(r.broken, key)
+		}
+	}
+}
+
+// linkKey возвращает ключ цели ссылки
+func linkKey(link core.NoteLink) string {
+	ref, _ := links.ParseRef(link.Ref)
+	return ref.Key()
+}
+
+func sortLinks(links []core.NoteLink) {
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].SourceID != links[j].SourceID {
+			return links[i].SourceID < links[j].SourceID
+		}
+		return links[i].Ref < links[j].Ref
+	})
+}
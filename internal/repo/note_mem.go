@@ -16,6 +16,18 @@ type NoteRepository interface {
 	GetAll(ctx context.Context) ([]core.Note, error)
 	Update(ctx context.Context, id int64, note core.Note) error
 	Delete(ctx context.Context, id int64) error
+	// Modify атомарно изменяет заметку функцией fn и возвращает результат.
+	// Если fn возвращает ошибку, заметка остается без изменений.
+	Modify(ctx context.Context, id int64, fn func(note *core.Note) error) (*core.Note, error)
+	// Batch выполняет набор операций за один проход. При atomic=true
+	// изменения применяются только если выполнимы все операции
+	// (в SQL-реализациях — в рамках одной транзакции).
+	Batch(ctx context.Context, ops []core.BatchOp, atomic bool) ([]core.BatchResult, error)
+	// Import атомарно добавляет заметки, а при replace заменяет ими все
+	// существующие. Заметки получают новые ID; перед сохранением каждая
+	// передается в fn вместе с соответствием ID из notes новым ID.
+	// Возвращает новые ID в порядке notes и ID удаленных заметок.
+	Import(ctx context.Context, notes []core.Note, replace bool, fn func(note *core.Note, ids map[int64]int64)) (created, deleted []int64, err error)
 }
 
 // NoteRepoMem реализует NoteRepository
@@ -32,12 +44,30 @@ func NewNoteRepoMem() *NoteRepoMem {
 	}
 }
 
+// Len возвращает число заметок в хранилище
+func (r *NoteRepoMem) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.notes)
+}
+
+// CheckHealth проверяет, что хранилище не заблокировано зависшей операцией
+func (r *NoteRepoMem) CheckHealth(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return ctx.Err()
+}
+
 func (r *NoteRepoMem) Create(ctx context.Context, n core.Note) (int64, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	n.ID = r.next
-	n.CreatedAt = time.Now()
+	// Сохранить исходную дату создания при импорте
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	n = copyNote(n)
 	r.notes[n.ID] = &n
 	r.next++
 
@@ -54,7 +84,7 @@ func (r *NoteRepoMem) GetByID(ctx context.Context, id int64) (*core.Note, error)
 	}
 
 	// Вернуть копию
-	noteCopy := *note
+	noteCopy := copyNote(*note)
 	return &noteCopy, nil
 }
 
@@ -64,7 +94,7 @@ func (r *NoteRepoMem) GetAll(ctx context.Context) ([]core.Note, error) {
 
 	notes := make([]core.Note, 0, len(r.notes))
 	for _, note := range r.notes {
-		notes = append(notes, *note)
+		notes = append(notes, copyNote(*note))
 	}
 
 	return notes, nil
@@ -79,6 +109,7 @@ func (r *NoteRepoMem) Update(ctx context.Context, id int64, updatedNote core.Not
 		return errors.New("заметка не найдена")
 	}
 
+	updatedNote = copyNote(updatedNote)
 	updatedNote.ID = id
 	now := time.Now()
 	updatedNote.UpdatedAt = &now
@@ -99,3 +130,176 @@ func (r *NoteRepoMem) Delete(ctx context.Context, id int64) error {
 	delete(r.notes, id)
 	return nil
 }
+
+func (r *NoteRepoMem) Batch(ctx context.Context, ops []core.BatchOp, atomic bool) ([]core.BatchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]core.BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = core.BatchResult{Index: i, Type: op.Type, ID: op.ID}
+	}
+
+	// Предварительная проверка: все операции должны быть выполнимы
+	if atomic {
+		deleted := make(map[int64]bool)
+		failed := false
+		for i, op := range ops {
+			if err := r.checkBatchOp(op, deleted); err != nil {
+				results[i].Err = err
+				failed = true
+			}
+		}
+		if failed {
+			return results, nil
+		}
+	}
+
+	now := time.Now()
+	for i, op := range ops {
+		switch op.Type {
+		case core.BatchCreate:
+			n := core.Note{ID: r.next, CreatedAt: now, Type: core.NoteTypeText}
+			if op.Title != nil {
+				n.Title = *op.Title
+			}
+			if op.Content != nil {
+				n.Content = *op.Content
+			}
+			r.notes[n.ID] = &n
+			r.next++
+			results[i].ID = n.ID
+		case core.BatchUpdate:
+			existing, exists := r.notes[op.ID]
+			if !exists {
+				results[i].Err = errors.New("заметка не найдена")
+				continue
+			}
+			results[i].OldTitle = existing.Title
+			updated := copyNote(*existing)
+			if op.Title != nil {
+				updated.Title = *op.Title
+			}
+			if op.Content != nil {
+				updated.Content = *op.Content
+			}
+			updatedAt := now
+			updated.UpdatedAt = &updatedAt
+			r.notes[op.ID] = &updated
+		case core.BatchDelete:
+			if _, exists := r.notes[op.ID]; !exists {
+				results[i].Err = errors.New("заметка не найдена")
+				continue
+			}
+			delete(r.notes, op.ID)
+		default:
+			results[i].Err = errors.New("неизвестная операция")
+			continue
+		}
+		results[i].Applied = true
+	}
+
+	return results, nil
+}
+
+func (r *NoteRepoMem) Import(ctx context.Context, notes []core.Note, replace bool, fn func(note *core.Note, ids map[int64]int64)) ([]int64, []int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted []int64
+	if replace {
+		for id := range r.notes {
+			deleted = append(deleted, id)
+		}
+		r.notes = make(map[int64]*core.Note, len(notes))
+	}
+
+	// При повторяющихся исходных ID ссылки ведут на первую из заметок
+	ids := make(map[int64]int64, len(notes))
+	created := make([]int64, len(notes))
+	for i, n := range notes {
+		created[i] = r.next + int64(i)
+		if _, exists := ids[n.ID]; n.ID > 0 && !exists {
+			ids[n.ID] = created[i]
+		}
+	}
+
+	now := time.Now()
+	for i, n := range notes {
+		n = copyNote(n)
+		if fn != nil {
+			fn(&n, ids)
+		}
+		n.ID = created[i]
+		if n.CreatedAt.IsZero() {
+			n.CreatedAt = now
+		}
+		r.notes[n.ID] = &n
+	}
+	r.next += int64(len(notes))
+
+	return created, deleted, nil
+}
+
+func (r *NoteRepoMem) Modify(ctx context.Context, id int64, fn func(note *core.Note) error) (*core.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.notes[id]
+	if !exists {
+		return nil, errors.New("заметка не найдена")
+	}
+
+	// Изменять копию, чтобы при ошибке заметка осталась прежней
+	modified := copyNote(*existing)
+	if err := fn(&modified); err != nil {
+		return nil, err
+	}
+	modified.ID = id
+	now := time.Now()
+	modified.UpdatedAt = &now
+	r.notes[id] = &modified
+
+	result := copyNote(modified)
+	return &result, nil
+}
+
+// checkBatchOp проверяет выполнимость операции с учётом удалений,
+// уже запланированных в том же пакете
+func (r *NoteRepoMem) checkBatchOp(op core.BatchOp, deleted map[int64]bool) error {
+	switch op.Type {
+	case core.BatchCreate:
+		return nil
+	case core.BatchUpdate, core.BatchDelete:
+		if _, exists := r.notes[op.ID]; !exists || deleted[op.ID] {
+			return errors.New("заметка не найдена")
+		}
+		if op.Type == core.BatchDelete {
+			deleted[op.ID] = true
+		}
+		return nil
+	default:
+		return errors.New("неизвестная операция")
+	}
+}
+
+// copyNote копирует заметку вместе с пунктами списка
+func copyNote(n core.Note) core.Note {
+	if n.Items != nil {
+		n.Items = append([]core.ChecklistItem(nil), n.Items...)
+	}
+	if n.Tags != nil {
+		n.Tags = append([]string(nil), n.Tags...)
+	}
+	n.Progress = nil
+	n.CommentCount = 0
+	if n.DueAt != nil {
+		dueAt := *n.DueAt
+		n.DueAt = &dueAt
+	}
+	if n.RemindAt != nil {
+		remindAt := *n.RemindAt
+		n.RemindAt = &remindAt
+	}
+	return n
+}
@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+)
+
+// TemplateRepository определяет интерфейс хранения шаблонов заметок
+type TemplateRepository interface {
+	Create(ctx context.Context, t core.Template) (int64, error)
+	GetByID(ctx context.Context, id int64) (*core.Template, error)
+	GetAll(ctx context.Context) ([]core.Template, error)
+	Update(ctx context.Context, id int64, t core.Template) error
+	Delete(ctx context.Context, id int64) error
+	// Import добавляет шаблоны из резервной копии с новыми ID, сохраняя
+	// время создания и изменения; replace сначала удаляет все шаблоны.
+	// Возвращает новые ID в порядке templates и число удаленных шаблонов.
+	Import(ctx context.Context, templates []core.Template, replace bool) ([]int64, int, error)
+}
+
+// TemplateRepoMem реализует TemplateRepository
+type TemplateRepoMem struct {
+	mu        sync.RWMutex
+	templates map[int64]*core.Template
+	next      int64
+}
+
+func NewTemplateRepoMem() *TemplateRepoMem {
+	return &TemplateRepoMem{
+		templates: make(map[int64]*core.Template),
+		next:      1,
+	}
+}
+
+func (r *TemplateRepoMem) Create(ctx context.Context, t core.Template) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t.ID = r.next
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = nil
+	r.templates[t.ID] = &t
+	r.next++
+
+	return t.ID, nil
+}
+
+func (r *TemplateRepoMem) GetByID(ctx context.Context, id int64) (*core.Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, exists := r.templates[id]
+	if !exists {
+		return nil, errors.New("шаблон не найден")
+	}
+
+	tCopy := *t
+	return &tCopy, nil
+}
+
+func (r *TemplateRepoMem) GetAll(ctx context.Context) ([]core.Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	templates := make([]core.Template, 0, len(r.templates))
+	for _, t := range r.templates {
+		templates = append(templates, *t)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].ID < templates[j].ID
+	})
+
+	return templates, nil
+}
+
+func (r *TemplateRepoMem) Update(ctx context.Context, id int64, t core.Template) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.templates[id]
+	if !exists {
+		return errors.New("шаблон не найден")
+	}
+
+	t.ID = id
+	t.CreatedAt = existing.CreatedAt
+	now := time.Now()
+	t.UpdatedAt = &now
+	r.templates[id] = &t
+
+	return nil
+}
+
+func (r *TemplateRepoMem) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.templates[id]; !exists {
+		return errors.New("шаблон не найден")
+	}
+
+	delete(r.templates, id)
+	return nil
+}
+
+func (r *TemplateRepoMem) Import(ctx context.Context, templates []core.Template, replace bool) ([]int64, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	if replace {
+		deleted = len(r.templates)
+		r.templates = make(map[int64]*core.Template, len(templates))
+	}
+
+	now := time.Now()
+	created := make([]int64, len(templates))
+	for i, t := range templates {
+		t.ID = r.next
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		r.templates[t.ID] = &t
+		created[i] = t.ID
+		r.next++
+	}
+
+	return created, deleted, nil
+}
@@ -0,0 +1,185 @@
+// Package templating подставляет переменные в шаблоны заметок.
+//
+// Шаблоны используют синтаксис text/template в ограниченном виде: доступны
+// только подстановки, условия if/with и функции из этого пакета. Циклы,
+// вложенные шаблоны и форматирование через printf запрещены, а размер
+// результата ограничен, поэтому шаблон пользователя не может исчерпать
+// память или процессорное время сервера.
+package templating
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+const (
+	// MaxTemplateSize ограничивает размер исходного текста шаблона
+	MaxTemplateSize = 10000
+	// maxOutputSize ограничивает размер результата подстановки
+	maxOutputSize = 64 << 10
+)
+
+var errOutputTooLarge = errors.New("результат шаблона слишком большой")
+
+// Context — значения, доступные шаблону
+type Context struct {
+	Now   time.Time
+	User  string
+	Title string
+	// Vars доступны в шаблоне как {{.имя}}
+	Vars map[string]string
+}
+
+// Validate проверяет синтаксис шаблона и отсутствие запрещенных конструкций
+func Validate(text string) error {
+	_, err := parseTemplate(text, Context{})
+	return err
+}
+
+// Render подставляет значения из c в шаблон. Переменные, которых нет
+// в c.Vars, подставляются пустой строкой, поэтому их можно проверять в if.
+func Render(text string, c Context) (string, error) {
+	t, err := parseTemplate(text, c)
+	if err != nil {
+		return "", err
+	}
+
+	vars := c.Vars
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&limitedWriter{buf: &buf, left: maxOutputSize}, vars); err != nil {
+		if errors.Is(err, errOutputTooLarge) {
+			return "", errOutputTooLarge
+		}
+		return "", fmt.Errorf("ошибка шаблона: %s", cleanError(err))
+	}
+	return buf.String(), nil
+}
+
+// parseTemplate разбирает шаблон с функциями для контекста c
+func parseTemplate(text string, c Context) (*template.Template, error) {
+	if len(text) > MaxTemplateSize {
+		return nil, fmt.Errorf("шаблон не может превышать %d байт", MaxTemplateSize)
+	}
+
+	t, err := template.New("note").Option("missingkey=zero").Funcs(funcs(c)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка шаблона: %s", cleanError(err))
+	}
+	if len(t.Templates()) > 1 {
+		return nil, errors.New("ошибка шаблона: вложенные шаблоны не поддерживаются")
+	}
+	if t.Tree != nil {
+		if err := checkNode(t.Tree.Root); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// forbiddenFuncs — встроенные функции text/template, позволяющие вызывать
+// произвольный код или раздувать результат (например, printf "%0999999999d")
+var forbiddenFuncs = map[string]bool{
+	"call":    true,
+	"print":   true,
+	"printf":  true,
+	"println": true,
+}
+
+// funcs возвращает функции шаблона
+func funcs(c Context) template.FuncMap {
+	return template.FuncMap{
+		"date":     func() string { return c.Now.Format("2006-01-02") },
+		"time":     func() string { return c.Now.Format("15:04") },
+		"datetime": func() string { return c.Now.Format("2006-01-02 15:04") },
+		"user":     func() string { return c.User },
+		"title":    func() string { return c.Title },
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+	}
+}
+
+// checkNode запрещает циклы, вызовы других шаблонов и опасные функции
+func checkNode(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ActionNode:
+		return checkPipe(n.Pipe)
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkNode(child); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.RangeNode, *parse.BreakNode, *parse.ContinueNode:
+		return errors.New("ошибка шаблона: циклы не поддерживаются")
+	case *parse.TemplateNode:
+		return errors.New("ошибка шаблона: вложенные шаблоны не поддерживаются")
+	}
+	return nil
+}
+
+func checkBranch(b *parse.BranchNode) error {
+	if err := checkPipe(b.Pipe); err != nil {
+		return err
+	}
+	if err := checkNode(b.List); err != nil {
+		return err
+	}
+	return checkNode(b.ElseList)
+}
+
+// checkPipe проверяет функции в конвейере, включая вложенные в скобках
+func checkPipe(pipe *parse.PipeNode) error {
+	if pipe == nil {
+		return nil
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.IdentifierNode:
+				if forbiddenFuncs[a.Ident] {
+					return fmt.Errorf("ошибка шаблона: функция %s недоступна", a.Ident)
+				}
+			case *parse.PipeNode:
+				if err := checkPipe(a); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// cleanError убирает из сообщения text/template служебное имя шаблона
+func cleanError(err error) string {
+	return strings.TrimPrefix(err.Error(), "template: ")
+}
+
+// limitedWriter прерывает выполнение шаблона, если результат превышает лимит
+type limitedWriter struct {
+	buf  *bytes.Buffer
+	left int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.left {
+		return 0, errOutputTooLarge
+	}
+	w.left -= len(p)
+	return w.buf.Write(p)
+}
@@ -0,0 +1,96 @@
+package templating
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	c := Context{
+		Now:   time.Date(2026, 10, 19, 9, 5, 0, 0, time.UTC),
+		User:  "alice",
+		Title: "Встреча",
+		Vars:  map[string]string{"project": "Заметки", "empty": "", "tag": "<b>"},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "без подстановок", text: "просто текст", want: "просто текст"},
+		{name: "дата и время", text: "{{date}} {{time}} / {{datetime}}", want: "2026-10-19 09:05 / 2026-10-19 09:05"},
+		{name: "пользователь и заголовок", text: "{{user}}: {{title}}", want: "alice: Встреча"},
+		{name: "переменная", text: "Проект: {{.project}}", want: "Проект: Заметки"},
+		{name: "неизвестная переменная пустая", text: "[{{.missing}}]", want: "[]"},
+		{name: "регистр", text: "{{upper .project}} {{lower (title)}}", want: "ЗАМЕТКИ встреча"},
+		{name: "if с переменной", text: "{{if .project}}есть{{else}}нет{{end}}", want: "есть"},
+		{name: "if с пустой переменной", text: "{{if .empty}}есть{{else}}нет{{end}}", want: "нет"},
+		{name: "with", text: "{{with .project}}<{{.}}>{{end}}", want: "<Заметки>"},
+		{name: "HTML не экранируется", text: "{{.tag}}", want: "<b>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.text, c)
+			if err != nil {
+				t.Fatalf("Render(%q) error = %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{name: "пустой шаблон", text: ""},
+		{name: "подстановки и условия", text: "{{if .a}}{{upper .a}}{{else}}{{date}}{{end}}"},
+		{name: "синтаксическая ошибка", text: "{{if .a}}", wantErr: "ошибка шаблона"},
+		{name: "неизвестная функция", text: "{{exec}}", wantErr: "ошибка шаблона"},
+		{name: "цикл", text: "{{range .a}}x{{end}}", wantErr: "циклы не поддерживаются"},
+		{name: "цикл внутри if", text: "{{if .a}}{{range .b}}{{end}}{{end}}", wantErr: "циклы не поддерживаются"},
+		{name: "define", text: `{{define "x"}}y{{end}}`, wantErr: "вложенные шаблоны не поддерживаются"},
+		{name: "template", text: `{{template "x"}}`, wantErr: "вложенные шаблоны не поддерживаются"},
+		{name: "printf", text: `{{printf "%0999999999d" 1}}`, wantErr: "функция printf недоступна"},
+		{name: "printf в скобках", text: `{{upper (printf "%s" .a)}}`, wantErr: "функция printf недоступна"},
+		{name: "printf в условии", text: `{{if (print .a)}}x{{end}}`, wantErr: "функция print недоступна"},
+		{name: "call", text: `{{call .a}}`, wantErr: "функция call недоступна"},
+		{name: "слишком большой", text: strings.Repeat("x", MaxTemplateSize+1), wantErr: "не может превышать"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.text)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && strings.HasPrefix(err.Error(), "template:") {
+				t.Errorf("Validate() error = %q, служебное имя не убрано", err)
+			}
+		})
+	}
+}
+
+func TestRenderOutputLimit(t *testing.T) {
+	big := strings.Repeat("x", maxOutputSize/2+1)
+	c := Context{Vars: map[string]string{"big": big}}
+
+	if _, err := Render("{{.big}}", c); err != nil {
+		t.Fatalf("Render() одной подстановки error = %v", err)
+	}
+	if _, err := Render("{{.big}}{{.big}}", c); !errors.Is(err, errOutputTooLarge) {
+		t.Errorf("Render() error = %v, want %v", err, errOutputTooLarge)
+	}
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader — заголовок ответа с идентификатором трассировки запроса
+const TraceIDHeader = "X-Trace-Id"
+
+// Middleware открывает серверный спан на каждый запрос, продолжая
+// трассировку из заголовка traceparent, и возвращает ее идентификатор
+// в заголовке X-Trace-Id. В текстовые ответы об ошибках (http.Error)
+// идентификатор также дописывается последней строкой.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		traceID := TraceID(ctx)
+		if traceID != "" {
+			w.Header().Set(TraceIDHeader, traceID)
+		}
+
+		tw := &traceResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(tw, r.WithContext(ctx))
+
+		status := tw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if tw.textError && traceID != "" {
+			w.Write([]byte("trace_id: " + traceID + "\n"))
+		}
+
+		// Имя спана — шаблон маршрута, известный только после маршрутизации
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// traceResponseWriter запоминает статус ответа и то, является ли он
+// текстовым сообщением об ошибке
+type traceResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	textError bool
+}
+
+func (w *traceResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.textError = status >= http.StatusBadRequest &&
+			strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *traceResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap дает http.ResponseController доступ к исходному writer
+func (w *traceResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
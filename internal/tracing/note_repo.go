@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+)
+
+// noteRepository оборачивает repo.NoteRepository и открывает спан на каждый вызов
+type noteRepository struct {
+	next repo.NoteRepository
+}
+
+// TraceNoteRepository возвращает хранилище заметок, вызовы которого
+// попадают в трассировку дочерними спанами NoteRepository.<метод>
+func TraceNoteRepository(next repo.NoteRepository) repo.NoteRepository {
+	return &noteRepository{next: next}
+}
+
+func (s *noteRepository) Create(ctx context.Context, note core.Note) (id int64, err error) {
+	ctx, end := start(ctx, "NoteRepository.Create")
+	defer end(&err)
+	return s.next.Create(ctx, note)
+}
+
+func (s *noteRepository) GetByID(ctx context.Context, id int64) (note *core.Note, err error) {
+	ctx, end := start(ctx, "NoteRepository.GetByID", noteAttr(id))
+	defer end(&err)
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *noteRepository) GetAll(ctx context.Context) (notes []core.Note, err error) {
+	ctx, end := start(ctx, "NoteRepository.GetAll")
+	defer end(&err)
+	return s.next.GetAll(ctx)
+}
+
+func (s *noteRepository) Update(ctx context.Context, id int64, note core.Note) (err error) {
+	ctx, end := start(ctx, "NoteRepository.Update", noteAttr(id))
+	defer end(&err)
+	return s.next.Update(ctx, id, note)
+}
+
+func (s *noteRepository) Delete(ctx context.Context, id int64) (err error) {
+	ctx, end := start(ctx, "NoteRepository.Delete", noteAttr(id))
+	defer end(&err)
+	return s.next.Delete(ctx, id)
+}
+
+func (s *noteRepository) Modify(ctx context.Context, id int64, fn func(note *core.Note) error) (note *core.Note, err error) {
+	ctx, end := start(ctx, "NoteRepository.Modify", noteAttr(id))
+	defer end(&err)
+	return s.next.Modify(ctx, id, fn)
+}
+
+func (s *noteRepository) Batch(ctx context.Context, ops []core.BatchOp, atomic bool) (results []core.BatchResult, err error) {
+	ctx, end := start(ctx, "NoteRepository.Batch")
+	defer end(&err)
+	return s.next.Batch(ctx, ops, atomic)
+}
+
+func (s *noteRepository) Import(ctx context.Context, notes []core.Note, replace bool, fn func(note *core.Note, ids map[int64]int64)) (created, deleted []int64, err error) {
+	ctx, end := start(ctx, "NoteRepository.Import")
+	defer end(&err)
+	return s.next.Import(ctx, notes, replace, fn)
+}
@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+)
+
+// noteService оборачивает service.NoteService и открывает спан на каждый вызов
+type noteService struct {
+	next service.NoteService
+}
+
+// TraceNoteService возвращает сервис заметок, вызовы которого попадают
+// в трассировку дочерними спанами NoteService.<метод>
+func TraceNoteService(next service.NoteService) service.NoteService {
+	return &noteService{next: next}
+}
+
+// noteAttr добавляет к спану идентификатор заметки
+func noteAttr(id int64) trace.SpanStartOption {
+	return trace.WithAttributes(attribute.Int64("note.id", id))
+}
+
+func (s *noteService) CreateNote(ctx context.Context, note core.Note) (id int64, err error) {
+	ctx, end := start(ctx, "NoteService.CreateNote")
+	defer end(&err)
+	return s.next.CreateNote(ctx, note)
+}
+
+func (s *noteService) GetNote(ctx context.Context, id int64) (note *core.Note, err error) {
+	ctx, end := start(ctx, "NoteService.GetNote", noteAttr(id))
+	defer end(&err)
+	return s.next.GetNote(ctx, id)
+}
+
+func (s *noteService) GetAllNotes(ctx context.Context) (notes []core.Note, err error) {
+	ctx, end := start(ctx, "NoteService.GetAllNotes")
+	defer end(&err)
+	return s.next.GetAllNotes(ctx)
+}
+
+func (s *noteService) ListNotes(ctx context.Context, filter core.NoteFilter) (notes []core.Note, err error) {
+	ctx, end := start(ctx, "NoteService.ListNotes")
+	defer end(&err)
+	return s.next.ListNotes(ctx, filter)
+}
+
+func (s *noteService) UpdateNote(ctx context.Context, id int64, updates service.UpdateNoteRequest) (err error) {
+	ctx, end := start(ctx, "NoteService.UpdateNote", noteAttr(id))
+	defer end(&err)
+	return s.next.UpdateNote(ctx, id, updates)
+}
+
+func (s *noteService) DeleteNote(ctx context.Context, id int64) (err error) {
+	ctx, end := start(ctx, "NoteService.DeleteNote", noteAttr(id))
+	defer end(&err)
+	return s.next.DeleteNote(ctx, id)
+}
+
+func (s *noteService) ApplyBatch(ctx context.Context, ops []core.BatchOp, atomic bool) (results []core.BatchResult, err error) {
+	ctx, end := start(ctx, "NoteService.ApplyBatch")
+	defer end(&err)
+	return s.next.ApplyBatch(ctx, ops, atomic)
+}
+
+func (s *noteService) RenderNoteHTML(ctx context.Context, id int64) (html string, err error) {
+	ctx, end := start(ctx, "NoteService.RenderNoteHTML", noteAttr(id))
+	defer end(&err)
+	return s.next.RenderNoteHTML(ctx, id)
+}
+
+func (s *noteService) SetNoteFlag(ctx context.Context, id int64, flag core.NoteFlag, value bool) (note *core.Note, err error) {
+	ctx, end := start(ctx, "NoteService.SetNoteFlag", noteAttr(id))
+	defer end(&err)
+	return s.next.SetNoteFlag(ctx, id, flag, value)
+}
+
+func (s *noteService) GetOutlinks(ctx context.Context, id int64) (links []core.NoteLink, err error) {
+	ctx, end := start(ctx, "NoteService.GetOutlinks", noteAttr(id))
+	defer end(&err)
+	return s.next.GetOutlinks(ctx, id)
+}
+
+func (s *noteService) GetBacklinks(ctx context.Context, id int64) (links []core.NoteLink, err error) {
+	ctx, end := start(ctx, "NoteService.GetBacklinks", noteAttr(id))
+	defer end(&err)
+	return s.next.GetBacklinks(ctx, id)
+}
+
+func (s *noteService) GetBrokenLinks(ctx context.Context) (links []core.NoteLink, err error) {
+	ctx, end := start(ctx, "NoteService.GetBrokenLinks")
+	defer end(&err)
+	return s.next.GetBrokenLinks(ctx)
+}
+
+func (s *noteService) RebuildLinks(ctx context.Context) (err error) {
+	ctx, end := start(ctx, "NoteService.RebuildLinks")
+	defer end(&err)
+	return s.next.RebuildLinks(ctx)
+}
+
+func (s *noteService) ImportNotes(ctx context.Context, notes []core.Note, opts service.ImportOptions) (result *service.ImportResult, err error) {
+	ctx, end := start(ctx, "NoteService.ImportNotes")
+	defer end(&err)
+	return s.next.ImportNotes(ctx, notes, opts)
+}
+
+func (s *noteService) AddChecklistItem(ctx context.Context, noteID int64, text string) (note *core.Note, err error) {
+	ctx, end := start(ctx, "NoteService.AddChecklistItem", noteAttr(noteID))
+	defer end(&err)
+	return s.next.AddChecklistItem(ctx, noteID, text)
+}
+
+func (s *noteService) ToggleChecklistItem(ctx context.Context, noteID, itemID int64) (note *core.Note, err error) {
+	ctx, end := start(ctx, "NoteService.ToggleChecklistItem", noteAttr(noteID))
+	defer end(&err)
+	return s.next.ToggleChecklistItem(ctx, noteID, itemID)
+}
+
+func (s *noteService) ReorderChecklistItems(ctx context.Context, noteID int64, itemIDs []int64) (note *core.Note, err error) {
+	ctx, end := start(ctx, "NoteService.ReorderChecklistItems", noteAttr(noteID))
+	defer end(&err)
+	return s.next.ReorderChecklistItems(ctx, noteID, itemIDs)
+}
+
+func (s *noteService) RemoveChecklistItem(ctx context.Context, noteID, itemID int64) (note *core.Note, err error) {
+	ctx, end := start(ctx, "NoteService.RemoveChecklistItem", noteAttr(noteID))
+	defer end(&err)
+	return s.next.RemoveChecklistItem(ctx, noteID, itemID)
+}
+
+func (s *noteService) CompleteReminder(ctx context.Context, id int64, at time.Time) (err error) {
+	ctx, end := start(ctx, "NoteService.CompleteReminder", noteAttr(id))
+	defer end(&err)
+	return s.next.CompleteReminder(ctx, id, at)
+}
+
+func (s *noteService) OnSave(hook service.NoteSaveHook) {
+	s.next.OnSave(hook)
+}
+
+func (s *noteService) OnDelete(hook service.NoteDeleteHook) {
+	s.next.OnDelete(hook)
+}
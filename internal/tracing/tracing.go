@@ -0,0 +1,125 @@
+// Package tracing настраивает OpenTelemetry: провайдер трассировки,
+// экспорт спанов (OTLP или stdout), распространение контекста W3C
+// traceparent, middleware для HTTP-запросов и декораторы сервиса
+// и хранилища заметок.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры спанов
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// instrumentation — имя трассировщика спанов сервера
+const instrumentation = "github.com/ybotet/pz12-notes-api"
+
+// Options — параметры трассировки
+type Options struct {
+	ServiceName string
+	// Exporter — none, stdout или otlp. Без экспортера спаны не отправляются,
+	// но идентификаторы трассировок создаются и распространяются.
+	Exporter string
+	// Endpoint — адрес OTLP/HTTP-приемника (host:port); пустой адрес
+	// берется из OTEL_EXPORTER_OTLP_ENDPOINT или localhost:4318
+	Endpoint string
+	Insecure bool
+	// SampleRatio — доля записываемых трассировок без решения вызывающего
+	SampleRatio float64
+}
+
+// Setup устанавливает глобальные провайдер трассировки и пропагатор.
+// Возвращенная функция отправляет накопленные спаны и останавливает экспорт.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tpOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	}
+
+	switch opts.Exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		tpOpts = append(tpOpts, sdktrace.WithBatcher(exp))
+	case ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		tpOpts = append(tpOpts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("неизвестный экспортер трассировки %q", opts.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(tpOpts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return tp.Shutdown, nil
+}
+
+// tracer возвращает трассировщик из глобального провайдера
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// TraceID возвращает идентификатор трассировки из контекста или пустую строку
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// start открывает дочерний спан; возвращенная функция вызывается через
+// defer с указателем на именованную ошибку и закрывает спан:
+//
+//	ctx, end := start(ctx, "NoteService.GetNote")
+//	defer end(&err)
+func start(ctx context.Context, name string, attrs ...trace.SpanStartOption) (context.Context, func(err *error)) {
+	ctx, span := tracer().Start(ctx, name, attrs...)
+	return ctx, func(err *error) {
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
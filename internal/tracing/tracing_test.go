@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/ybotet/pz12-notes-api/internal/core"
+	"github.com/ybotet/pz12-notes-api/internal/core/service"
+	"github.com/ybotet/pz12-notes-api/internal/repo"
+	"github.com/ybotet/pz12-notes-api/internal/validation"
+)
+
+const (
+	remoteTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	remoteSpanID  = "00f067aa0ba902b7"
+)
+
+// newRecorder устанавливает глобальный провайдер, записывающий спаны в память
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+// newTestServer собирает цепочку middleware → NoteService → NoteRepository,
+// как main, с заметкой 1
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+	notes := TraceNoteRepository(repo.NewNoteRepoMem())
+	s := TraceNoteService(service.NewNoteService(notes, repo.NewLinkRepoMem(), repo.NewCommentRepoMem(), validation.New(validation.DefaultRules())))
+	if _, err := s.CreateNote(context.Background(), core.Note{Title: "План"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/notes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if _, err := s.GetNote(r.Context(), id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		}
+	})
+	r.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "сбой", http.StatusInternalServerError)
+	})
+	return r
+}
+
+func TestMiddlewarePropagation(t *testing.T) {
+	recorder := newRecorder(t)
+	h := newTestServer(t)
+	recorder.Reset()
+
+	req := httptest.NewRequest(http.MethodGet, "/notes/1", nil)
+	req.Header.Set("traceparent", "00-"+remoteTraceID+"-"+remoteSpanID+"-01")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get(TraceIDHeader); got != remoteTraceID {
+		t.Errorf("%s = %q, want %q", TraceIDHeader, got, remoteTraceID)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() != remoteTraceID {
+			t.Errorf("спан %s в трассировке %s, want %s", span.Name(), span.SpanContext().TraceID(), remoteTraceID)
+		}
+		spans[span.Name()] = span
+	}
+
+	// Каждый спан — дочерний для предыдущего, серверный — для вызывающего
+	chain := []string{"GET /notes/{id}", "NoteService.GetNote", "NoteRepository.GetByID"}
+	parent := remoteSpanID
+	for _, name := range chain {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("нет спана %s среди %v", name, recorder.Ended())
+		}
+		if got := span.Parent().SpanID().String(); got != parent {
+			t.Errorf("родитель %s = %s, want %s", name, got, parent)
+		}
+		parent = span.SpanContext().SpanID().String()
+	}
+	if !spans[chain[0]].Parent().IsRemote() {
+		t.Error("серверный спан не продолжает удаленную трассировку")
+	}
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantSpan   string
+	}{
+		{name: "заметка не найдена", path: "/notes/999", wantStatus: http.StatusNotFound, wantSpan: "NoteService.GetNote"},
+		{name: "ошибка сервера", path: "/panic", wantStatus: http.StatusInternalServerError, wantSpan: "GET /panic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newRecorder(t)
+			h := newTestServer(t)
+			recorder.Reset()
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			// Без traceparent начинается новая трассировка
+			traceID := rec.Header().Get(TraceIDHeader)
+			if len(traceID) != 32 || traceID == remoteTraceID {
+				t.Fatalf("%s = %q", TraceIDHeader, traceID)
+			}
+			if body := rec.Body.String(); !strings.HasSuffix(body, "trace_id: "+traceID+"\n") {
+				t.Errorf("тело = %q, нет идентификатора трассировки", body)
+			}
+
+			var found bool
+			for _, span := range recorder.Ended() {
+				if span.Name() == tt.wantSpan {
+					found = true
+					if span.Status().Code != codes.Error {
+						t.Errorf("статус спана %s = %v, want %v", span.Name(), span.Status().Code, codes.Error)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("нет спана %s", tt.wantSpan)
+			}
+		})
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	if got := TraceID(context.Background()); got != "" {
+		t.Errorf("TraceID() без спана = %q, want пустую строку", got)
+	}
+}
@@ -0,0 +1,239 @@
+// Package validation проверяет текстовые поля по настраиваемым правилам.
+//
+// Длина измеряется в символах (рунах), а не в байтах, поэтому лимиты
+// одинаково работают для латиницы и кириллицы. Проверка собирает все
+// нарушения сразу и возвращает их как Errors.
+package validation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Имена полей, для которых заданы правила по умолчанию
+const (
+	FieldTitle         = "title"
+	FieldContent       = "content"
+	FieldChecklistItem = "checklist_item"
+	FieldComment       = "comment"
+	FieldTemplateName  = "template_name"
+	FieldTag           = "tag"
+)
+
+// Коды нарушений
+const (
+	CodeRequired       = "required"
+	CodeMinLength      = "min_length"
+	CodeMaxLength      = "max_length"
+	CodeForbiddenChars = "forbidden_chars"
+	CodeInvalidUTF8    = "invalid_utf8"
+)
+
+// Rule задает ограничения для одного поля. Нулевые значения не ограничивают.
+type Rule struct {
+	Required bool `yaml:"required" json:"required"`
+	// MinLength и MaxLength — длина в символах после обрезки пробелов
+	MinLength int `yaml:"min_length" json:"min_length,omitempty"`
+	MaxLength int `yaml:"max_length" json:"max_length,omitempty"`
+	// ForbiddenChars — символы, которые не могут встречаться в значении
+	ForbiddenChars string `yaml:"forbidden_chars" json:"forbidden_chars,omitempty"`
+}
+
+// Rules сопоставляет имя поля с правилом
+type Rules map[string]Rule
+
+// DefaultRules возвращает правила, действующие без конфигурации
+func DefaultRules() Rules {
+	return Rules{
+		FieldTitle:         {Required: true, MaxLength: 200, ForbiddenChars: "\n\r\t"},
+		FieldContent:       {MaxLength: 1000},
+		FieldChecklistItem: {Required: true, MaxLength: 500, ForbiddenChars: "\n\r"},
+		FieldComment:       {Required: true, MaxLength: 2000},
+		FieldTemplateName:  {Required: true, MaxLength: 200, ForbiddenChars: "\n\r\t"},
+		FieldTag:           {Required: true, MaxLength: 50, ForbiddenChars: ",\n\r\t"},
+	}
+}
+
+// LoadRules читает правила из YAML-файла вида
+//
+//	title:
+//	  max_length: 120
+//	  forbidden_chars: "<>"
+//
+// Указанные в файле свойства заменяют свойства правила по умолчанию
+// по одному: в примере заголовок остается обязательным. Поля, не указанные
+// в файле, сохраняют правила по умолчанию целиком.
+func LoadRules(path string) (Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded map[string]ruleOverride
+	if err := yaml.UnmarshalStrict(data, &loaded); err != nil {
+		return nil, fmt.Errorf("неверный файл правил валидации: %w", err)
+	}
+
+	rules := DefaultRules()
+	for field, override := range loaded {
+		rule := override.apply(rules[field])
+		if rule.MinLength < 0 || rule.MaxLength < 0 || (rule.MaxLength > 0 && rule.MinLength > rule.MaxLength) {
+			return nil, fmt.Errorf("неверные ограничения длины для поля %s", field)
+		}
+		rules[field] = rule
+	}
+	return rules, nil
+}
+
+// ruleOverride — правило из файла; nil означает, что свойство не указано
+type ruleOverride struct {
+	Required       *bool   `yaml:"required"`
+	MinLength      *int    `yaml:"min_length"`
+	MaxLength      *int    `yaml:"max_length"`
+	ForbiddenChars *string `yaml:"forbidden_chars"`
+}
+
+// apply заменяет в rule указанные свойства
+func (o ruleOverride) apply(rule Rule) Rule {
+	if o.Required != nil {
+		rule.Required = *o.Required
+	}
+	if o.MinLength != nil {
+		rule.MinLength = *o.MinLength
+	}
+	if o.MaxLength != nil {
+		rule.MaxLength = *o.MaxLength
+	}
+	if o.ForbiddenChars != nil {
+		rule.ForbiddenChars = *o.ForbiddenChars
+	}
+	return rule
+}
+
+// FieldError описывает нарушение правила одним полем
+// @Description Ошибка проверки поля
+type FieldError struct {
+	Field   string `json:"field" example:"title"`
+	Code    string `json:"code" example:"max_length"`
+	Message string `json:"message" example:"не может превышать 200 символов"`
+}
+
+// Errors — все нарушения, найденные при проверке
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validator проверяет поля по набору правил. Правила можно заменить
+// на лету через SetRules; начатые проверки используют прежний набор.
+type Validator struct {
+	mu    sync.RWMutex
+	rules Rules
+}
+
+// New создает валидатор; поля без правила в rules не проверяются
+func New(rules Rules) *Validator {
+	return &Validator{rules: rules}
+}
+
+// SetRules заменяет набор правил
+func (v *Validator) SetRules(rules Rules) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules = rules
+}
+
+// Rule возвращает правило поля
+func (v *Validator) Rule(field string) Rule {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.rules[field]
+}
+
+// Check начинает проверку набора полей
+func (v *Validator) Check() *Check {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return &Check{rules: v.rules}
+}
+
+// Check накапливает нарушения по нескольким полям
+type Check struct {
+	rules Rules
+	errs  Errors
+}
+
+// Field проверяет значение поля. Значение сравнивается после обрезки
+// крайних пробелов; вызывающий сохраняет обрезанное значение сам.
+func (c *Check) Field(field, value string) *Check {
+	return c.field(field, field, value)
+}
+
+// Item проверяет элемент списка; в ошибке поле указывается как field[index]
+func (c *Check) Item(field string, index int, value string) *Check {
+	return c.field(field, fmt.Sprintf("%s[%d]", field, index), value)
+}
+
+// Add добавляет нарушение, найденное вне правил
+func (c *Check) Add(field, code, message string) *Check {
+	c.errs = append(c.errs, FieldError{Field: field, Code: code, Message: message})
+	return c
+}
+
+// Err возвращает Errors, если найдено хотя бы одно нарушение
+func (c *Check) Err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return c.errs
+}
+
+func (c *Check) field(ruleName, field, value string) *Check {
+	rule, ok := c.rules[ruleName]
+	if !ok {
+		return c
+	}
+
+	value = strings.TrimSpace(value)
+	if !utf8.ValidString(value) {
+		return c.Add(field, CodeInvalidUTF8, "содержит недопустимую последовательность байт")
+	}
+
+	length := utf8.RuneCountInString(value)
+	if length == 0 {
+		if rule.Required {
+			c.Add(field, CodeRequired, "не может быть пустым")
+		}
+		return c
+	}
+	if rule.MinLength > 0 && length < rule.MinLength {
+		c.Add(field, CodeMinLength, fmt.Sprintf("не может быть короче %d символов", rule.MinLength))
+	}
+	if rule.MaxLength > 0 && length > rule.MaxLength {
+		c.Add(field, CodeMaxLength, fmt.Sprintf("не может превышать %d символов", rule.MaxLength))
+	}
+	if i := strings.IndexAny(value, rule.ForbiddenChars); rule.ForbiddenChars != "" && i >= 0 {
+		r, _ := utf8.DecodeRuneInString(value[i:])
+		c.Add(field, CodeForbiddenChars, "содержит недопустимый символ "+displayRune(r))
+	}
+	return c
+}
+
+// displayRune показывает символ в кавычках, а непечатаемый — кодом U+XXXX
+func displayRune(r rune) string {
+	if unicode.IsPrint(r) {
+		return "«" + string(r) + "»"
+	}
+	return fmt.Sprintf("%U", r)
+}
@@ -0,0 +1,186 @@
+package validation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		field   string
+		want    Rule
+		wantErr string
+	}{
+		{
+			name:  "частичное переопределение сохраняет обязательность",
+			yaml:  "title:\n  max_length: 120\n",
+			field: "title",
+			want:  Rule{Required: true, MaxLength: 120, ForbiddenChars: "\n\r\t"},
+		},
+		{
+			name:  "явное отключение обязательности",
+			yaml:  "comment:\n  required: false\n",
+			field: "comment",
+			want:  Rule{MaxLength: 2000},
+		},
+		{
+			name:  "пустой список запрещенных символов",
+			yaml:  "title:\n  forbidden_chars: \"\"\n",
+			field: "title",
+			want:  Rule{Required: true, MaxLength: 200},
+		},
+		{
+			name:  "поле не указано",
+			yaml:  "title:\n  min_length: 3\n",
+			field: "content",
+			want:  Rule{MaxLength: 1000},
+		},
+		{
+			name:  "новое поле",
+			yaml:  "nickname:\n  required: true\n  max_length: 50\n",
+			field: "nickname",
+			want:  Rule{Required: true, MaxLength: 50},
+		},
+		{
+			name:    "минимум больше унаследованного максимума",
+			yaml:    "title:\n  min_length: 300\n",
+			wantErr: "неверные ограничения длины для поля title",
+		},
+		{
+			name:    "неизвестное свойство",
+			yaml:    "title:\n  max_lenght: 10\n",
+			wantErr: "неверный файл правил валидации",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			rules, err := LoadRules(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRules() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadRules() error = %v", err)
+			}
+			if got := rules[tt.field]; got != tt.want {
+				t.Errorf("rules[%q] = %+v, want %+v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckField(t *testing.T) {
+	rules := Rules{
+		"title": {Required: true, MinLength: 3, MaxLength: 5, ForbiddenChars: "\n<"},
+		"note":  {},
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		value    string
+		wantCode []string
+		wantMsg  string
+	}{
+		{name: "верное значение", field: "title", value: "План"},
+		{name: "пробелы по краям не считаются", field: "title", value: "  План\t "},
+		{name: "длина в символах, а не байтах", field: "title", value: "Пятый"},
+		{name: "пустое", field: "title", value: "   ", wantCode: []string{CodeRequired}, wantMsg: "не может быть пустым"},
+		{name: "короткое", field: "title", value: "ab", wantCode: []string{CodeMinLength}, wantMsg: "короче 3 символов"},
+		{name: "длинное", field: "title", value: "Шестой", wantCode: []string{CodeMaxLength}, wantMsg: "превышать 5 символов"},
+		{name: "запрещенный символ", field: "title", value: "a<b", wantCode: []string{CodeForbiddenChars}, wantMsg: "символ «<»"},
+		{name: "непечатаемый символ кодом", field: "title", value: "a\nb", wantCode: []string{CodeForbiddenChars}, wantMsg: "символ U+000A"},
+		{name: "несколько нарушений", field: "title", value: "a<bcdef", wantCode: []string{CodeMaxLength, CodeForbiddenChars}},
+		{name: "неверный UTF-8", field: "title", value: "a\xffb", wantCode: []string{CodeInvalidUTF8}},
+		{name: "пустое необязательное", field: "note", value: ""},
+		{name: "поле без правила", field: "other", value: strings.Repeat("x", 10000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(rules).Check().Field(tt.field, tt.value).Err()
+			if len(tt.wantCode) == 0 {
+				if err != nil {
+					t.Errorf("Field(%q) error = %v", tt.value, err)
+				}
+				return
+			}
+
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Field(%q) error = %v, want Errors", tt.value, err)
+			}
+			var codes []string
+			for _, fe := range errs {
+				if fe.Field != tt.field {
+					t.Errorf("Field = %q, want %q", fe.Field, tt.field)
+				}
+				codes = append(codes, fe.Code)
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.wantCode, ",") {
+				t.Errorf("коды = %v, want %v", codes, tt.wantCode)
+			}
+			if !strings.Contains(errs[0].Message, tt.wantMsg) {
+				t.Errorf("Message = %q, want %q", errs[0].Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestCheckCollectsErrors(t *testing.T) {
+	err := New(DefaultRules()).Check().
+		Field(FieldTitle, "").
+		Item(FieldTag, 0, "go").
+		Item(FieldTag, 1, "a,b").
+		Add("due", "past", "не может быть в прошлом").
+		Err()
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Err() = %v, want Errors", err)
+	}
+	want := Errors{
+		{Field: FieldTitle, Code: CodeRequired, Message: "не может быть пустым"},
+		{Field: "tag[1]", Code: CodeForbiddenChars, Message: "содержит недопустимый символ «,»"},
+		{Field: "due", Code: "past", Message: "не может быть в прошлом"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Err() = %v, want %v", errs, want)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Errorf("errs[%d] = %+v, want %+v", i, errs[i], want[i])
+		}
+	}
+	wantMsg := "title: не может быть пустым; tag[1]: содержит недопустимый символ «,»; due: не может быть в прошлом"
+	if err.Error() != wantMsg {
+		t.Errorf("Error() = %q, want %q", err.Error(), wantMsg)
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	v := New(Rules{"title": {MaxLength: 3}})
+	started := v.Check()
+
+	v.SetRules(Rules{"title": {MaxLength: 10}})
+	if got := v.Rule("title").MaxLength; got != 10 {
+		t.Errorf("Rule().MaxLength = %d, want 10", got)
+	}
+	if err := v.Check().Field("title", "Заметка").Err(); err != nil {
+		t.Errorf("проверка по новым правилам: %v", err)
+	}
+	// Начатая проверка использует прежний набор
+	if err := started.Field("title", "Заметка").Err(); err == nil {
+		t.Error("начатая проверка использовала новые правила")
+	}
+}